@@ -6,8 +6,11 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/mcp/client"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
 )
 
 // Create
@@ -49,7 +52,21 @@ func (a *AutoCodeTemplateApi) MCPList(c *gin.Context) {
 
 	baseUrl := fmt.Sprintf("http://127.0.0.1:%d%s", global.GVA_CONFIG.System.Addr, global.GVA_CONFIG.MCP.SSEPath)
 
-	testClient, err := client.NewClient(baseUrl, "testClient", "v1.0.0", global.GVA_CONFIG.MCP.Name)
+	// 为当前用户签发MCP专用令牌 供外部MCP客户端配置使用
+	j := utils.NewJWT()
+	mcpToken, err := j.CreateToken(j.CreateMcpClaims(utils.GetUserInfo(c).BaseClaims))
+	if err != nil {
+		global.GVA_LOG.Error("签发MCP令牌失败!", zap.Error(err))
+		response.FailWithMessage("签发MCP令牌失败", c)
+		return
+	}
+
+	testClient, err := client.NewClient(baseUrl, "testClient", "v1.0.0", global.GVA_CONFIG.MCP.Name,
+		transport.WithHeaders(map[string]string{"x-token": utils.GetToken(c)}))
+	if err != nil {
+		response.FailWithMessage("创建MCP客户端失败:"+err.Error(), c)
+		return
+	}
 	defer testClient.Close()
 	toolsRequest := mcp.ListToolsRequest{}
 
@@ -63,8 +80,11 @@ func (a *AutoCodeTemplateApi) MCPList(c *gin.Context) {
 
 	mcpServerConfig := map[string]interface{}{
 		"mcpServers": map[string]interface{}{
-			global.GVA_CONFIG.MCP.Name: map[string]string{
+			global.GVA_CONFIG.MCP.Name: map[string]interface{}{
 				"url": baseUrl,
+				"headers": map[string]string{
+					"x-token": mcpToken,
+				},
 			},
 		},
 	}
@@ -98,7 +118,8 @@ func (a *AutoCodeTemplateApi) MCPTest(c *gin.Context) {
 
 	// 创建MCP客户端
 	baseUrl := fmt.Sprintf("http://127.0.0.1:%d%s", global.GVA_CONFIG.System.Addr, global.GVA_CONFIG.MCP.SSEPath)
-	testClient, err := client.NewClient(baseUrl, "testClient", "v1.0.0", global.GVA_CONFIG.MCP.Name)
+	testClient, err := client.NewClient(baseUrl, "testClient", "v1.0.0", global.GVA_CONFIG.MCP.Name,
+		transport.WithHeaders(map[string]string{"x-token": utils.GetToken(c)}))
 	if err != nil {
 		response.FailWithMessage("创建MCP客户端失败:"+err.Error(), c)
 		return
@@ -23,6 +23,10 @@ type ApiGroup struct {
 	AutoCodeTemplateApi
 	SysParamsApi
 	SysVersionApi
+	DeptApi
+	TenantApi
+	AuthorityGrantApi
+	SysJobApi
 }
 
 var (
@@ -46,4 +50,12 @@ var (
 	autoCodeHistoryService  = service.ServiceGroupApp.SystemServiceGroup.AutoCodeHistory
 	autoCodeTemplateService = service.ServiceGroupApp.SystemServiceGroup.AutoCodeTemplate
 	sysVersionService       = service.ServiceGroupApp.SystemServiceGroup.SysVersionService
+	userTotpService         = service.ServiceGroupApp.SystemServiceGroup.UserTotpService
+	deptService             = service.ServiceGroupApp.SystemServiceGroup.DeptService
+	userSessionService      = service.ServiceGroupApp.SystemServiceGroup.UserSessionService
+	oidcService             = service.ServiceGroupApp.SystemServiceGroup.OidcService
+	userTokenService        = service.ServiceGroupApp.SystemServiceGroup.UserTokenService
+	tenantService           = service.ServiceGroupApp.SystemServiceGroup.TenantService
+	authorityGrantService   = service.ServiceGroupApp.SystemServiceGroup.AuthorityGrantService
+	jobService              = service.ServiceGroupApp.SystemServiceGroup.JobService
 )
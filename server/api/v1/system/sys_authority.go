@@ -39,6 +39,11 @@ func (a *AuthorityApi) CreateAuthority(c *gin.Context) {
 	if *authority.ParentId == 0 && global.GVA_CONFIG.System.UseStrictAuth {
 		authority.ParentId = utils.Pointer(utils.GetUserAuthorityId(c))
 	}
+	if !checkTenantAuthorities(c, *authority.ParentId) {
+		return
+	}
+	// 租户管理员创建的角色属于本租户
+	authority.TenantID = utils.GetUserTenantId(c)
 
 	if authBack, err = authorityService.CreateAuthority(authority); err != nil {
 		global.GVA_LOG.Error("创建失败!", zap.Error(err))
@@ -80,6 +85,14 @@ func (a *AuthorityApi) CopyAuthority(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	authorityIds := []uint{copyInfo.OldAuthorityId}
+	if copyInfo.Authority.ParentId != nil {
+		authorityIds = append(authorityIds, *copyInfo.Authority.ParentId)
+	}
+	if !checkTenantAuthorities(c, authorityIds...) {
+		return
+	}
+	copyInfo.Authority.TenantID = utils.GetUserTenantId(c)
 	adminAuthorityID := utils.GetUserAuthorityId(c)
 	authBack, err := authorityService.CopyAuthority(adminAuthorityID, copyInfo)
 	if err != nil {
@@ -87,6 +100,12 @@ func (a *AuthorityApi) CopyAuthority(c *gin.Context) {
 		response.FailWithMessage("拷贝失败"+err.Error(), c)
 		return
 	}
+	// 开启域模型时按新的父角色同步角色继承关系
+	if utils.CasbinDomainEnabled() {
+		if err = casbinService.FreshCasbin(); err != nil {
+			global.GVA_LOG.Error("权限刷新失败!", zap.Error(err))
+		}
+	}
 	response.OkWithDetailed(systemRes.SysAuthorityResponse{Authority: authBack}, "拷贝成功", c)
 }
 
@@ -110,6 +129,9 @@ func (a *AuthorityApi) DeleteAuthority(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	if !checkTenantAuthorities(c, authority.AuthorityId) {
+		return
+	}
 	// 删除角色之前需要判断是否有用户正在使用此角色
 	if err = authorityService.DeleteAuthority(&authority); err != nil {
 		global.GVA_LOG.Error("删除失败!", zap.Error(err))
@@ -141,12 +163,25 @@ func (a *AuthorityApi) UpdateAuthority(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	authorityIds := []uint{auth.AuthorityId}
+	if auth.ParentId != nil {
+		authorityIds = append(authorityIds, *auth.ParentId)
+	}
+	if !checkTenantAuthorities(c, authorityIds...) {
+		return
+	}
 	authority, err := authorityService.UpdateAuthority(auth)
 	if err != nil {
 		global.GVA_LOG.Error("更新失败!", zap.Error(err))
 		response.FailWithMessage("更新失败"+err.Error(), c)
 		return
 	}
+	// 开启域模型时按新的父角色同步角色继承关系
+	if utils.CasbinDomainEnabled() {
+		if err = casbinService.FreshCasbin(); err != nil {
+			global.GVA_LOG.Error("权限刷新失败!", zap.Error(err))
+		}
+	}
 	response.OkWithDetailed(systemRes.SysAuthorityResponse{Authority: authority}, "更新成功", c)
 }
 
@@ -161,7 +196,7 @@ func (a *AuthorityApi) UpdateAuthority(c *gin.Context) {
 // @Router    /authority/getAuthorityList [post]
 func (a *AuthorityApi) GetAuthorityList(c *gin.Context) {
 	authorityID := utils.GetUserAuthorityId(c)
-	list, err := authorityService.GetAuthorityInfoList(authorityID)
+	list, err := authorityService.GetAuthorityInfoList(authorityID, utils.GetUserTenantId(c))
 	if err != nil {
 		global.GVA_LOG.Error("获取失败!", zap.Error(err))
 		response.FailWithMessage("获取失败"+err.Error(), c)
@@ -191,6 +226,13 @@ func (a *AuthorityApi) SetDataAuthority(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	dataAuthorityIds := []uint{auth.AuthorityId}
+	for i := range auth.DataAuthorityId {
+		dataAuthorityIds = append(dataAuthorityIds, auth.DataAuthorityId[i].AuthorityId)
+	}
+	if !checkTenantAuthorities(c, dataAuthorityIds...) {
+		return
+	}
 	adminAuthorityID := utils.GetUserAuthorityId(c)
 	err = authorityService.SetDataAuthority(adminAuthorityID, auth)
 	if err != nil {
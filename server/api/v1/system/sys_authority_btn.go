@@ -51,6 +51,9 @@ func (a *AuthorityBtnApi) SetAuthorityBtn(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	if !checkTenantAuthorities(c, req.AuthorityId) {
+		return
+	}
 	err = authorityBtnService.SetAuthorityBtn(req)
 	if err != nil {
 		global.GVA_LOG.Error("分配失败!", zap.Error(err))
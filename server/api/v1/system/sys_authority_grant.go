@@ -0,0 +1,144 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AuthorityGrantApi struct{}
+
+// CreateAuthorityGrant
+// @Tags      AuthorityGrant
+// @Summary   创建临时授权
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.CreateAuthorityGrant                                true  "用户ID, 角色ID, 开始时间, 结束时间, 是否需要审批"
+// @Success   200   {object}  response.Response{data=system.SysAuthorityGrant,msg=string}  "创建临时授权 无需审批时到开始时间即生效"
+// @Router    /authorityGrant/createAuthorityGrant [post]
+func (a *AuthorityGrantApi) CreateAuthorityGrant(c *gin.Context) {
+	var req systemReq.CreateAuthorityGrant
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if !checkTenantUsers(c, req.UserID) || !checkTenantAuthorities(c, req.AuthorityId) {
+		return
+	}
+	grant, err := authorityGrantService.CreateGrant(utils.GetUserID(c), utils.GetUserAuthorityId(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("创建失败!", zap.Error(err))
+		response.FailWithMessage("创建失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(grant, "创建成功", c)
+}
+
+// ReviewAuthorityGrant
+// @Tags      AuthorityGrant
+// @Summary   审批临时授权
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.ReviewAuthorityGrant  true  "临时授权ID, 是否批准, 审批意见"
+// @Success   200   {object}  response.Response{msg=string}   "审批临时授权 审批人需拥有比授予角色更高级别的角色"
+// @Router    /authorityGrant/reviewAuthorityGrant [post]
+func (a *AuthorityGrantApi) ReviewAuthorityGrant(c *gin.Context) {
+	var req systemReq.ReviewAuthorityGrant
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if !checkGrantTenant(c, req.ID) {
+		return
+	}
+	if err := authorityGrantService.ReviewGrant(utils.GetUserID(c), utils.GetUserAuthorityId(c), req); err != nil {
+		global.GVA_LOG.Error("审批失败!", zap.Error(err))
+		response.FailWithMessage("审批失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("审批成功", c)
+}
+
+// RevokeAuthorityGrant
+// @Tags      AuthorityGrant
+// @Summary   撤销临时授权
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.RevokeAuthorityGrant  true  "临时授权ID, 撤销原因"
+// @Success   200   {object}  response.Response{msg=string}   "撤销临时授权 生效中的授权收回角色并使用户重新登录"
+// @Router    /authorityGrant/revokeAuthorityGrant [post]
+func (a *AuthorityGrantApi) RevokeAuthorityGrant(c *gin.Context) {
+	var req systemReq.RevokeAuthorityGrant
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if !checkGrantTenant(c, req.ID) {
+		return
+	}
+	if err := authorityGrantService.RevokeGrant(utils.GetUserID(c), req); err != nil {
+		global.GVA_LOG.Error("撤销失败!", zap.Error(err))
+		response.FailWithMessage("撤销失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("撤销成功", c)
+}
+
+// GetAuthorityGrantList
+// @Tags      AuthorityGrant
+// @Summary   分页获取临时授权
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     systemReq.GetAuthorityGrantList                         true  "页码, 每页大小, 用户ID, 角色ID, 状态"
+// @Success   200   {object}  response.Response{data=response.PageResult,msg=string}  "分页获取临时授权及审计记录"
+// @Router    /authorityGrant/getAuthorityGrantList [get]
+func (a *AuthorityGrantApi) GetAuthorityGrantList(c *gin.Context) {
+	var req systemReq.GetAuthorityGrantList
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if utils.GetUserTenantId(c) != 0 {
+		// 租户管理员只能查看本租户用户的授权
+		if req.UserID == 0 {
+			response.FailWithMessage("请指定用户", c)
+			return
+		}
+		if !checkTenantUsers(c, req.UserID) {
+			return
+		}
+	}
+	list, total, err := authorityGrantService.GetGrantList(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// checkGrantTenant 租户管理员只能操作本租户用户的授权 失败时已写入响应
+func checkGrantTenant(c *gin.Context, id uint) bool {
+	if utils.GetUserTenantId(c) == 0 {
+		return true
+	}
+	var grant system.SysAuthorityGrant
+	if err := global.GVA_DB.Select("user_id").First(&grant, id).Error; err != nil {
+		response.FailWithMessage("临时授权不存在", c)
+		return false
+	}
+	return checkTenantUsers(c, grant.UserID)
+}
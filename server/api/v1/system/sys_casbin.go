@@ -18,7 +18,7 @@ type CasbinApi struct{}
 // @Security  ApiKeyAuth
 // @accept    application/json
 // @Produce   application/json
-// @Param     data  body      request.CasbinInReceive        true  "权限id, 权限模型列表"
+// @Param     data  body      request.CasbinInReceive        true  "权限id, 域, 权限模型列表"
 // @Success   200   {object}  response.Response{msg=string}  "更新角色api权限"
 // @Router    /casbin/UpdateCasbin [post]
 func (cas *CasbinApi) UpdateCasbin(c *gin.Context) {
@@ -33,8 +33,11 @@ func (cas *CasbinApi) UpdateCasbin(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	if !checkTenantAuthorities(c, cmr.AuthorityId) {
+		return
+	}
 	adminAuthorityID := utils.GetUserAuthorityId(c)
-	err = casbinService.UpdateCasbin(adminAuthorityID, cmr.AuthorityId, cmr.CasbinInfos)
+	err = casbinService.UpdateCasbin(adminAuthorityID, cmr.AuthorityId, cmr.Domain, cmr.CasbinInfos)
 	if err != nil {
 		global.GVA_LOG.Error("更新失败!", zap.Error(err))
 		response.FailWithMessage("更新失败", c)
@@ -64,6 +67,38 @@ func (cas *CasbinApi) GetPolicyPathByAuthorityId(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
-	paths := casbinService.GetPolicyPathByAuthorityId(casbin.AuthorityId)
+	paths := casbinService.GetPolicyPathByAuthorityId(casbin.AuthorityId, casbin.Domain)
 	response.OkWithDetailed(systemRes.PolicyPathResponse{Paths: paths}, "获取成功", c)
 }
+
+// ExplainPermission
+// @Tags      Casbin
+// @Summary   权限模拟 说明用户或角色访问接口时放行或拒绝的原因
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.PermissionExplain                                       true  "用户ID, 角色ID, 接口路径, 请求方法"
+// @Success   200   {object}  response.Response{data=systemRes.PermissionExplain,msg=string}  "模拟结果, 包括命中的策略、可见的菜单按钮及数据权限"
+// @Router    /casbin/explainPermission [post]
+func (cas *CasbinApi) ExplainPermission(c *gin.Context) {
+	var req request.PermissionExplain
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if req.UserID == 0 && req.AuthorityId == 0 {
+		response.FailWithMessage("用户和角色不能同时为空", c)
+		return
+	}
+	if !checkTenantUsers(c, req.UserID) || !checkTenantAuthorities(c, req.AuthorityId) {
+		return
+	}
+	res, err := casbinService.ExplainPermission(req)
+	if err != nil {
+		global.GVA_LOG.Error("权限模拟失败!", zap.Error(err))
+		response.FailWithMessage("权限模拟失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(res, "获取成功", c)
+}
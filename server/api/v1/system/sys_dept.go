@@ -0,0 +1,159 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type DeptApi struct{}
+
+// CreateDept
+// @Tags      SysDept
+// @Summary   创建部门
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      system.SysDept                 true  "部门名称, 父部门ID"
+// @Success   200   {object}  response.Response{msg=string}  "创建部门"
+// @Router    /dept/createDept [post]
+func (d *DeptApi) CreateDept(c *gin.Context) {
+	var dept system.SysDept
+	if err := c.ShouldBindJSON(&dept); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(dept, utils.DeptVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := deptService.CreateDept(&dept); err != nil {
+		global.GVA_LOG.Error("创建失败!", zap.Error(err))
+		response.FailWithMessage("创建失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(dept, "创建成功", c)
+}
+
+// DeleteDept
+// @Tags      SysDept
+// @Summary   删除部门
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "部门ID"
+// @Success   200   {object}  response.Response{msg=string}  "删除部门"
+// @Router    /dept/deleteDept [post]
+func (d *DeptApi) DeleteDept(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(req, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := deptService.DeleteDept(req.Uint()); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// UpdateDept
+// @Tags      SysDept
+// @Summary   更新部门
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      system.SysDept                 true  "部门信息"
+// @Success   200   {object}  response.Response{msg=string}  "更新部门"
+// @Router    /dept/updateDept [put]
+func (d *DeptApi) UpdateDept(c *gin.Context) {
+	var dept system.SysDept
+	if err := c.ShouldBindJSON(&dept); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(dept, utils.DeptVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := deptService.UpdateDept(dept); err != nil {
+		global.GVA_LOG.Error("更新失败!", zap.Error(err))
+		response.FailWithMessage("更新失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+// FindDept
+// @Tags      SysDept
+// @Summary   根据ID获取部门
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     request.GetById                                true  "部门ID"
+// @Success   200   {object}  response.Response{data=system.SysDept,msg=string}  "部门详情"
+// @Router    /dept/findDept [get]
+func (d *DeptApi) FindDept(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	dept, err := deptService.GetDeptById(req.Uint())
+	if err != nil {
+		global.GVA_LOG.Error("查询失败!", zap.Error(err))
+		response.FailWithMessage("查询失败", c)
+		return
+	}
+	response.OkWithData(dept, c)
+}
+
+// GetDeptTree
+// @Tags      SysDept
+// @Summary   获取部门树
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=[]system.SysDept,msg=string}  "部门树"
+// @Router    /dept/getDeptTree [get]
+func (d *DeptApi) GetDeptTree(c *gin.Context) {
+	tree, err := deptService.GetDeptTree()
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(tree, "获取成功", c)
+}
+
+// SetUserDept
+// @Tags      SysDept
+// @Summary   设置用户所属部门
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.SetUserDept          true  "用户ID, 部门ID"
+// @Success   200   {object}  response.Response{msg=string}  "设置用户所属部门"
+// @Router    /dept/setUserDept [post]
+func (d *DeptApi) SetUserDept(c *gin.Context) {
+	var req systemReq.SetUserDept
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := deptService.SetUserDept(req.UserId, req.DeptId); err != nil {
+		global.GVA_LOG.Error("设置失败!", zap.Error(err))
+		response.FailWithMessage("设置失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("设置成功", c)
+}
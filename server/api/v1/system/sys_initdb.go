@@ -35,6 +35,10 @@ func (i *DBApi) InitDB(c *gin.Context) {
 		response.FailWithMessage("自动创建数据库失败，请查看后台日志，检查后在进行初始化", c)
 		return
 	}
+	// 初始化时写入的定时任务 无需重启即开始调度
+	if _, err := jobService.LoadJobs(); err != nil {
+		global.GVA_LOG.Error("加载定时任务失败!", zap.Error(err))
+	}
 	response.OkWithMessage("自动创建数据库成功", c)
 }
 
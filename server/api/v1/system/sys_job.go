@@ -0,0 +1,278 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SysJobApi struct{}
+
+// CreateSysJob
+// @Tags      SysJob
+// @Summary   创建定时任务
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      system.SysJob                                  true  "任务名称, cron表达式, 处理函数, JSON参数, 是否启用"
+// @Success   200   {object}  response.Response{data=system.SysJob,msg=string}  "创建定时任务 启用时立即加入调度"
+// @Router    /sysJob/createSysJob [post]
+func (s *SysJobApi) CreateSysJob(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var job system.SysJob
+	if err := c.ShouldBindJSON(&job); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := jobService.CreateJob(&job); err != nil {
+		global.GVA_LOG.Error("创建失败!", zap.Error(err))
+		response.FailWithMessage("创建失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(job, "创建成功", c)
+}
+
+// UpdateSysJob
+// @Tags      SysJob
+// @Summary   更新定时任务
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      system.SysJob                  true  "任务ID, 任务名称, cron表达式, 处理函数, JSON参数, 是否启用"
+// @Success   200   {object}  response.Response{msg=string}  "更新定时任务 并按新的配置重新调度"
+// @Router    /sysJob/updateSysJob [put]
+func (s *SysJobApi) UpdateSysJob(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var job system.SysJob
+	if err := c.ShouldBindJSON(&job); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := jobService.UpdateJob(job); err != nil {
+		global.GVA_LOG.Error("更新失败!", zap.Error(err))
+		response.FailWithMessage("更新失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+// DeleteSysJobsByIds
+// @Tags      SysJob
+// @Summary   删除定时任务
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.IdsReq                 true  "任务ID"
+// @Success   200   {object}  response.Response{msg=string}  "删除定时任务 执行记录保留"
+// @Router    /sysJob/deleteSysJobsByIds [delete]
+func (s *SysJobApi) DeleteSysJobsByIds(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var ids request.IdsReq
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	jobIds := make([]uint, 0, len(ids.Ids))
+	for _, id := range ids.Ids {
+		jobIds = append(jobIds, uint(id))
+	}
+	if err := jobService.DeleteJobs(jobIds); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败", c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// PauseSysJob
+// @Tags      SysJob
+// @Summary   暂停定时任务
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "任务ID"
+// @Success   200   {object}  response.Response{msg=string}  "暂停定时任务"
+// @Router    /sysJob/pauseSysJob [post]
+func (s *SysJobApi) PauseSysJob(c *gin.Context) {
+	s.setEnabled(c, false)
+}
+
+// ResumeSysJob
+// @Tags      SysJob
+// @Summary   恢复定时任务
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "任务ID"
+// @Success   200   {object}  response.Response{msg=string}  "恢复定时任务"
+// @Router    /sysJob/resumeSysJob [post]
+func (s *SysJobApi) ResumeSysJob(c *gin.Context) {
+	s.setEnabled(c, true)
+}
+
+func (s *SysJobApi) setEnabled(c *gin.Context, enabled bool) {
+	if !platformOnly(c) {
+		return
+	}
+	var idInfo request.GetById
+	if err := c.ShouldBindJSON(&idInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(idInfo, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := jobService.SetJobEnabled(idInfo.Uint(), enabled); err != nil {
+		global.GVA_LOG.Error("设置失败!", zap.Error(err))
+		response.FailWithMessage("设置失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("设置成功", c)
+}
+
+// RunSysJob
+// @Tags      SysJob
+// @Summary   立即执行定时任务
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "任务ID"
+// @Success   200   {object}  response.Response{msg=string}  "在后台立即执行一次 结果见执行记录"
+// @Router    /sysJob/runSysJob [post]
+func (s *SysJobApi) RunSysJob(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var idInfo request.GetById
+	if err := c.ShouldBindJSON(&idInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(idInfo, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := jobService.RunJob(idInfo.Uint()); err != nil {
+		global.GVA_LOG.Error("执行失败!", zap.Error(err))
+		response.FailWithMessage("执行失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已开始执行", c)
+}
+
+// FindSysJob
+// @Tags      SysJob
+// @Summary   根据ID获取定时任务
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     request.GetById                                   true  "任务ID"
+// @Success   200   {object}  response.Response{data=system.SysJob,msg=string}  "根据ID获取定时任务"
+// @Router    /sysJob/findSysJob [get]
+func (s *SysJobApi) FindSysJob(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var idInfo request.GetById
+	if err := c.ShouldBindQuery(&idInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	job, err := jobService.GetJob(idInfo.Uint())
+	if err != nil {
+		global.GVA_LOG.Error("查询失败!", zap.Error(err))
+		response.FailWithMessage("查询失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(job, "查询成功", c)
+}
+
+// GetSysJobList
+// @Tags      SysJob
+// @Summary   分页获取定时任务
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     systemReq.SysJobSearch                                 true  "页码, 每页大小, 任务名称, 处理函数, 是否启用"
+// @Success   200   {object}  response.Response{data=response.PageResult,msg=string}  "分页获取定时任务"
+// @Router    /sysJob/getSysJobList [get]
+func (s *SysJobApi) GetSysJobList(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var pageInfo systemReq.SysJobSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := jobService.GetJobList(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// GetSysJobRunList
+// @Tags      SysJob
+// @Summary   分页获取定时任务执行记录
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     systemReq.SysJobRunSearch                              true  "页码, 每页大小, 任务ID, 状态"
+// @Success   200   {object}  response.Response{data=response.PageResult,msg=string}  "分页获取执行状态、耗时和错误信息"
+// @Router    /sysJob/getSysJobRunList [get]
+func (s *SysJobApi) GetSysJobRunList(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var pageInfo systemReq.SysJobRunSearch
+	if err := c.ShouldBindQuery(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := jobService.GetJobRunList(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// GetSysJobHandlers
+// @Tags      SysJob
+// @Summary   获取已注册的处理函数
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=[]string,msg=string}  "获取已注册的处理函数名称"
+// @Router    /sysJob/getSysJobHandlers [get]
+func (s *SysJobApi) GetSysJobHandlers(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	response.OkWithDetailed(jobService.GetJobHandlers(), "获取成功", c)
+}
@@ -1,6 +1,8 @@
 package system
 
 import (
+	"net/http"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
@@ -28,6 +30,31 @@ func (j *JwtApi) JsonInBlacklist(c *gin.Context) {
 		response.FailWithMessage("jwt作废失败", c)
 		return
 	}
+	// 注销时一并撤销刷新令牌
+	if err = jwtService.RevokeTokenFamilyByToken(token); err != nil {
+		global.GVA_LOG.Error("令牌族撤销失败!", zap.Error(err))
+	}
+	if err = userSessionService.RevokeSessionByToken(token); err != nil {
+		global.GVA_LOG.Error("会话撤销失败!", zap.Error(err))
+	}
 	utils.ClearToken(c)
 	response.OkWithMessage("jwt作废成功", c)
 }
+
+// GetJwks
+// @Tags      Jwt
+// @Summary   获取jwt验签公钥
+// @Produce   application/json
+// @Success   200  {object}  systemRes.JSONWebKeySet  "JWKS格式的公钥集合 HS256模式下为空"
+// @Router    /.well-known/jwks.json [get]
+func (j *JwtApi) GetJwks(c *gin.Context) {
+	set, err := utils.JwtKeySet()
+	if err != nil {
+		global.GVA_LOG.Error("获取jwt公钥失败!", zap.Error(err))
+		response.FailWithMessage("获取jwt公钥失败", c)
+		return
+	}
+	// 下游服务可缓存公钥 遇到未知kid时再重新获取
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
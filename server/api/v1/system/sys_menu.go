@@ -75,6 +75,9 @@ func (a *AuthorityMenuApi) AddMenuAuthority(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	if !checkTenantAuthorities(c, authorityMenu.AuthorityId) {
+		return
+	}
 	adminAuthorityID := utils.GetUserAuthorityId(c)
 	if err := menuService.AddMenuAuthority(authorityMenu.Menus, adminAuthorityID, authorityMenu.AuthorityId); err != nil {
 		global.GVA_LOG.Error("添加失败!", zap.Error(err))
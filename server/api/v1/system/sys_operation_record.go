@@ -122,3 +122,58 @@ func (s *OperationRecordApi) GetSysOperationRecordList(c *gin.Context) {
 		PageSize: pageInfo.PageSize,
 	}, "获取成功", c)
 }
+
+// GetOperationRecordStats
+// @Tags      SysOperationRecord
+// @Summary   获取操作记录写入队列指标
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Success   200   {object}  response.Response{data=systemRes.OperationRecordWriterStats,msg=string}  "获取队列深度、丢弃数量等指标"
+// @Router    /sysOperationRecord/getOperationRecordStats [get]
+func (s *OperationRecordApi) GetOperationRecordStats(c *gin.Context) {
+	response.OkWithDetailed(operationRecordService.GetOperationRecordWriterStats(), "获取成功", c)
+}
+
+// GetSysOperationRecordStatistics
+// @Tags      SysOperationRecord
+// @Summary   统计操作记录
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     systemReq.SysOperationRecordStatistics                                    true  "开始时间, 结束时间, 返回条数"
+// @Success   200   {object}  response.Response{data=systemRes.OperationRecordStatistics,msg=string}  "按用户、路径、状态码统计及耗时分位数"
+// @Router    /sysOperationRecord/getSysOperationRecordStatistics [get]
+func (s *OperationRecordApi) GetSysOperationRecordStatistics(c *gin.Context) {
+	var info systemReq.SysOperationRecordStatistics
+	err := c.ShouldBindQuery(&info)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	stats, err := operationRecordService.GetSysOperationRecordStatistics(info)
+	if err != nil {
+		global.GVA_LOG.Error("统计失败!", zap.Error(err))
+		response.FailWithMessage("统计失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(stats, "获取成功", c)
+}
+
+// VerifySysOperationRecordChain
+// @Tags      SysOperationRecord
+// @Summary   校验操作记录哈希链
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Success   200   {object}  response.Response{data=systemRes.OperationRecordChainVerification,msg=string}  "哈希链是否完整及断裂位置"
+// @Router    /sysOperationRecord/verifySysOperationRecordChain [get]
+func (s *OperationRecordApi) VerifySysOperationRecordChain(c *gin.Context) {
+	result, err := operationRecordService.VerifySysOperationRecordChain()
+	if err != nil {
+		global.GVA_LOG.Error("校验失败!", zap.Error(err))
+		response.FailWithMessage("校验失败", c)
+		return
+	}
+	response.OkWithDetailed(result, "校验完成", c)
+}
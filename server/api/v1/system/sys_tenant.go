@@ -0,0 +1,183 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TenantApi struct{}
+
+// CreateTenant
+// @Tags      SysTenant
+// @Summary   创建租户
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.CreateTenant                                true  "租户信息, 租户管理员角色及账号"
+// @Success   200   {object}  response.Response{data=system.SysTenant,msg=string}  "创建租户 同时创建租户管理员角色和租户管理员"
+// @Router    /tenant/createTenant [post]
+func (t *TenantApi) CreateTenant(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var req systemReq.CreateTenant
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	tenant, err := tenantService.CreateTenant(utils.GetUserAuthorityId(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("创建失败!", zap.Error(err))
+		response.FailWithMessage("创建失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(tenant, "创建成功", c)
+}
+
+// UpdateTenant
+// @Tags      SysTenant
+// @Summary   更新租户
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.UpdateTenant         true  "租户ID, 名称, 状态, 到期时间"
+// @Success   200   {object}  response.Response{msg=string}  "更新租户"
+// @Router    /tenant/updateTenant [put]
+func (t *TenantApi) UpdateTenant(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var req systemReq.UpdateTenant
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := tenantService.UpdateTenant(req); err != nil {
+		global.GVA_LOG.Error("更新失败!", zap.Error(err))
+		response.FailWithMessage("更新失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+// DeleteTenant
+// @Tags      SysTenant
+// @Summary   删除租户
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "租户ID"
+// @Success   200   {object}  response.Response{msg=string}  "删除租户"
+// @Router    /tenant/deleteTenant [delete]
+func (t *TenantApi) DeleteTenant(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(req, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := tenantService.DeleteTenant(req.Uint()); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		response.FailWithMessage("删除失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// FindTenant
+// @Tags      SysTenant
+// @Summary   根据ID获取租户
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     request.GetById                                      true  "租户ID"
+// @Success   200   {object}  response.Response{data=system.SysTenant,msg=string}  "租户详情"
+// @Router    /tenant/findTenant [get]
+func (t *TenantApi) FindTenant(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var req request.GetById
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	tenant, err := tenantService.GetTenant(req.Uint())
+	if err != nil {
+		global.GVA_LOG.Error("查询失败!", zap.Error(err))
+		response.FailWithMessage("查询失败", c)
+		return
+	}
+	response.OkWithData(tenant, c)
+}
+
+// GetTenantList
+// @Tags      SysTenant
+// @Summary   分页获取租户
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  query     systemReq.GetTenantList                                 true  "页码, 每页大小, 名称, 编码"
+// @Success   200   {object}  response.Response{data=response.PageResult,msg=string}  "分页获取租户"
+// @Router    /tenant/getTenantList [get]
+func (t *TenantApi) GetTenantList(c *gin.Context) {
+	if !platformOnly(c) {
+		return
+	}
+	var req systemReq.GetTenantList
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	list, total, err := tenantService.GetTenantList(req)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// platformOnly 租户管理员即使拥有接口权限也不能管理租户
+func platformOnly(c *gin.Context) bool {
+	if utils.GetUserTenantId(c) != 0 {
+		response.FailWithMessage(systemService.ErrTenantPlatform.Error(), c)
+		return false
+	}
+	return true
+}
+
+// checkTenantUsers 租户管理员只能操作本租户的用户 失败时已写入响应
+func checkTenantUsers(c *gin.Context, ids ...uint) bool {
+	if err := tenantService.CheckUsers(utils.GetUserTenantId(c), ids...); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return false
+	}
+	return true
+}
+
+// checkTenantAuthorities 租户管理员只能操作本租户的角色 失败时已写入响应
+func checkTenantAuthorities(c *gin.Context, ids ...uint) bool {
+	if err := tenantService.CheckAuthorities(utils.GetUserTenantId(c), ids...); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return false
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package system
 
 import (
+	"errors"
 	"strconv"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
 	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -50,15 +52,28 @@ func (b *BaseApi) Login(c *gin.Context) {
 	var oc bool = openCaptcha == 0 || openCaptcha < interfaceToInt(v)
 
 	if !oc || (l.CaptchaId != "" && l.Captcha != "" && store.Verify(l.CaptchaId, l.Captcha, true)) {
+		if lockedUntil, err := userService.CheckLoginLock(l.Username); err != nil {
+			if errors.Is(err, systemService.ErrUserLocked) {
+				response.FailWithMessage("账号已被锁定, 请"+lockRemaining(lockedUntil)+"后重试", c)
+				return
+			}
+			global.GVA_LOG.Error("查询账号锁定状态失败!", zap.Error(err))
+		}
 		u := &system.SysUser{Username: l.Username, Password: l.Password}
 		user, err := userService.Login(u)
 		if err != nil {
 			global.GVA_LOG.Error("登陆失败! 用户名不存在或者密码错误!", zap.Error(err))
 			// 验证码次数+1
 			global.BlackCache.Increment(key, 1)
+			if b.loginFailed(c, l.Username) {
+				return
+			}
 			response.FailWithMessage("用户名不存在或者密码错误", c)
 			return
 		}
+		if err = userService.ResetLoginFailures(l.Username); err != nil {
+			global.GVA_LOG.Error("清除登录失败次数失败!", zap.Error(err))
+		}
 		if user.Enable != 1 {
 			global.GVA_LOG.Error("登陆失败! 用户被禁止登录!")
 			// 验证码次数+1
@@ -66,6 +81,9 @@ func (b *BaseApi) Login(c *gin.Context) {
 			response.FailWithMessage("用户被禁止登录", c)
 			return
 		}
+		if b.totpChallenge(c, *user) {
+			return
+		}
 		b.TokenNext(c, *user)
 		return
 	}
@@ -74,57 +92,117 @@ func (b *BaseApi) Login(c *gin.Context) {
 	response.FailWithMessage("验证码错误", c)
 }
 
-// TokenNext 登录以后签发jwt
+// TokenNext 登录以后签发jwt 开启刷新令牌模式时同时签发刷新令牌
 func (b *BaseApi) TokenNext(c *gin.Context, user system.SysUser) {
-	token, claims, err := utils.LoginToken(&user)
+	pair, ok := b.issueToken(c, user)
+	if !ok {
+		return
+	}
+	response.OkWithDetailed(b.loginResponse(c, user, pair), "登录成功", c)
+}
+
+// issueToken 签发令牌并处理多点登录 失败时已写入响应
+func (b *BaseApi) issueToken(c *gin.Context, user system.SysUser) (pair utils.TokenPair, ok bool) {
+	if err := tenantService.CheckTenant(user.TenantID); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	// 须修改密码时签发的令牌仅能访问修改密码和注销接口
+	userService.RestrictPassword(&user)
+	var err error
+	if global.GVA_CONFIG.JWT.UseRefreshToken {
+		pair, err = jwtService.IssueTokenPair(&user)
+	} else {
+		pair.Token, pair.Claims, err = utils.LoginToken(&user)
+	}
 	if err != nil {
 		global.GVA_LOG.Error("获取token失败!", zap.Error(err))
 		response.FailWithMessage("获取token失败", c)
 		return
 	}
-	if !global.GVA_CONFIG.System.UseMultipoint {
-		utils.SetToken(c, token, int(claims.RegisteredClaims.ExpiresAt.Unix()-time.Now().Unix()))
-		response.OkWithDetailed(systemRes.LoginResponse{
-			User:      user,
-			Token:     token,
-			ExpiresAt: claims.RegisteredClaims.ExpiresAt.Unix() * 1000,
-		}, "登录成功", c)
-		return
-	}
-
-	if jwtStr, err := jwtService.GetRedisJWT(user.Username); err == redis.Nil {
-		if err := utils.SetRedisJWT(token, user.Username); err != nil {
+	if global.GVA_CONFIG.System.UseMultipoint {
+		if jwtStr, err := jwtService.GetRedisJWT(user.Username); err == nil {
+			// 作废该用户上一次登录的jwt及其令牌族
+			if err := jwtService.JsonInBlacklist(system.JwtBlacklist{Jwt: jwtStr}); err != nil {
+				response.FailWithMessage("jwt作废失败", c)
+				return
+			}
+			if err := jwtService.RevokeTokenFamilyByToken(jwtStr); err != nil {
+				global.GVA_LOG.Error("令牌族撤销失败!", zap.Error(err))
+			}
+			if err := userSessionService.RevokeSessionByToken(jwtStr); err != nil {
+				global.GVA_LOG.Error("会话撤销失败!", zap.Error(err))
+			}
+		} else if err != redis.Nil {
 			global.GVA_LOG.Error("设置登录状态失败!", zap.Error(err))
 			response.FailWithMessage("设置登录状态失败", c)
 			return
 		}
-		utils.SetToken(c, token, int(claims.RegisteredClaims.ExpiresAt.Unix()-time.Now().Unix()))
-		response.OkWithDetailed(systemRes.LoginResponse{
-			User:      user,
-			Token:     token,
-			ExpiresAt: claims.RegisteredClaims.ExpiresAt.Unix() * 1000,
-		}, "登录成功", c)
-	} else if err != nil {
-		global.GVA_LOG.Error("设置登录状态失败!", zap.Error(err))
-		response.FailWithMessage("设置登录状态失败", c)
-	} else {
-		var blackJWT system.JwtBlacklist
-		blackJWT.Jwt = jwtStr
-		if err := jwtService.JsonInBlacklist(blackJWT); err != nil {
-			response.FailWithMessage("jwt作废失败", c)
+		if err := utils.SetRedisJWT(pair.Token, user.Username); err != nil {
+			global.GVA_LOG.Error("设置登录状态失败!", zap.Error(err))
+			response.FailWithMessage("设置登录状态失败", c)
 			return
 		}
-		if err := utils.SetRedisJWT(token, user.GetUsername()); err != nil {
-			response.FailWithMessage("设置登录状态失败", c)
+	}
+	// 会话按刷新令牌的有效期保留 未开启刷新令牌时与访问令牌一致
+	expiresAt := pair.Claims.RegisteredClaims.ExpiresAt.Time
+	if pair.RefreshToken != "" {
+		expiresAt = pair.RefreshClaims.RegisteredClaims.ExpiresAt.Time
+	}
+	if err = userSessionService.CreateSession(user, pair.Claims, pair.Token, expiresAt, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		global.GVA_LOG.Error("登记会话失败!", zap.Error(err))
+	}
+	return pair, true
+}
+
+// RefreshToken
+// @Tags     Base
+// @Summary  刷新令牌
+// @Produce   application/json
+// @Param    data  body      systemReq.RefreshToken                                      true  "刷新令牌"
+// @Success  200   {object}  response.Response{data=systemRes.LoginResponse,msg=string}  "返回新的访问令牌与刷新令牌"
+// @Router   /base/refresh [post]
+func (b *BaseApi) RefreshToken(c *gin.Context) {
+	if !global.GVA_CONFIG.JWT.UseRefreshToken {
+		response.FailWithMessage("未开启刷新令牌", c)
+		return
+	}
+	var req systemReq.RefreshToken
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	pair, user, err := jwtService.RotateRefreshToken(req.RefreshToken)
+	if err != nil {
+		global.GVA_LOG.Error("刷新令牌失败!", zap.Error(err))
+		if errors.Is(err, systemService.ErrRefreshTokenInvalid) || errors.Is(err, systemService.ErrRefreshTokenReused) {
+			utils.ClearToken(c)
+			response.NoAuth(err.Error(), c)
 			return
 		}
-		utils.SetToken(c, token, int(claims.RegisteredClaims.ExpiresAt.Unix()-time.Now().Unix()))
-		response.OkWithDetailed(systemRes.LoginResponse{
-			User:      user,
-			Token:     token,
-			ExpiresAt: claims.RegisteredClaims.ExpiresAt.Unix() * 1000,
-		}, "登录成功", c)
+		response.FailWithMessage("刷新令牌失败", c)
+		return
+	}
+	if err = userSessionService.RotateSession(pair.Claims.FamilyID, pair.Token, pair.RefreshClaims.RegisteredClaims.ExpiresAt.Time); err != nil {
+		global.GVA_LOG.Error("更新会话失败!", zap.Error(err))
 	}
+	response.OkWithDetailed(b.loginResponse(c, user, pair), "刷新成功", c)
+}
+
+// loginResponse 写入cookie并组装令牌信息
+func (b *BaseApi) loginResponse(c *gin.Context, user system.SysUser, pair utils.TokenPair) systemRes.LoginResponse {
+	utils.SetToken(c, pair.Token, int(pair.Claims.RegisteredClaims.ExpiresAt.Unix()-time.Now().Unix()))
+	res := systemRes.LoginResponse{
+		User:      user,
+		Token:     pair.Token,
+		ExpiresAt: pair.Claims.RegisteredClaims.ExpiresAt.Unix() * 1000,
+	}
+	res.PasswordState, _ = userService.PasswordState(user)
+	if pair.RefreshToken != "" {
+		res.RefreshToken = pair.RefreshToken
+		res.RefreshExpiresAt = pair.RefreshClaims.RegisteredClaims.ExpiresAt.Unix() * 1000
+	}
+	return res
 }
 
 // Register
@@ -146,16 +224,25 @@ func (b *BaseApi) Register(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	if !checkTenantAuthorities(c, append(r.AuthorityIds, r.AuthorityId)...) {
+		return
+	}
 	var authorities []system.SysAuthority
 	for _, v := range r.AuthorityIds {
 		authorities = append(authorities, system.SysAuthority{
 			AuthorityId: v,
 		})
 	}
-	user := &system.SysUser{Username: r.Username, NickName: r.NickName, Password: r.Password, HeaderImg: r.HeaderImg, AuthorityId: r.AuthorityId, Authorities: authorities, Enable: r.Enable, Phone: r.Phone, Email: r.Email}
+	// 租户管理员创建的用户属于本租户
+	user := &system.SysUser{GVA_TENANT: global.GVA_TENANT{TenantID: utils.GetUserTenantId(c)}, Username: r.Username, NickName: r.NickName, Password: r.Password, HeaderImg: r.HeaderImg, AuthorityId: r.AuthorityId, Authorities: authorities, Enable: r.Enable, Phone: r.Phone, Email: r.Email, DeptId: r.DeptId}
 	userReturn, err := userService.Register(*user)
 	if err != nil {
 		global.GVA_LOG.Error("注册失败!", zap.Error(err))
+		var policyErr utils.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			response.FailWithDetailed(systemRes.SysUserResponse{User: userReturn}, "注册失败, "+policyErr.Error(), c)
+			return
+		}
 		response.FailWithDetailed(systemRes.SysUserResponse{User: userReturn}, "注册失败", c)
 		return
 	}
@@ -187,7 +274,37 @@ func (b *BaseApi) ChangePassword(c *gin.Context) {
 	err = userService.ChangePassword(u, req.NewPassword)
 	if err != nil {
 		global.GVA_LOG.Error("修改失败!", zap.Error(err))
-		response.FailWithMessage("修改失败，原密码与当前账户不符", c)
+		var policyErr utils.PasswordPolicyError
+		switch {
+		case errors.Is(err, systemService.ErrOldPasswordMismatch):
+			response.FailWithMessage("修改失败，原密码与当前账户不符", c)
+		case errors.As(err, &policyErr):
+			response.FailWithMessage("修改失败，"+policyErr.Error(), c)
+		default:
+			response.FailWithMessage("修改失败", c)
+		}
+		return
+	}
+	// 受限令牌在修改密码后作废 重新签发可访问全部接口的令牌
+	if claims := utils.GetUserInfo(c); claims != nil && claims.MustChangePassword {
+		token := utils.GetToken(c)
+		if err = jwtService.JsonInBlacklist(system.JwtBlacklist{Jwt: token}); err != nil {
+			global.GVA_LOG.Error("jwt作废失败!", zap.Error(err))
+		}
+		if err = jwtService.RevokeTokenFamilyByToken(token); err != nil {
+			global.GVA_LOG.Error("令牌族撤销失败!", zap.Error(err))
+		}
+		if err = userSessionService.RevokeSessionByToken(token); err != nil {
+			global.GVA_LOG.Error("会话撤销失败!", zap.Error(err))
+		}
+		user, err := userService.GetUserInfo(claims.UUID)
+		if err != nil {
+			global.GVA_LOG.Error("获取用户信息失败!", zap.Error(err))
+			utils.ClearToken(c)
+			response.OkWithMessage("修改成功, 请重新登录", c)
+			return
+		}
+		b.TokenNext(c, user)
 		return
 	}
 	response.OkWithMessage("修改成功", c)
@@ -214,6 +331,10 @@ func (b *BaseApi) GetUserList(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	// 租户管理员只能查看本租户的用户
+	if tenantID := utils.GetUserTenantId(c); tenantID != 0 {
+		pageInfo.TenantID = &tenantID
+	}
 	list, total, err := userService.GetUserInfoList(pageInfo)
 	if err != nil {
 		global.GVA_LOG.Error("获取失败!", zap.Error(err))
@@ -285,6 +406,9 @@ func (b *BaseApi) SetUserAuthorities(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	if !checkTenantUsers(c, sua.ID) || !checkTenantAuthorities(c, sua.AuthorityIds...) {
+		return
+	}
 	authorityID := utils.GetUserAuthorityId(c)
 	err = userService.SetUserAuthorities(authorityID, sua.ID, sua.AuthorityIds)
 	if err != nil {
@@ -321,6 +445,9 @@ func (b *BaseApi) DeleteUser(c *gin.Context) {
 		response.FailWithMessage("删除失败, 无法删除自己。", c)
 		return
 	}
+	if !checkTenantUsers(c, uint(reqId.ID)) {
+		return
+	}
 	err = userService.DeleteUser(reqId.ID)
 	if err != nil {
 		global.GVA_LOG.Error("删除失败!", zap.Error(err))
@@ -351,6 +478,9 @@ func (b *BaseApi) SetUserInfo(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	if !checkTenantUsers(c, user.ID) || !checkTenantAuthorities(c, user.AuthorityIds...) {
+		return
+	}
 	if len(user.AuthorityIds) != 0 {
 		authorityID := utils.GetUserAuthorityId(c)
 		err = userService.SetUserAuthorities(authorityID, user.ID, user.AuthorityIds)
@@ -369,6 +499,7 @@ func (b *BaseApi) SetUserInfo(c *gin.Context) {
 		Phone:     user.Phone,
 		Email:     user.Email,
 		Enable:    user.Enable,
+		DeptId:    user.DeptId,
 	})
 	if err != nil {
 		global.GVA_LOG.Error("设置失败!", zap.Error(err))
@@ -473,6 +604,9 @@ func (b *BaseApi) ResetPassword(c *gin.Context) {
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
+	if !checkTenantUsers(c, rps.ID) {
+		return
+	}
 	err = userService.ResetPassword(rps.ID, rps.Password)
 	if err != nil {
 		global.GVA_LOG.Error("重置失败!", zap.Error(err))
@@ -0,0 +1,81 @@
+package system
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UnlockUser
+// @Tags      SysUser
+// @Summary   解除用户登录锁定
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "用户ID"
+// @Success   200   {object}  response.Response{msg=string}  "解除用户登录锁定"
+// @Router    /user/unlockUser [post]
+func (b *BaseApi) UnlockUser(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(req, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if !checkTenantUsers(c, req.Uint()) {
+		return
+	}
+	if err := userService.UnlockUser(req.Uint()); err != nil {
+		global.GVA_LOG.Error("解锁失败!", zap.Error(err))
+		response.FailWithMessage("解锁失败", c)
+		return
+	}
+	response.OkWithMessage("解锁成功", c)
+}
+
+// loginFailed 记录按用户名统计的登录失败 触发锁定时写入操作记录并返回 true
+func (b *BaseApi) loginFailed(c *gin.Context, username string) bool {
+	failure, err := userService.RecordLoginFailure(username)
+	if err != nil {
+		global.GVA_LOG.Error("记录登录失败次数失败!", zap.Error(err))
+		return false
+	}
+	if !failure.Locked {
+		return false
+	}
+	global.GVA_LOG.Warn("连续登录失败, 账号已锁定!", zap.String("username", username), zap.Time("lockedUntil", failure.LockedUntil))
+	body, _ := json.Marshal(map[string]string{"username": username})
+	systemService.OperationRecordWriterApp.Write(system.SysOperationRecord{
+		Ip:           c.ClientIP(),
+		Method:       c.Request.Method,
+		Path:         c.Request.URL.Path,
+		Agent:        c.Request.UserAgent(),
+		Status:       http.StatusLocked,
+		ErrorMessage: "连续登录失败, 账号锁定至 " + failure.LockedUntil.Format(time.DateTime),
+		Body:         string(body),
+	})
+	response.FailWithMessage("密码错误次数过多, 账号已被锁定, 请"+lockRemaining(failure.LockedUntil)+"后重试", c)
+	return true
+}
+
+// lockRemaining 剩余锁定时间 不足一分钟时按秒显示
+func lockRemaining(until time.Time) string {
+	remaining := time.Until(until)
+	if remaining < time.Minute {
+		return strconv.Itoa(max(int(math.Ceil(remaining.Seconds())), 1)) + "秒"
+	}
+	return strconv.Itoa(int(math.Ceil(remaining.Minutes()))) + "分钟"
+}
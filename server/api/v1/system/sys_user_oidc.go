@@ -0,0 +1,78 @@
+package system
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// oidcBindingCookie 保存发起单点登录的浏览器绑定值
+const oidcBindingCookie = "gva-oidc-binding"
+
+// OidcAuthorize
+// @Tags     Base
+// @Summary  获取单点登录授权地址
+// @Produce   application/json
+// @Success  200  {object}  response.Response{data=systemRes.OidcAuthorize,msg=string}  "返回跳转到身份提供方的授权地址"
+// @Router   /base/oidcAuthorize [get]
+func (b *BaseApi) OidcAuthorize(c *gin.Context) {
+	authURL, binding, err := oidcService.AuthorizeURL()
+	if err != nil {
+		if errors.Is(err, systemService.ErrOidcDisabled) {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+		global.GVA_LOG.Error("获取单点登录地址失败!", zap.Error(err))
+		response.FailWithMessage("获取单点登录地址失败", c)
+		return
+	}
+	// 绑定值只写入发起授权的浏览器 回调时校验 前端无法读取
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcBindingCookie, binding, 0, "/", "", c.Request.TLS != nil, true)
+	response.OkWithDetailed(systemRes.OidcAuthorize{Name: global.GVA_CONFIG.OIDC.Name, AuthURL: authURL}, "获取成功", c)
+}
+
+// OidcLogin
+// @Tags     Base
+// @Summary  单点登录回调 使用授权码登录
+// @Produce   application/json
+// @Param    data  body      systemReq.OidcLogin                                         true  "授权码, state"
+// @Success  200   {object}  response.Response{data=systemRes.LoginResponse,msg=string}  "返回包括用户信息,token,过期时间"
+// @Router   /base/oidcLogin [post]
+func (b *BaseApi) OidcLogin(c *gin.Context) {
+	var req systemReq.OidcLogin
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	binding, _ := c.Cookie(oidcBindingCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcBindingCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	user, err := oidcService.Login(req.Code, req.State, binding)
+	if err != nil {
+		global.GVA_LOG.Error("单点登录失败!", zap.Error(err))
+		switch {
+		case errors.Is(err, systemService.ErrOidcDisabled), errors.Is(err, systemService.ErrOidcStateInvalid),
+			errors.Is(err, systemService.ErrNoMappedAuthority), errors.Is(err, systemService.ErrExternalUserNotFound),
+			errors.Is(err, systemService.ErrUsernameTaken):
+			response.FailWithMessage(err.Error(), c)
+		default:
+			response.FailWithMessage("单点登录失败", c)
+		}
+		return
+	}
+	if user.Enable != 1 {
+		global.GVA_LOG.Error("登陆失败! 用户被禁止登录!")
+		response.FailWithMessage("用户被禁止登录", c)
+		return
+	}
+	// 身份提供方负责认证及多因素验证 不再校验本地密码状态和两步验证
+	b.TokenNext(c, user)
+}
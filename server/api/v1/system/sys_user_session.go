@@ -0,0 +1,133 @@
+package system
+
+import (
+	"errors"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetSessions
+// @Tags      SysUser
+// @Summary   获取自身的登录会话
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=[]system.SysUserSession,msg=string}  "获取自身的登录会话 current标记当前会话"
+// @Router    /user/getSessions [get]
+func (b *BaseApi) GetSessions(c *gin.Context) {
+	claims := utils.GetUserInfo(c)
+	if claims == nil {
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	sessions, err := userSessionService.GetUserSessions(claims.BaseClaims.ID, claims.FamilyID)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(sessions, "获取成功", c)
+}
+
+// RevokeSession
+// @Tags      SysUser
+// @Summary   撤销自身的登录会话
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.RevokeSession        true  "会话ID"
+// @Success   200   {object}  response.Response{msg=string}  "撤销自身的登录会话"
+// @Router    /user/revokeSession [post]
+func (b *BaseApi) RevokeSession(c *gin.Context) {
+	var req systemReq.RevokeSession
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if req.SessionID == "" {
+		response.FailWithMessage("会话ID不能为空", c)
+		return
+	}
+	if err := userSessionService.RevokeSession(utils.GetUserID(c), req.SessionID); err != nil {
+		global.GVA_LOG.Error("撤销失败!", zap.Error(err))
+		if errors.Is(err, systemService.ErrSessionNotFound) {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+		response.FailWithMessage("撤销失败", c)
+		return
+	}
+	response.OkWithMessage("撤销成功", c)
+}
+
+// GetSessionList
+// @Tags      SysUser
+// @Summary   分页获取全部在线会话
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.GetSessionList                                true  "页码, 每页大小, 用户名"
+// @Success   200   {object}  response.Response{data=response.PageResult,msg=string}  "分页获取全部在线会话,返回包括列表,总数,页码,每页数量"
+// @Router    /user/getSessionList [post]
+func (b *BaseApi) GetSessionList(c *gin.Context) {
+	var pageInfo systemReq.GetSessionList
+	if err := c.ShouldBindJSON(&pageInfo); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(pageInfo, utils.PageInfoVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if tenantID := utils.GetUserTenantId(c); tenantID != 0 {
+		pageInfo.TenantID = &tenantID
+	}
+	list, total, err := userSessionService.GetSessionList(pageInfo)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.PageSize,
+	}, "获取成功", c)
+}
+
+// ForceLogout
+// @Tags      SysUser
+// @Summary   强制用户下线
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "用户ID"
+// @Success   200   {object}  response.Response{msg=string}  "强制用户下线 撤销该用户的全部会话"
+// @Router    /user/forceLogout [post]
+func (b *BaseApi) ForceLogout(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(req, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if !checkTenantUsers(c, req.Uint()) {
+		return
+	}
+	if err := userSessionService.RevokeUserSessions(req.Uint()); err != nil {
+		global.GVA_LOG.Error("强制下线失败!", zap.Error(err))
+		response.FailWithMessage("强制下线失败", c)
+		return
+	}
+	response.OkWithMessage("强制下线成功", c)
+}
@@ -0,0 +1,125 @@
+package system
+
+import (
+	"errors"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateUserToken
+// @Tags      SysUser
+// @Summary   创建个人访问令牌
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.CreateUserToken                                  true  "令牌名称, 过期时间, 允许访问的接口"
+// @Success   200   {object}  response.Response{data=systemRes.UserTokenCreated,msg=string}  "创建个人访问令牌 令牌明文只返回一次"
+// @Router    /user/createToken [post]
+func (b *BaseApi) CreateUserToken(c *gin.Context) {
+	if rejectUserToken(c) {
+		return
+	}
+	var req systemReq.CreateUserToken
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	token, record, err := userTokenService.CreateToken(utils.GetUserID(c), req)
+	if err != nil {
+		global.GVA_LOG.Error("创建失败!", zap.Error(err))
+		response.FailWithMessage("创建失败, "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(systemRes.UserTokenCreated{Token: token, Info: record}, "创建成功, 请妥善保存令牌, 关闭后将无法再次查看", c)
+}
+
+// GetUserTokens
+// @Tags      SysUser
+// @Summary   获取自身的个人访问令牌
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=[]system.SysUserToken,msg=string}  "获取自身的个人访问令牌"
+// @Router    /user/getTokens [get]
+func (b *BaseApi) GetUserTokens(c *gin.Context) {
+	tokens, err := userTokenService.GetUserTokens(utils.GetUserID(c))
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(tokens, "获取成功", c)
+}
+
+// UpdateUserToken
+// @Tags      SysUser
+// @Summary   修改个人访问令牌
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      systemReq.UpdateUserToken      true  "令牌ID, 令牌名称, 过期时间, 允许访问的接口"
+// @Success   200   {object}  response.Response{msg=string}  "修改个人访问令牌"
+// @Router    /user/updateToken [put]
+func (b *BaseApi) UpdateUserToken(c *gin.Context) {
+	if rejectUserToken(c) {
+		return
+	}
+	var req systemReq.UpdateUserToken
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := userTokenService.UpdateToken(utils.GetUserID(c), req); err != nil {
+		global.GVA_LOG.Error("更新失败!", zap.Error(err))
+		response.FailWithMessage("更新失败, "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+// DeleteUserToken
+// @Tags      SysUser
+// @Summary   删除个人访问令牌
+// @Security  ApiKeyAuth
+// @accept    application/json
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "令牌ID"
+// @Success   200   {object}  response.Response{msg=string}  "删除个人访问令牌 删除后立即失效"
+// @Router    /user/deleteToken [delete]
+func (b *BaseApi) DeleteUserToken(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(req, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := userTokenService.DeleteToken(utils.GetUserID(c), req.Uint()); err != nil {
+		global.GVA_LOG.Error("删除失败!", zap.Error(err))
+		if errors.Is(err, systemService.ErrUserTokenNotFound) {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+		response.FailWithMessage("删除失败", c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+// rejectUserToken 个人访问令牌不能用于创建或修改个人访问令牌 防止令牌自行扩展权限
+func rejectUserToken(c *gin.Context) bool {
+	if _, ok := c.Get("userToken"); ok {
+		response.FailWithMessage("个人令牌不能用于管理个人令牌, 请登录后操作", c)
+		return true
+	}
+	return false
+}
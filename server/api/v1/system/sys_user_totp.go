@@ -0,0 +1,248 @@
+package system
+
+import (
+	"errors"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// totpChallenge 用户已启用两步验证或所属角色强制要求时 返回挑战令牌代替jwt
+func (b *BaseApi) totpChallenge(c *gin.Context, user system.SysUser) bool {
+	enabled, required, err := userTotpService.TotpState(user)
+	if err != nil {
+		global.GVA_LOG.Error("获取两步验证状态失败!", zap.Error(err))
+		response.FailWithMessage("获取两步验证状态失败", c)
+		return true
+	}
+	if !enabled && !required {
+		return false
+	}
+	token, expiresAt, err := userTotpService.CreateChallenge(user.ID)
+	if err != nil {
+		global.GVA_LOG.Error("创建两步验证失败!", zap.Error(err))
+		response.FailWithMessage("创建两步验证失败", c)
+		return true
+	}
+	response.OkWithDetailed(systemRes.TotpChallengeResponse{
+		NeedTotp:       true,
+		NeedEnroll:     !enabled,
+		ChallengeToken: token,
+		ExpiresAt:      expiresAt.Unix() * 1000,
+	}, "请输入两步验证码", c)
+	return true
+}
+
+// TotpLogin
+// @Tags     Base
+// @Summary  两步验证登录
+// @Produce   application/json
+// @Param    data  body      systemReq.TotpLogin                                         true  "挑战令牌, 验证码或恢复码"
+// @Success  200   {object}  response.Response{data=systemRes.LoginResponse,msg=string}  "返回包括用户信息,token,过期时间"
+// @Router   /base/totpLogin [post]
+func (b *BaseApi) TotpLogin(c *gin.Context) {
+	var req systemReq.TotpLogin
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		response.FailWithMessage("请输入验证码或恢复码", c)
+		return
+	}
+	user, recoveryCodes, err := userTotpService.VerifyChallenge(req.ChallengeToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		global.GVA_LOG.Error("两步验证失败!", zap.Error(err))
+		if errors.Is(err, systemService.ErrTotpChallengeInvalid) {
+			response.NoAuth(err.Error(), c)
+			return
+		}
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	pair, ok := b.issueToken(c, user)
+	if !ok {
+		return
+	}
+	res := b.loginResponse(c, user, pair)
+	res.RecoveryCodes = recoveryCodes
+	response.OkWithDetailed(res, "登录成功", c)
+}
+
+// TotpEnroll
+// @Tags     Base
+// @Summary  登录时绑定身份验证器
+// @Produce   application/json
+// @Param    data  body      systemReq.TotpChallenge                                         true  "挑战令牌"
+// @Success  200   {object}  response.Response{data=systemRes.TotpSetupResponse,msg=string}  "返回密钥及otpauth链接"
+// @Router   /base/totpEnroll [post]
+func (b *BaseApi) TotpEnroll(c *gin.Context) {
+	var req systemReq.TotpChallenge
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	secret, uri, err := userTotpService.EnrollByChallenge(req.ChallengeToken)
+	if err != nil {
+		global.GVA_LOG.Error("绑定身份验证器失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(systemRes.TotpSetupResponse{Secret: secret, Uri: uri}, "请使用身份验证器扫码", c)
+}
+
+// GetTotpStatus
+// @Tags      SysUser
+// @Summary   获取自身两步验证状态
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=systemRes.TotpStatusResponse,msg=string}  "两步验证状态"
+// @Router    /user/getTotpStatus [get]
+func (b *BaseApi) GetTotpStatus(c *gin.Context) {
+	user, err := userService.FindUserById(int(utils.GetUserID(c)))
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	enabled, required, err := userTotpService.TotpState(*user)
+	if err != nil {
+		global.GVA_LOG.Error("获取失败!", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	left, _ := userTotpService.RecoveryCodesLeft(user.ID)
+	response.OkWithDetailed(systemRes.TotpStatusResponse{
+		Enabled:           enabled,
+		Required:          required,
+		RecoveryCodesLeft: left,
+	}, "获取成功", c)
+}
+
+// SetupTotp
+// @Tags      SysUser
+// @Summary   生成两步验证密钥
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Success   200  {object}  response.Response{data=systemRes.TotpSetupResponse,msg=string}  "返回密钥及otpauth链接"
+// @Router    /user/setupTotp [post]
+func (b *BaseApi) SetupTotp(c *gin.Context) {
+	secret, uri, err := userTotpService.Setup(utils.GetUserID(c), utils.GetUserName(c))
+	if err != nil {
+		global.GVA_LOG.Error("生成密钥失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(systemRes.TotpSetupResponse{Secret: secret, Uri: uri}, "请使用身份验证器扫码", c)
+}
+
+// EnableTotp
+// @Tags      SysUser
+// @Summary   启用两步验证
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      systemReq.TotpVerify                                                    true  "验证码"
+// @Success   200   {object}  response.Response{data=systemRes.TotpRecoveryCodesResponse,msg=string}  "返回恢复码"
+// @Router    /user/enableTotp [post]
+func (b *BaseApi) EnableTotp(c *gin.Context) {
+	var req systemReq.TotpVerify
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	codes, err := userTotpService.Enable(utils.GetUserID(c), req.Code)
+	if err != nil {
+		global.GVA_LOG.Error("启用失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(systemRes.TotpRecoveryCodesResponse{RecoveryCodes: codes}, "启用成功, 请妥善保存恢复码", c)
+}
+
+// DisableTotp
+// @Tags      SysUser
+// @Summary   关闭两步验证
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      systemReq.TotpVerify           true  "验证码或恢复码"
+// @Success   200   {object}  response.Response{msg=string}  "关闭两步验证"
+// @Router    /user/disableTotp [post]
+func (b *BaseApi) DisableTotp(c *gin.Context) {
+	var req systemReq.TotpVerify
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	user, err := userService.FindUserById(int(utils.GetUserID(c)))
+	if err != nil {
+		global.GVA_LOG.Error("关闭失败!", zap.Error(err))
+		response.FailWithMessage("关闭失败", c)
+		return
+	}
+	if err = userTotpService.Disable(*user, req.Code, req.RecoveryCode); err != nil {
+		global.GVA_LOG.Error("关闭失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("关闭成功", c)
+}
+
+// RegenerateRecoveryCodes
+// @Tags      SysUser
+// @Summary   重新生成两步验证恢复码
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      systemReq.TotpVerify                                                    true  "验证码"
+// @Success   200   {object}  response.Response{data=systemRes.TotpRecoveryCodesResponse,msg=string}  "返回恢复码"
+// @Router    /user/regenerateRecoveryCodes [post]
+func (b *BaseApi) RegenerateRecoveryCodes(c *gin.Context) {
+	var req systemReq.TotpVerify
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	codes, err := userTotpService.RegenerateRecoveryCodes(utils.GetUserID(c), req.Code)
+	if err != nil {
+		global.GVA_LOG.Error("生成失败!", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(systemRes.TotpRecoveryCodesResponse{RecoveryCodes: codes}, "生成成功, 请妥善保存恢复码", c)
+}
+
+// ResetUserTotp
+// @Tags      SysUser
+// @Summary   重置用户两步验证
+// @Security  ApiKeyAuth
+// @Produce   application/json
+// @Param     data  body      request.GetById                true  "用户ID"
+// @Success   200   {object}  response.Response{msg=string}  "重置用户两步验证"
+// @Router    /user/resetUserTotp [post]
+func (b *BaseApi) ResetUserTotp(c *gin.Context) {
+	var req request.GetById
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if err := utils.Verify(req, utils.IdVerify); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if !checkTenantUsers(c, req.Uint()) {
+		return
+	}
+	if err := userTotpService.ResetTotp(req.Uint()); err != nil {
+		global.GVA_LOG.Error("重置失败!", zap.Error(err))
+		response.FailWithMessage("重置失败", c)
+		return
+	}
+	response.OkWithMessage("重置成功", c)
+}
@@ -0,0 +1,8 @@
+package config
+
+// AuthorityRule 外部身份的claim或LDAP属性中任一值命中时 为用户分配对应角色
+type AuthorityRule struct {
+	Claim        string   `mapstructure:"claim" json:"claim" yaml:"claim"`                         // claim或属性名称 默认groups 支持以.分隔的嵌套路径
+	Values       []string `mapstructure:"values" json:"values" yaml:"values"`                      // 匹配的值 * 匹配任意值
+	AuthorityIDs []uint   `mapstructure:"authority-ids" json:"authority-ids" yaml:"authority-ids"` // 分配的角色ID
+}
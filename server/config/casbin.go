@@ -0,0 +1,10 @@
+package config
+
+type Casbin struct {
+	Model            string `mapstructure:"model" json:"model" yaml:"model"`                                     // 权限模型 basic: 角色只拥有自身的策略(默认) domain: 子角色继承父角色的策略 且策略可按域(租户)配置 修改后需重启
+	Watcher          string `mapstructure:"watcher" json:"watcher" yaml:"watcher"`                               // 多节点策略同步方式 为空不同步(单节点) redis: 通过redis发布订阅 db: 轮询数据库
+	Channel          string `mapstructure:"channel" json:"channel" yaml:"channel"`                               // redis发布订阅的频道 默认 gva:casbin
+	PollInterval     int    `mapstructure:"poll-interval" json:"poll-interval" yaml:"poll-interval"`             // 轮询数据库的间隔(秒) 默认 3
+	ReloadInterval   int    `mapstructure:"reload-interval" json:"reload-interval" yaml:"reload-interval"`       // 开启同步时定时全量加载策略的间隔(秒) 补偿丢失的变更 默认 60 小于0时不加载
+	DebugAuthorities []uint `mapstructure:"debug-authorities" json:"debug-authorities" yaml:"debug-authorities"` // 可通过请求头 X-Permission-Debug 查看权限判定说明的角色(超级管理员) 说明以base64编码的JSON写入响应头 X-Permission-Explain
+}
@@ -9,6 +9,22 @@ type Server struct {
 	Email     Email   `mapstructure:"email" json:"email" yaml:"email"`
 	System    System  `mapstructure:"system" json:"system" yaml:"system"`
 	Captcha   Captcha `mapstructure:"captcha" json:"captcha" yaml:"captcha"`
+	Totp      Totp    `mapstructure:"totp" json:"totp" yaml:"totp"`
+	Lockout   Lockout `mapstructure:"lockout" json:"lockout" yaml:"lockout"`
+	// 登录认证方式 按顺序尝试 可选 db ldap 未开启的方式会被跳过 为空时为 ldap db
+	Authenticators []string `mapstructure:"authenticators" json:"authenticators" yaml:"authenticators"`
+	// LDAP认证
+	LDAP LDAP `mapstructure:"ldap" json:"ldap" yaml:"ldap"`
+	// OIDC单点登录
+	OIDC OIDC `mapstructure:"oidc" json:"oidc" yaml:"oidc"`
+	// casbin权限模型
+	Casbin Casbin `mapstructure:"casbin" json:"casbin" yaml:"casbin"`
+	// 密码策略
+	PasswordPolicy PasswordPolicy `mapstructure:"password-policy" json:"password-policy" yaml:"password-policy"`
+	// 操作记录
+	OperationRecord OperationRecord `mapstructure:"operation-record" json:"operation-record" yaml:"operation-record"`
+	// 定时任务
+	Cron Cron `mapstructure:"cron" json:"cron" yaml:"cron"`
 	// auto
 	AutoCode Autocode `mapstructure:"autocode" json:"autocode" yaml:"autocode"`
 	// gorm
@@ -0,0 +1,8 @@
+package config
+
+type Cron struct {
+	Cluster          bool   `mapstructure:"cluster" json:"cluster" yaml:"cluster"`                               // 多节点部署时开启 定时任务每次触发只在一个节点执行 @every 按间隔的整数倍对齐触发
+	Lock             string `mapstructure:"lock" json:"lock" yaml:"lock"`                                        // 分布式锁 redis db 为空时启用了redis则使用redis 否则使用数据库
+	Misfire          string `mapstructure:"misfire" json:"misfire" yaml:"misfire"`                               // 所有节点都未运行而错过的触发 fire-once: 补偿执行一次(默认) ignore: 跳过
+	MisfireThreshold int    `mapstructure:"misfire-threshold" json:"misfire-threshold" yaml:"misfire-threshold"` // 超过触发时间多少秒仍未执行视为错过(秒) 默认 60
+}
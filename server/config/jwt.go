@@ -1,8 +1,13 @@
 package config
 
 type JWT struct {
-	SigningKey  string `mapstructure:"signing-key" json:"signing-key" yaml:"signing-key"`    // jwt签名
-	ExpiresTime string `mapstructure:"expires-time" json:"expires-time" yaml:"expires-time"` // 过期时间
-	BufferTime  string `mapstructure:"buffer-time" json:"buffer-time" yaml:"buffer-time"`    // 缓冲时间
-	Issuer      string `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                   // 签发者
+	SigningKey         string `mapstructure:"signing-key" json:"signing-key" yaml:"signing-key"`                            // jwt签名
+	ExpiresTime        string `mapstructure:"expires-time" json:"expires-time" yaml:"expires-time"`                         // 过期时间
+	BufferTime         string `mapstructure:"buffer-time" json:"buffer-time" yaml:"buffer-time"`                            // 缓冲时间
+	Issuer             string `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                                           // 签发者
+	UseRefreshToken    bool   `mapstructure:"use-refresh-token" json:"use-refresh-token" yaml:"use-refresh-token"`          // 使用访问令牌+刷新令牌模式 开启后expires-time为访问令牌有效期 且不再自动续期
+	RefreshExpiresTime string `mapstructure:"refresh-expires-time" json:"refresh-expires-time" yaml:"refresh-expires-time"` // 刷新令牌有效期
+	SigningMethod      string `mapstructure:"signing-method" json:"signing-method" yaml:"signing-method"`                   // 签名算法 HS256(默认 使用signing-key) RS256 ES256 EdDSA 非对称算法的密钥自动生成并存放于数据库
+	KeyRotation        string `mapstructure:"key-rotation" json:"key-rotation" yaml:"key-rotation"`                         // 非对称密钥轮换周期 为空时不轮换
+	KeyGracePeriod     string `mapstructure:"key-grace-period" json:"key-grace-period" yaml:"key-grace-period"`             // 密钥轮换后旧密钥继续用于验签的时长 为空时取令牌最长有效期
 }
@@ -0,0 +1,24 @@
+package config
+
+type LDAP struct {
+	Enable             bool            `mapstructure:"enable" json:"enable" yaml:"enable"`                                           // 开启LDAP认证
+	URL                string          `mapstructure:"url" json:"url" yaml:"url"`                                                    // 服务地址 ldap://host:389 或 ldaps://host:636
+	StartTLS           bool            `mapstructure:"start-tls" json:"start-tls" yaml:"start-tls"`                                  // 使用ldap://时通过StartTLS加密连接
+	InsecureSkipVerify bool            `mapstructure:"insecure-skip-verify" json:"insecure-skip-verify" yaml:"insecure-skip-verify"` // 不校验服务端证书 仅用于测试环境
+	Timeout            string          `mapstructure:"timeout" json:"timeout" yaml:"timeout"`                                        // 连接及请求超时 默认10s
+	BindDN             string          `mapstructure:"bind-dn" json:"bind-dn" yaml:"bind-dn"`                                        // 查询用户使用的服务账号 为空时匿名查询
+	BindPassword       string          `mapstructure:"bind-password" json:"bind-password" yaml:"bind-password"`                      // 服务账号密码
+	BaseDN             string          `mapstructure:"base-dn" json:"base-dn" yaml:"base-dn"`                                        // 查询用户的起始DN
+	UserFilter         string          `mapstructure:"user-filter" json:"user-filter" yaml:"user-filter"`                            // 查询用户的过滤器 %s替换为用户名 AD可使用 (sAMAccountName=%s)
+	UsernameAttribute  string          `mapstructure:"username-attribute" json:"username-attribute" yaml:"username-attribute"`       // 用户名属性 默认uid AD为sAMAccountName
+	NickNameAttribute  string          `mapstructure:"nick-name-attribute" json:"nick-name-attribute" yaml:"nick-name-attribute"`    // 昵称属性 默认displayName
+	EmailAttribute     string          `mapstructure:"email-attribute" json:"email-attribute" yaml:"email-attribute"`                // 邮箱属性 默认mail
+	PhoneAttribute     string          `mapstructure:"phone-attribute" json:"phone-attribute" yaml:"phone-attribute"`                // 电话属性 默认telephoneNumber
+	GroupAttribute     string          `mapstructure:"group-attribute" json:"group-attribute" yaml:"group-attribute"`                // 用户所属组的属性 默认memberOf 组的CN作为groups参与角色映射
+	AutoCreate         bool            `mapstructure:"auto-create" json:"auto-create" yaml:"auto-create"`                            // 首次登录时自动创建用户
+	LinkExisting       bool            `mapstructure:"link-existing" json:"link-existing" yaml:"link-existing"`                      // 首次登录时按用户名关联已有的本地用户
+	SyncAuthorities    bool            `mapstructure:"sync-authorities" json:"sync-authorities" yaml:"sync-authorities"`             // 登录及定时同步时按映射规则更新用户角色
+	SyncInterval       string          `mapstructure:"sync-interval" json:"sync-interval" yaml:"sync-interval"`                      // 定时同步用户昵称、邮箱、电话的周期 为空时不同步
+	DefaultAuthorityID uint            `mapstructure:"default-authority-id" json:"default-authority-id" yaml:"default-authority-id"` // 没有规则命中时分配的角色 为0时拒绝登录
+	Rules              []AuthorityRule `mapstructure:"rules" json:"rules" yaml:"rules"`                                              // 角色映射规则
+}
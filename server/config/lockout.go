@@ -0,0 +1,9 @@
+package config
+
+type Lockout struct {
+	MaxAttempts int    `mapstructure:"max-attempts" json:"max-attempts" yaml:"max-attempts"` // 锁定前允许连续输错密码的次数 默认5 小于0时不锁定
+	Window      string `mapstructure:"window" json:"window" yaml:"window"`                   // 失败次数统计窗口 超过窗口未再失败时重新计数
+	Duration    string `mapstructure:"duration" json:"duration" yaml:"duration"`             // 首次锁定时长 之后每次锁定时长翻倍
+	MaxDuration string `mapstructure:"max-duration" json:"max-duration" yaml:"max-duration"` // 最长锁定时长
+	ResetAfter  string `mapstructure:"reset-after" json:"reset-after" yaml:"reset-after"`    // 锁定次数保留时长 超过后锁定时长重新从首次计算
+}
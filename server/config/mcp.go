@@ -6,4 +6,6 @@ type MCP struct {
 	SSEPath     string `mapstructure:"sse_path" json:"sse_path" yaml:"sse_path"`             // SSE路径
 	MessagePath string `mapstructure:"message_path" json:"message_path" yaml:"message_path"` // 消息路径
 	UrlPrefix   string `mapstructure:"url_prefix" json:"url_prefix" yaml:"url_prefix"`       // URL前缀
+	// MCP专用令牌过期时间 为空时使用jwt过期时间
+	TokenExpiresTime string `mapstructure:"token_expires_time" json:"token_expires_time" yaml:"token_expires_time"`
 }
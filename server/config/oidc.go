@@ -0,0 +1,19 @@
+package config
+
+type OIDC struct {
+	Enable             bool            `mapstructure:"enable" json:"enable" yaml:"enable"`                                           // 开启OIDC单点登录
+	Name               string          `mapstructure:"name" json:"name" yaml:"name"`                                                 // 登录页显示的身份提供方名称
+	Issuer             string          `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                                           // 身份提供方地址 通过 /.well-known/openid-configuration 获取端点
+	ClientID           string          `mapstructure:"client-id" json:"client-id" yaml:"client-id"`                                  // 客户端ID
+	ClientSecret       string          `mapstructure:"client-secret" json:"client-secret" yaml:"client-secret"`                      // 客户端密钥 公共客户端可为空 仅使用PKCE
+	RedirectURL        string          `mapstructure:"redirect-url" json:"redirect-url" yaml:"redirect-url"`                         // 回调地址 指向前端回调页 由前端将code和state提交到 /base/oidcLogin
+	Scopes             []string        `mapstructure:"scopes" json:"scopes" yaml:"scopes"`                                           // 申请的scope 默认 openid profile email
+	UsernameClaim      string          `mapstructure:"username-claim" json:"username-claim" yaml:"username-claim"`                   // 用户名取值的claim 默认 preferred_username
+	NickNameClaim      string          `mapstructure:"nick-name-claim" json:"nick-name-claim" yaml:"nick-name-claim"`                // 昵称取值的claim 默认 name
+	EmailClaim         string          `mapstructure:"email-claim" json:"email-claim" yaml:"email-claim"`                            // 邮箱取值的claim 默认 email
+	AutoCreate         bool            `mapstructure:"auto-create" json:"auto-create" yaml:"auto-create"`                            // 首次登录时自动创建用户
+	LinkExisting       bool            `mapstructure:"link-existing" json:"link-existing" yaml:"link-existing"`                      // 首次登录时按用户名关联已有的本地用户
+	SyncAuthorities    bool            `mapstructure:"sync-authorities" json:"sync-authorities" yaml:"sync-authorities"`             // 每次登录时按映射规则更新用户角色
+	DefaultAuthorityID uint            `mapstructure:"default-authority-id" json:"default-authority-id" yaml:"default-authority-id"` // 没有规则命中时分配的角色 为0时拒绝登录
+	Rules              []AuthorityRule `mapstructure:"rules" json:"rules" yaml:"rules"`                                              // 角色映射规则
+}
@@ -0,0 +1,27 @@
+package config
+
+type OperationRecord struct {
+	Async         bool   `mapstructure:"async" json:"async" yaml:"async"`                            // 异步批量写入操作记录
+	QueueSize     int    `mapstructure:"queue-size" json:"queue-size" yaml:"queue-size"`             // 队列容量
+	BatchSize     int    `mapstructure:"batch-size" json:"batch-size" yaml:"batch-size"`             // 单次批量写入条数
+	FlushInterval int    `mapstructure:"flush-interval" json:"flush-interval" yaml:"flush-interval"` // 批次未满时的最长等待时间(毫秒)
+	DropPolicy    string `mapstructure:"drop-policy" json:"drop-policy" yaml:"drop-policy"`          // 队列满时的处理策略 drop:丢弃 block:等待 sync:同步写入
+	BlockTimeout  int    `mapstructure:"block-timeout" json:"block-timeout" yaml:"block-timeout"`    // block策略的最长等待时间(毫秒) 超时后丢弃
+	RetentionDays int    `mapstructure:"retention-days" json:"retention-days" yaml:"retention-days"` // 保留天数 默认90 小于0时不清理 初始化数据库时写入清理操作记录定时任务的参数
+	Redact        Redact `mapstructure:"redact" json:"redact" yaml:"redact"`                         // 敏感字段脱敏
+}
+
+// Redact 操作记录脱敏配置 默认字段 password newPassword token secret captcha recoveryCode(s) 及两步验证链接始终生效
+type Redact struct {
+	Mask   string       `mapstructure:"mask" json:"mask" yaml:"mask"`       // 替换敏感值的掩码
+	Fields []string     `mapstructure:"fields" json:"fields" yaml:"fields"` // 追加的全局脱敏字段名
+	Rules  []RedactRule `mapstructure:"rules" json:"rules" yaml:"rules"`    // 按路径生效的脱敏规则
+}
+
+// RedactRule 按请求路径生效的脱敏规则
+type RedactRule struct {
+	Path   string   `mapstructure:"path" json:"path" yaml:"path"`       // 请求路径 支持 path.Match 通配符 以 /** 结尾时匹配前缀
+	Fields []string `mapstructure:"fields" json:"fields" yaml:"fields"` // 该路径下额外脱敏的字段名
+	Body   []string `mapstructure:"body" json:"body" yaml:"body"`       // 请求体中脱敏的JSON路径 如 data.items.*.cardNo
+	Resp   []string `mapstructure:"resp" json:"resp" yaml:"resp"`       // 响应中脱敏的JSON路径 如 data.token
+}
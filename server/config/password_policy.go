@@ -0,0 +1,15 @@
+package config
+
+type PasswordPolicy struct {
+	MinLength             int      `mapstructure:"min-length" json:"min-length" yaml:"min-length"`                                           // 最小长度
+	RequireUpper          bool     `mapstructure:"require-upper" json:"require-upper" yaml:"require-upper"`                                  // 必须包含大写字母
+	RequireLower          bool     `mapstructure:"require-lower" json:"require-lower" yaml:"require-lower"`                                  // 必须包含小写字母
+	RequireDigit          bool     `mapstructure:"require-digit" json:"require-digit" yaml:"require-digit"`                                  // 必须包含数字
+	RequireSymbol         bool     `mapstructure:"require-symbol" json:"require-symbol" yaml:"require-symbol"`                               // 必须包含特殊字符
+	BannedWords           []string `mapstructure:"banned-words" json:"banned-words" yaml:"banned-words"`                                     // 禁用词 密码包含任一禁用词(忽略大小写)时拒绝
+	HistoryCount          int      `mapstructure:"history-count" json:"history-count" yaml:"history-count"`                                  // 新密码不能与最近N次使用的密码相同 0为不限制
+	ForceChangeFirstLogin bool     `mapstructure:"force-change-first-login" json:"force-change-first-login" yaml:"force-change-first-login"` // 管理员创建的账号首次登录须修改密码
+	ForceChangeAfterReset bool     `mapstructure:"force-change-after-reset" json:"force-change-after-reset" yaml:"force-change-after-reset"` // 管理员重置密码后须修改密码
+	MaxAge                string   `mapstructure:"max-age" json:"max-age" yaml:"max-age"`                                                    // 密码有效期 如 90d 为空时不过期
+	GracePeriod           string   `mapstructure:"grace-period" json:"grace-period" yaml:"grace-period"`                                     // 过期后的宽限期 宽限期内仍可登录并提示修改 超过后禁止登录
+}
@@ -0,0 +1,8 @@
+package config
+
+type Totp struct {
+	Issuer               string `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                                                 // 身份验证器中显示的签发者
+	ChallengeExpiresTime string `mapstructure:"challenge-expires-time" json:"challenge-expires-time" yaml:"challenge-expires-time"` // 两步登录挑战令牌有效期
+	MaxAttempts          int    `mapstructure:"max-attempts" json:"max-attempts" yaml:"max-attempts"`                               // 每个挑战令牌允许尝试验证码的次数
+	RecoveryCodeCount    int    `mapstructure:"recovery-code-count" json:"recovery-code-count" yaml:"recovery-code-count"`          // 生成恢复码的数量
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/initialize"
@@ -33,5 +34,15 @@ func RunServer() {
 
 	address := fmt.Sprintf(":%d", global.GVA_CONFIG.System.Addr)
 
+	// 启动操作记录异步写入
+	system.OperationRecordWriterApp.Start(global.GVA_CONFIG.OperationRecord)
+
 	initServer(address, Router, 10*time.Minute, 10*time.Minute)
+
+	// 服务关闭后写入队列中剩余的操作记录
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := system.OperationRecordWriterApp.Close(ctx); err != nil {
+		zap.L().Error("写入剩余操作记录超时", zap.Error(err))
+	}
 }
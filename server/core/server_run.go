@@ -52,8 +52,10 @@ func initServer(address string, router *gin.Engine, readTimeout, writeTimeout ti
 
 	defer cancel()
 
+	// 关闭异常时仍返回 以便调用方完成操作记录等收尾工作
 	if err := srv.Shutdown(ctx); err != nil {
-		zap.L().Fatal("WEB服务关闭异常", zap.Error(err))
+		zap.L().Error("WEB服务关闭异常", zap.Error(err))
+		return
 	}
 
 	zap.L().Info("WEB服务已关闭")
@@ -12,3 +12,11 @@ type GVA_MODEL struct {
 	UpdatedAt time.Time      // 更新时间
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // 删除时间
 }
+
+// GVA_TENANT 租户字段 嵌入后该表的语句按请求所属的租户自动过滤
+type GVA_TENANT struct {
+	TenantID uint `json:"tenantId" gorm:"index;default:0;comment:所属租户ID 0为平台"` // 所属租户ID 0为平台
+}
+
+// TenantTable 标识模型按租户隔离
+func (GVA_TENANT) TenantTable() {}
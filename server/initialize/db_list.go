@@ -3,6 +3,8 @@ package initialize
 import (
 	"github.com/flipped-aurora/gin-vue-admin/server/config"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -26,6 +28,13 @@ func DBList() {
 		default:
 			continue
 		}
+		// 业务库同样按请求所属租户过滤 与主库一致
+		if db := dbMap[info.AliasName]; db != nil {
+			if err := utils.RegisterTenantCallbacks(db); err != nil {
+				global.GVA_LOG.Error("register tenant callbacks failed", zap.String("db", info.AliasName), zap.Error(err))
+				delete(dbMap, info.AliasName)
+			}
+		}
 	}
 	// 做特殊判断,是否有迁移
 	// 适配低版本迁移多数据库版本
@@ -44,6 +44,8 @@ func (e *ensureTables) MigrateTable(ctx context.Context) (context.Context, error
 		sysModel.SysDictionary{},
 		sysModel.SysAutoCodeHistory{},
 		sysModel.SysOperationRecord{},
+		sysModel.SysOperationRecordCheckpoint{},
+		sysModel.SysOperationRecordChainHead{},
 		sysModel.SysDictionaryDetail{},
 		sysModel.SysBaseMenuParameter{},
 		sysModel.SysBaseMenuBtn{},
@@ -54,6 +56,21 @@ func (e *ensureTables) MigrateTable(ctx context.Context) (context.Context, error
 		sysModel.JoinTemplate{},
 		sysModel.SysParams{},
 		sysModel.SysVersion{},
+		sysModel.SysUserTotp{},
+		sysModel.SysUserRecoveryCode{},
+		sysModel.SysUserPasswordHistory{},
+		sysModel.SysUserSession{},
+		sysModel.SysUserToken{},
+		sysModel.SysJwtKey{},
+		sysModel.SysUserIdentity{},
+		sysModel.SysDept{},
+		sysModel.SysTenant{},
+		sysModel.SysCasbinEvent{},
+		sysModel.SysAuthorityGrant{},
+		sysModel.SysAuthorityGrantLog{},
+		sysModel.SysJob{},
+		sysModel.SysJobRun{},
+		sysModel.SysCronLock{},
 		adapter.CasbinRule{},
 
 		example.ExaFile{},
@@ -86,6 +103,8 @@ func (e *ensureTables) TableCreated(ctx context.Context) bool {
 		sysModel.SysDictionary{},
 		sysModel.SysAutoCodeHistory{},
 		sysModel.SysOperationRecord{},
+		sysModel.SysOperationRecordCheckpoint{},
+		sysModel.SysOperationRecordChainHead{},
 		sysModel.SysDictionaryDetail{},
 		sysModel.SysBaseMenuParameter{},
 		sysModel.SysBaseMenuBtn{},
@@ -94,6 +113,21 @@ func (e *ensureTables) TableCreated(ctx context.Context) bool {
 		sysModel.SysExportTemplate{},
 		sysModel.Condition{},
 		sysModel.JoinTemplate{},
+		sysModel.SysUserTotp{},
+		sysModel.SysUserRecoveryCode{},
+		sysModel.SysUserPasswordHistory{},
+		sysModel.SysUserSession{},
+		sysModel.SysUserToken{},
+		sysModel.SysJwtKey{},
+		sysModel.SysUserIdentity{},
+		sysModel.SysDept{},
+		sysModel.SysTenant{},
+		sysModel.SysCasbinEvent{},
+		sysModel.SysAuthorityGrant{},
+		sysModel.SysAuthorityGrantLog{},
+		sysModel.SysJob{},
+		sysModel.SysJobRun{},
+		sysModel.SysCronLock{},
 
 		adapter.CasbinRule{},
 
@@ -6,6 +6,7 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/example"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -46,6 +47,8 @@ func RegisterTables() {
 		system.SysAuthority{},
 		system.SysDictionary{},
 		system.SysOperationRecord{},
+		system.SysOperationRecordCheckpoint{},
+		system.SysOperationRecordChainHead{},
 		system.SysAutoCodeHistory{},
 		system.SysDictionaryDetail{},
 		system.SysBaseMenuParameter{},
@@ -57,6 +60,21 @@ func RegisterTables() {
 		system.JoinTemplate{},
 		system.SysParams{},
 		system.SysVersion{},
+		system.SysUserTotp{},
+		system.SysUserRecoveryCode{},
+		system.SysUserPasswordHistory{},
+		system.SysUserSession{},
+		system.SysUserToken{},
+		system.SysJwtKey{},
+		system.SysUserIdentity{},
+		system.SysDept{},
+		system.SysTenant{},
+		system.SysCasbinEvent{},
+		system.SysAuthorityGrant{},
+		system.SysAuthorityGrantLog{},
+		system.SysJob{},
+		system.SysJobRun{},
+		system.SysCronLock{},
 
 		example.ExaFile{},
 		example.ExaCustomer{},
@@ -75,5 +93,10 @@ func RegisterTables() {
 		global.GVA_LOG.Error("register biz_table failed", zap.Error(err))
 		os.Exit(0)
 	}
+	// 嵌入global.GVA_TENANT的表按请求所属租户自动过滤
+	if err = utils.RegisterTenantCallbacks(db); err != nil {
+		global.GVA_LOG.Error("register tenant callbacks failed", zap.Error(err))
+		os.Exit(0)
+	}
 	global.GVA_LOG.Info("register table success")
 }
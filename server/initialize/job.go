@@ -0,0 +1,42 @@
+package initialize
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/task"
+	"go.uber.org/zap"
+)
+
+// Jobs 注册定时任务的处理函数 数据库已初始化时从数据库加载定时任务
+func Jobs() {
+	// 其他处理函数注册在这里 在定时任务管理中按名称引用
+	system.RegisterJobHandler("clearTable", func(json.RawMessage) error {
+		return task.ClearTable(global.GVA_DB)
+	})
+	system.RegisterJobHandler("pruneOperationRecords", func(args json.RawMessage) error {
+		// 参数 {"retentionDays": 90}
+		params := struct {
+			RetentionDays int `json:"retentionDays"`
+		}{RetentionDays: 90}
+		if len(args) > 0 {
+			if err := json.Unmarshal(args, &params); err != nil {
+				return err
+			}
+		}
+		_, err := system.OperationRecordServiceApp.PruneSysOperationRecords(time.Now().AddDate(0, 0, -params.RetentionDays))
+		return err
+	})
+
+	if global.GVA_DB == nil {
+		return
+	}
+	loaded, err := system.JobServiceApp.LoadJobs()
+	if err != nil {
+		global.GVA_LOG.Error("加载定时任务失败!", zap.Error(err))
+		return
+	}
+	global.GVA_LOG.Info("加载定时任务", zap.Int("loaded", loaded))
+}
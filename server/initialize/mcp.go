@@ -12,6 +12,9 @@ func McpRun() *server.SSEServer {
 	s := server.NewMCPServer(
 		config.Name,
 		config.Version,
+		server.WithHooks(mcpTool.Hooks()),
+		server.WithToolFilter(mcpTool.ToolFilter),
+		server.WithToolHandlerMiddleware(mcpTool.ToolAuthMiddleware),
 	)
 
 	global.GVA_MCP_SERVER = s
@@ -19,6 +19,12 @@ func OtherInit() {
 	if err != nil {
 		panic(err)
 	}
+	if global.GVA_CONFIG.JWT.UseRefreshToken {
+		_, err = utils.ParseDuration(global.GVA_CONFIG.JWT.RefreshExpiresTime)
+		if err != nil {
+			panic(err)
+		}
+	}
 
 	global.BlackCache = local_cache.NewCache(
 		local_cache.SetDefaultExpire(dr),
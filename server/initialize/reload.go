@@ -37,6 +37,9 @@ func Reload() error {
 		RegisterTables()
 	}
 
+	// 重新加载数据库中的定时任务
+	Jobs()
+
 	// 重新初始化定时任务
 	Timer()
 
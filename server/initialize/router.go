@@ -42,12 +42,12 @@ func Routers() *gin.Engine {
 
 	sseServer := McpRun()
 
-	// 注册mcp服务
-	Router.GET(global.GVA_CONFIG.MCP.SSEPath, func(c *gin.Context) {
+	// 注册mcp服务 会话与工具调用均需登录鉴权
+	Router.GET(global.GVA_CONFIG.MCP.SSEPath, middleware.McpAuth(), func(c *gin.Context) {
 		sseServer.SSEHandler().ServeHTTP(c.Writer, c.Request)
 	})
 
-	Router.POST(global.GVA_CONFIG.MCP.MessagePath, func(c *gin.Context) {
+	Router.POST(global.GVA_CONFIG.MCP.MessagePath, middleware.McpAuth(), func(c *gin.Context) {
 		sseServer.MessageHandler().ServeHTTP(c.Writer, c.Request)
 	})
 
@@ -85,6 +85,7 @@ func Routers() *gin.Engine {
 	{
 		systemRouter.InitBaseRouter(PublicGroup) // 注册基础功能路由 不做鉴权
 		systemRouter.InitInitRouter(PublicGroup) // 自动初始化相关
+		systemRouter.InitJwksRouter(PublicGroup) // jwt验签公钥
 	}
 
 	{
@@ -104,6 +105,10 @@ func Routers() *gin.Engine {
 		systemRouter.InitAuthorityBtnRouterRouter(PrivateGroup)             // 按钮权限管理
 		systemRouter.InitSysExportTemplateRouter(PrivateGroup, PublicGroup) // 导出模板
 		systemRouter.InitSysParamsRouter(PrivateGroup, PublicGroup)         // 参数管理
+		systemRouter.InitDeptRouter(PrivateGroup)                           // 部门管理
+		systemRouter.InitTenantRouter(PrivateGroup)                         // 租户管理
+		systemRouter.InitAuthorityGrantRouter(PrivateGroup)                 // 临时授权
+		systemRouter.InitSysJobRouter(PrivateGroup)                         // 定时任务
 		exampleRouter.InitCustomerRouter(PrivateGroup)                      // 客户路由
 		exampleRouter.InitFileUploadAndDownloadRouter(PrivateGroup)         // 文件上传下载功能路由
 		exampleRouter.InitAttachmentCategoryRouterRouter(PrivateGroup)      // 文件上传下载分类
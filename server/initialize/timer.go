@@ -2,34 +2,102 @@ package initialize
 
 import (
 	"fmt"
-	"github.com/flipped-aurora/gin-vue-admin/server/task"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"go.uber.org/zap"
 
 	"github.com/robfig/cron/v3"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 )
 
+// timerCronNames Timer 加入的定时任务 重新加载配置时先清除 避免重复执行
+var timerCronNames = []string{"JwtKey", "LdapSync", "AuthorityGrant", "CronCluster"}
+
 func Timer() {
 	go func() {
+		for _, name := range timerCronNames {
+			global.GVA_Timer.Clear(name)
+			utils.RemoveClusterJob(name)
+		}
+
 		var option []cron.Option
-		option = append(option, cron.WithSeconds())
-		// 清理DB定时任务
-		_, err := global.GVA_Timer.AddTaskByFunc("ClearDB", "@daily", func() {
-			err := task.ClearTable(global.GVA_DB) // 定时任务方法定在task文件包中
+		// 秒可省略 开启集群模式时 @every 按固定时间对齐 各节点触发时间一致
+		option = append(option, cron.WithParser(utils.CronParser))
+		// 清理数据库和操作记录的任务在初始化数据库时写入定时任务表 见 source/system/job.go
+		// 开启集群模式时 utils.ClusterJob 包装的任务每次触发只在一个节点执行
+		var err error
+
+		// 轮换jwt非对称签名密钥 并删除超过宽限期的旧密钥
+		if utils.IsAsymmetricJwt() {
+			_, err = global.GVA_Timer.AddTaskByFunc("JwtKey", "@hourly", utils.ClusterJob("JwtKey", "@hourly", func() {
+				rotated, err := utils.RotateJwtKeys()
+				if err != nil {
+					global.GVA_LOG.Error("轮换jwt签名密钥失败!", zap.Error(err))
+					return
+				}
+				if rotated {
+					global.GVA_LOG.Info("已轮换jwt签名密钥")
+				}
+			}), "定时轮换jwt签名密钥", option...)
+			if err != nil {
+				fmt.Println("add timer error:", err)
+			}
+		}
+
+		// 同步LDAP用户的昵称、邮箱、电话
+		if interval, _ := utils.ParseDuration(global.GVA_CONFIG.LDAP.SyncInterval); global.GVA_CONFIG.LDAP.Enable && interval > 0 {
+			spec := "@every " + interval.String()
+			_, err = global.GVA_Timer.AddTaskByFunc("LdapSync", spec, utils.ClusterJob("LdapSync", spec, func() {
+				synced, err := system.UserServiceApp.SyncLdapUsers()
+				if err != nil {
+					global.GVA_LOG.Error("同步LDAP用户失败!", zap.Error(err))
+					return
+				}
+				global.GVA_LOG.Info("同步LDAP用户", zap.Int("synced", synced))
+			}), "定时同步LDAP用户信息", option...)
+			if err != nil {
+				fmt.Println("add timer error:", err)
+			}
+		}
+
+		// 临时授权到开始时间生效 到期收回角色并使用户的令牌失效
+		_, err = global.GVA_Timer.AddTaskByFunc("AuthorityGrant", "@every 1m", utils.ClusterJob("AuthorityGrant", "@every 1m", func() {
+			activated, expired, err := system.AuthorityGrantServiceApp.ProcessGrants(time.Now())
 			if err != nil {
-				fmt.Println("timer error:", err)
+				global.GVA_LOG.Error("处理临时授权失败!", zap.Error(err))
+				return
+			}
+			if activated > 0 || expired > 0 {
+				global.GVA_LOG.Info("处理临时授权", zap.Int("activated", activated), zap.Int("expired", expired))
+			}
+		}), "定时处理临时授权的生效和到期", option...)
+		if err != nil {
+			fmt.Println("add timer error:", err)
+		}
+
+		// 集群模式下同步其他节点修改的定时任务 并检查所有节点都未运行时错过的触发 每个节点都执行
+		_, err = global.GVA_Timer.AddTaskByFunc("CronCluster", "@every 1m", func() {
+			if !global.GVA_CONFIG.Cron.Cluster || global.GVA_DB == nil {
+				return
+			}
+			if err := system.JobServiceApp.SyncJobs(); err != nil {
+				global.GVA_LOG.Error("同步定时任务失败!", zap.Error(err))
 			}
-		}, "定时清理数据库【日志，黑名单】内容", option...)
+			utils.CheckCronMisfires(time.Now())
+		}, "同步集群定时任务并检查错过的触发", option...)
 		if err != nil {
 			fmt.Println("add timer error:", err)
 		}
 
-		// 其他定时任务定在这里 参考上方使用方法
+		// 其他定时任务定在这里 参考上方使用方法 定时任务标识需加入 timerCronNames
 
-		//_, err := global.GVA_Timer.AddTaskByFunc("定时任务标识", "corn表达式", func() {
+		//_, err := global.GVA_Timer.AddTaskByFunc("定时任务标识", "corn表达式", utils.ClusterJob("集群中唯一的任务标识", "corn表达式", func() {
 		//	具体执行内容...
 		//  ......
-		//}, option...)
+		//}), option...)
 		//if err != nil {
 		//	fmt.Println("add timer error:", err)
 		//}
@@ -0,0 +1,159 @@
+package mcpTool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// ToolPathPrefix MCP工具在casbin中的资源前缀 工具 create_menu 对应策略 (/mcp/create_menu, POST)
+const ToolPathPrefix = "/mcp/"
+
+const (
+	toolMethod     = "POST"
+	recordMethod   = "MCP"
+	recordBodySize = 1024
+)
+
+// Caller MCP调用者信息 由鉴权中间件写入请求context
+type Caller struct {
+	Claims *systemReq.CustomClaims
+	IP     string
+	Agent  string
+}
+
+type callerKey struct{}
+
+// sessionUsers 记录SSE会话与GVA用户的绑定关系 key: sessionID value: userID
+var sessionUsers sync.Map
+
+// WithCaller 将调用者信息写入context
+func WithCaller(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext 从context中获取调用者信息
+func CallerFromContext(ctx context.Context) (*Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(*Caller)
+	return caller, ok && caller != nil && caller.Claims != nil
+}
+
+// ToolPath 获取工具对应的casbin资源路径
+func ToolPath(name string) string {
+	return ToolPathPrefix + name
+}
+
+// Hooks 建立会话时绑定当前用户 会话关闭时解除绑定
+func Hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		if caller, ok := CallerFromContext(ctx); ok {
+			sessionUsers.Store(session.SessionID(), caller.Claims.BaseClaims.ID)
+		}
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		sessionUsers.Delete(session.SessionID())
+	})
+	return hooks
+}
+
+// ToolAuthMiddleware 工具调用鉴权 校验会话归属与casbin权限 并写入操作记录
+func ToolAuthMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		caller, ok := CallerFromContext(ctx)
+		if !ok {
+			return nil, errors.New("未登录或非法访问")
+		}
+		record := system.SysOperationRecord{
+			Ip:     caller.IP,
+			Method: recordMethod,
+			Path:   ToolPath(request.Params.Name),
+			Agent:  caller.Agent,
+			UserID: int(caller.Claims.BaseClaims.ID),
+		}
+		// 敏感字段在截断判断前脱敏 避免记录原文
+		redactor := utils.NewRedactor(global.GVA_CONFIG.OperationRecord.Redact)
+		if body, err := json.Marshal(request.GetArguments()); err == nil {
+			if redacted := redactor.RedactBody(record.Path, string(body)); len(redacted) > recordBodySize {
+				record.Body = "[超出记录长度]"
+			} else {
+				record.Body = redacted
+			}
+		}
+		now := time.Now()
+
+		var result *mcp.CallToolResult
+		err := authorizeTool(ctx, caller, request.Params.Name)
+		if err == nil {
+			result, err = next(ctx, request)
+		}
+
+		record.Latency = time.Since(now)
+		record.Status = 200
+		if err != nil {
+			record.Status = 500
+			if errors.Is(err, errToolForbidden) {
+				record.Status = 403
+			}
+			record.ErrorMessage = err.Error()
+		} else if result != nil {
+			if result.IsError {
+				record.Status = 500
+			}
+			if resp, e := json.Marshal(result.Content); e == nil {
+				if redacted := redactor.RedactResp(record.Path, string(resp)); len(redacted) <= recordBodySize {
+					record.Resp = redacted
+				}
+			}
+		}
+		// 与接口的操作记录一样经过异步写入器 接在哈希链末尾
+		systemService.OperationRecordWriterApp.Write(record)
+		return result, err
+	}
+}
+
+var errToolForbidden = errors.New("权限不足")
+
+// authorizeTool 校验调用者是否为会话所有者 以及角色是否拥有该工具的调用权限
+func authorizeTool(ctx context.Context, caller *Caller, name string) error {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		if owner, ok := sessionUsers.Load(session.SessionID()); ok && owner.(uint) != caller.Claims.BaseClaims.ID {
+			return errors.New("会话不属于当前用户")
+		}
+	}
+	sub := strconv.Itoa(int(caller.Claims.AuthorityId))
+	success, _ := utils.CasbinEnforce(sub, utils.TenantDomain(caller.Claims.TenantID), ToolPath(name), toolMethod)
+	if !success {
+		global.GVA_LOG.Warn("mcp工具调用被拒绝", zap.String("tool", name), zap.String("user", caller.Claims.Username))
+		return errToolForbidden
+	}
+	return nil
+}
+
+// ToolFilter tools/list 时仅返回当前用户有权调用的工具
+func ToolFilter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	caller, ok := CallerFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	sub, dom := strconv.Itoa(int(caller.Claims.AuthorityId)), utils.TenantDomain(caller.Claims.TenantID)
+	allowed := make([]mcp.Tool, 0, len(tools))
+	for i := range tools {
+		if success, _ := utils.CasbinEnforce(sub, dom, ToolPath(tools[i].Name), toolMethod); success {
+			allowed = append(allowed, tools[i])
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,60 @@
+package mcpTool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/glebarez/sqlite"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
+)
+
+func TestToolAuthMiddlewareRecordChain(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&system.SysAuthority{}, &gormadapter.CasbinRule{}, &system.SysOperationRecord{}, &system.SysOperationRecordCheckpoint{}, &system.SysOperationRecordChainHead{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db.Create(&gormadapter.CasbinRule{Ptype: "p", V0: "888", V1: ToolPath("echo"), V2: toolMethod})
+	global.GVA_DB = db
+	global.GVA_LOG = zap.NewNop()
+
+	handler := ToolAuthMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(`{"token":"abc"}`), nil
+	})
+	caller := &Caller{Claims: &systemReq.CustomClaims{BaseClaims: systemReq.BaseClaims{ID: 1, AuthorityId: 888}}}
+	ctx := WithCaller(context.Background(), caller)
+	for _, name := range []string{"echo", "forbidden"} {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = map[string]interface{}{"username": "admin", "password": "123456"}
+		_, _ = handler(ctx, request)
+	}
+
+	var records []system.SysOperationRecord
+	db.Order("id").Find(&records)
+	if len(records) != 2 || records[0].Status != 200 || records[1].Status != 403 {
+		t.Fatalf("records = %+v", records)
+	}
+	for _, record := range records {
+		if record.Hash == "" || strings.Contains(record.Body, "123456") {
+			t.Errorf("record should be chained and redacted: %+v", record)
+		}
+	}
+	result, err := systemService.OperationRecordServiceApp.VerifySysOperationRecordChain()
+	if err != nil || !result.Valid {
+		t.Errorf("verify = %+v, %v", result, err)
+	}
+}
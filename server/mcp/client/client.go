@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	mcpClient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
-func NewClient(baseUrl, name, version, serverName string) (*mcpClient.Client, error) {
-	client, err := mcpClient.NewSSEMCPClient(baseUrl)
+// NewClient 创建并初始化SSE客户端 服务端开启鉴权时可通过 transport.WithHeaders 携带令牌
+func NewClient(baseUrl, name, version, serverName string, options ...transport.ClientOption) (*mcpClient.Client, error) {
+	client, err := mcpClient.NewSSEMCPClient(baseUrl, options...)
 	if err != nil {
 		return nil, err
 	}
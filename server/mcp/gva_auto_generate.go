@@ -155,6 +155,7 @@ func (t *AutomationModuleAnalyzer) New() mcp.Tool {
     "gvaModel": "是否使用GVA模型(bool) 固定为true 后续不需要创建ID created_at deleted_at updated_at",
     "autoMigrate": "是否自动迁移(bool)",
     "autoCreateResource": "是否创建资源(bool)",
+    "autoCreateTenant": "是否按租户隔离数据(bool)",
     "autoCreateApiToSql": "是否创建API(bool)",
     "autoCreateMenuToSql": "是否创建菜单(bool)",
     "autoCreateBtnAuth": "是否创建按钮权限(bool)",
@@ -773,6 +774,7 @@ func (t *AutomationModuleAnalyzer) handleAnalyze(ctx context.Context, request mc
     "gvaModel": true,
     "autoMigrate": true,
     "autoCreateResource": true/false 用户不特地强调开启资源标识则为false,
+    "autoCreateTenant": true/false 用户不特地强调按租户隔离数据则为false,
     "autoCreateApiToSql": true,
     "autoCreateMenuToSql": true,
     "autoCreateBtnAuth": false/true 用户不特地强调创建按钮权限则为false,
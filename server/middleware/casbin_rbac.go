@@ -1,13 +1,20 @@
 package middleware
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // CasbinHandler 拦截器
@@ -23,13 +30,36 @@ func CasbinHandler() gin.HandlerFunc {
 		c.Set("userID", waitUse.BaseClaims.ID)
 		c.Set("authorityIds", waitUse.AuthorityIds)
 		sub := strconv.Itoa(int(waitUse.AuthorityId))
-		e := utils.GetCasbin() // 判断策略中是否存在
-		success, _ := e.Enforce(sub, obj, act)
+		// 开启域模型时租户用户按所属租户的域判断 平台用户使用默认域
+		success, _ := utils.CasbinEnforce(sub, utils.TenantDomain(waitUse.TenantID), obj, act) // 判断策略中是否存在
+		if c.GetHeader("X-Permission-Debug") != "" && slices.Contains(global.GVA_CONFIG.Casbin.DebugAuthorities, waitUse.AuthorityId) {
+			setPermissionExplain(c, waitUse.BaseClaims.ID, waitUse.AuthorityId, obj, act)
+		}
 		if !success {
 			response.FailWithDetailed(gin.H{}, "权限不足", c)
 			c.Abort()
 			return
 		}
+		// 个人访问令牌只能访问创建时限定的接口
+		if record, ok := c.Get("userToken"); ok && !systemService.UserTokenAllows(record.(system.SysUserToken).Scopes, obj, act) {
+			response.FailWithDetailed(gin.H{}, "个人令牌无权访问该接口", c)
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
+
+// setPermissionExplain 将本次请求的权限判定说明写入响应头 供超级管理员排查权限问题
+func setPermissionExplain(c *gin.Context, userID, authorityID uint, path, method string) {
+	res, err := systemService.CasbinServiceApp.ExplainPermission(systemReq.PermissionExplain{UserID: userID, AuthorityId: authorityID, Path: path, Method: method})
+	if err != nil {
+		global.GVA_LOG.Error("权限模拟失败!", zap.Error(err))
+		return
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+	c.Header("X-Permission-Explain", base64.StdEncoding.EncodeToString(data))
+}
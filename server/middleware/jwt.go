@@ -3,9 +3,12 @@ package middleware
 import (
 	"errors"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
@@ -21,6 +24,23 @@ func JWTAuth() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		// 个人访问令牌 供脚本、CI等程序调用接口
+		if systemService.IsUserToken(token) {
+			claims, record, err := systemService.UserTokenServiceApp.Authenticate(token, c.ClientIP())
+			if err != nil {
+				if !errors.Is(err, systemService.ErrUserTokenInvalid) {
+					global.GVA_LOG.Error("校验个人令牌失败!", zap.Error(err))
+				}
+				response.NoAuth(systemService.ErrUserTokenInvalid.Error(), c)
+				c.Abort()
+				return
+			}
+			c.Set("claims", claims)
+			c.Set("userToken", record)
+			setTenantScope(c, claims.TenantID)
+			c.Next()
+			return
+		}
 		if isBlacklist(token) {
 			response.NoAuth("您的帐户异地登陆或令牌失效", c)
 			utils.ClearToken(c)
@@ -43,16 +63,31 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
-		// 已登录用户被管理员禁用 需要使该用户的jwt失效 此处比较消耗性能 如果需要 请自行打开
-		// 用户被删除的逻辑 需要优化 此处比较消耗性能 如果需要 请自行打开
+		// MCP专用令牌只能访问MCP服务 刷新令牌只能用于换取新令牌
+		if utils.IsMcpClaims(claims) || utils.IsRefreshClaims(claims) {
+			response.NoAuth("该令牌无法访问此接口", c)
+			c.Abort()
+			return
+		}
 
-		//if user, err := userService.FindUserByUuid(claims.UUID.String()); err != nil || user.Enable == 2 {
-		//	_ = jwtService.JsonInBlacklist(system.JwtBlacklist{Jwt: token})
-		//	response.FailWithDetailed(gin.H{"reload": true}, err.Error(), c)
-		//	c.Abort()
-		//}
+		// 会话被撤销(强制下线、用户被禁用或删除)后 令牌在本节点加入黑名单
+		if systemService.UserSessionServiceApp.Revoked(claims) {
+			global.BlackCache.SetDefault(token, struct{}{})
+			response.NoAuth("登录会话已失效，请重新登录", c)
+			utils.ClearToken(c)
+			c.Abort()
+			return
+		}
+		// 须修改密码(管理员要求或密码已过期)时 修改密码前只能修改密码或注销
+		if claims.MustChangePassword && !passwordChangeAllows(c) {
+			response.FailWithDetailed(gin.H{"mustChangePassword": true}, "请先修改密码", c)
+			c.Abort()
+			return
+		}
 		c.Set("claims", claims)
-		if claims.ExpiresAt.Unix()-time.Now().Unix() < claims.BufferTime {
+		setTenantScope(c, claims.TenantID)
+		// 开启刷新令牌模式后 访问令牌过期需由前端调用 /base/refresh 换取 不再自动续期
+		if !global.GVA_CONFIG.JWT.UseRefreshToken && claims.ExpiresAt.Unix()-time.Now().Unix() < claims.BufferTime {
 			dr, _ := utils.ParseDuration(global.GVA_CONFIG.JWT.ExpiresTime)
 			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(dr))
 			newToken, _ := j.CreateTokenByOldToken(token, *claims)
@@ -64,6 +99,7 @@ func JWTAuth() gin.HandlerFunc {
 				// 记录新的活跃jwt
 				_ = utils.SetRedisJWT(newToken, newClaims.Username)
 			}
+			_ = systemService.UserSessionServiceApp.RotateSession(newClaims.FamilyID, newToken, newClaims.ExpiresAt.Time)
 		}
 		c.Next()
 
@@ -76,6 +112,30 @@ func JWTAuth() gin.HandlerFunc {
 	}
 }
 
+// passwordChangeApis 须修改密码的令牌可访问的接口
+var passwordChangeApis = map[string]string{
+	"/user/changePassword": "POST",
+	"/jwt/jsonInBlacklist": "POST",
+}
+
+func passwordChangeAllows(c *gin.Context) bool {
+	path := strings.TrimPrefix(c.Request.URL.Path, global.GVA_CONFIG.System.RouterPrefix)
+	method, ok := passwordChangeApis[path]
+	return ok && method == c.Request.Method
+}
+
+// setTenantScope 将请求所属的租户写入context 携带该context的语句由租户回调按租户过滤
+func setTenantScope(c *gin.Context, tenantID uint) {
+	if tenantID == 0 {
+		return
+	}
+	// 初始化数据库后 global.GVA_DB 会被替换 确保新实例也注册了回调
+	if err := utils.RegisterTenantCallbacks(global.GVA_DB); err != nil {
+		global.GVA_LOG.Error("注册租户回调失败!", zap.Error(err))
+	}
+	c.Request = c.Request.WithContext(utils.WithTenant(c.Request.Context(), tenantID))
+}
+
 //@author: [piexlmax](https://github.com/piexlmax)
 //@function: IsBlacklist
 //@description: 判断JWT是否在黑名单内部
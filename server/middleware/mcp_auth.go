@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	mcpTool "github.com/flipped-aurora/gin-vue-admin/server/mcp"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// McpAuth MCP SSE/消息端点鉴权 接受普通登录jwt或MCP专用令牌
+// 令牌可通过 x-token 或 Authorization: Bearer 请求头携带
+func McpAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Request.Header.Get("x-token")
+		if token == "" {
+			token = bearerToken(c.Request.Header.Get("Authorization"))
+		}
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "未登录或非法访问，请登录"})
+			return
+		}
+		if isBlacklist(token) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "您的帐户异地登陆或令牌失效"})
+			return
+		}
+		claims, err := utils.NewJWT().ParseToken(token)
+		if err != nil {
+			msg := err.Error()
+			if errors.Is(err, utils.TokenExpired) {
+				msg = "登录已过期，请重新登录"
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": msg})
+			return
+		}
+		if utils.IsRefreshClaims(claims) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "该令牌无法访问此接口"})
+			return
+		}
+		var user system.SysUser
+		if err = global.GVA_DB.Select("id", "enable").Where("uuid = ?", claims.UUID).First(&user).Error; err != nil || user.Enable != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "用户不存在或已被禁用"})
+			return
+		}
+		c.Set("claims", claims)
+		c.Request = c.Request.WithContext(mcpTool.WithCaller(c.Request.Context(), &mcpTool.Caller{
+			Claims: claims,
+			IP:     c.ClientIP(),
+			Agent:  c.Request.UserAgent(),
+		}))
+		c.Next()
+	}
+}
+
+func bearerToken(authorization string) string {
+	const prefix = "Bearer "
+	if len(authorization) > len(prefix) && strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return strings.TrimSpace(authorization[len(prefix):])
+	}
+	return ""
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -72,14 +73,18 @@ func OperationRecord() gin.HandlerFunc {
 			UserID: userId,
 		}
 
+		// 敏感字段在截断判断前脱敏 避免记录原文
+		redactor := utils.NewRedactor(global.GVA_CONFIG.OperationRecord.Redact)
+
 		// 上传文件时候 中间件日志进行裁断操作
 		if strings.Contains(c.GetHeader("Content-Type"), "multipart/form-data") {
 			record.Body = "[文件]"
 		} else {
-			if len(body) > bufferSize {
+			redacted := redactor.RedactBody(record.Path, string(body))
+			if len(redacted) > bufferSize {
 				record.Body = "[超出记录长度]"
 			} else {
-				record.Body = string(body)
+				record.Body = redacted
 			}
 		}
 
@@ -96,7 +101,7 @@ func OperationRecord() gin.HandlerFunc {
 		record.ErrorMessage = c.Errors.ByType(gin.ErrorTypePrivate).String()
 		record.Status = c.Writer.Status()
 		record.Latency = latency
-		record.Resp = writer.body.String()
+		record.Resp = redactor.RedactResp(record.Path, writer.body.String())
 
 		if strings.Contains(c.Writer.Header().Get("Pragma"), "public") ||
 			strings.Contains(c.Writer.Header().Get("Expires"), "0") ||
@@ -112,9 +117,7 @@ func OperationRecord() gin.HandlerFunc {
 				record.Body = "超出记录长度"
 			}
 		}
-		if err := global.GVA_DB.Create(&record).Error; err != nil {
-			global.GVA_LOG.Error("create operation record error:", zap.Error(err))
-		}
+		systemService.OperationRecordWriterApp.Write(record)
 	}
 }
 
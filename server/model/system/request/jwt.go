@@ -9,14 +9,17 @@ import (
 type CustomClaims struct {
 	BaseClaims
 	BufferTime int64
+	FamilyID   string `json:"FamilyID,omitempty"` // 令牌族 同一次登录签发及刷新得到的令牌属于同一族
 	jwt.RegisteredClaims
 }
 
 type BaseClaims struct {
-	UUID         uuid.UUID
-	ID           uint
-	Username     string
-	NickName     string
-	AuthorityId  uint
-	AuthorityIds []uint
+	UUID               uuid.UUID
+	ID                 uint
+	Username           string
+	NickName           string
+	AuthorityId        uint
+	AuthorityIds       []uint
+	TenantID           uint `json:"TenantID,omitempty"`           // 所属租户ID 0为平台用户
+	MustChangePassword bool `json:"MustChangePassword,omitempty"` // 须先修改密码 令牌仅能访问修改密码和注销接口
 }
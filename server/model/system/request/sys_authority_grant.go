@@ -0,0 +1,38 @@
+package request
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+// CreateAuthorityGrant 创建临时授权
+type CreateAuthorityGrant struct {
+	UserID          uint       `json:"userId" binding:"required"`      // 被授权用户ID
+	AuthorityId     uint       `json:"authorityId" binding:"required"` // 授予的角色ID
+	StartAt         *time.Time `json:"startAt"`                        // 开始时间 为空时立即开始
+	EndAt           time.Time  `json:"endAt" binding:"required"`       // 结束时间
+	Reason          string     `json:"reason"`                         // 授权原因
+	RequireApproval bool       `json:"requireApproval"`                // 是否需要更高级别角色的用户审批 为自己申请时必须审批
+}
+
+// ReviewAuthorityGrant 审批临时授权
+type ReviewAuthorityGrant struct {
+	ID      uint   `json:"ID" binding:"required"` // 临时授权ID
+	Approve bool   `json:"approve"`               // 是否批准
+	Remark  string `json:"remark"`                // 审批意见
+}
+
+// RevokeAuthorityGrant 撤销临时授权
+type RevokeAuthorityGrant struct {
+	ID     uint   `json:"ID" binding:"required"` // 临时授权ID
+	Remark string `json:"remark"`                // 撤销原因
+}
+
+// GetAuthorityGrantList 分页获取临时授权
+type GetAuthorityGrantList struct {
+	request.PageInfo
+	UserID      uint   `json:"userId" form:"userId"`           // 被授权用户ID
+	AuthorityId uint   `json:"authorityId" form:"authorityId"` // 角色ID
+	Status      string `json:"status" form:"status"`           // 状态
+}
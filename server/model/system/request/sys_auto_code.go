@@ -23,6 +23,7 @@ type AutoCode struct {
 	GvaModel            bool                   `json:"gvaModel" example:"false"`            // 是否使用gva默认Model
 	AutoMigrate         bool                   `json:"autoMigrate" example:"false"`         // 是否自动迁移表结构
 	AutoCreateResource  bool                   `json:"autoCreateResource" example:"false"`  // 是否自动创建资源标识
+	AutoCreateTenant    bool                   `json:"autoCreateTenant" example:"false"`    // 是否按租户隔离数据
 	AutoCreateApiToSql  bool                   `json:"autoCreateApiToSql" example:"false"`  // 是否自动创建api
 	AutoCreateMenuToSql bool                   `json:"autoCreateMenuToSql" example:"false"` // 是否自动创建menu
 	AutoCreateBtnAuth   bool                   `json:"autoCreateBtnAuth" example:"false"`   // 是否自动创建按钮权限
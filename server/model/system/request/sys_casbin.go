@@ -9,6 +9,7 @@ type CasbinInfo struct {
 // CasbinInReceive Casbin structure for input parameters
 type CasbinInReceive struct {
 	AuthorityId uint         `json:"authorityId"` // 权限id
+	Domain      string       `json:"domain"`      // 域 开启域模型时有效 为空时为对所有域生效的策略
 	CasbinInfos []CasbinInfo `json:"casbinInfos"`
 }
 
@@ -25,3 +26,11 @@ func DefaultCasbin() []CasbinInfo {
 		{Path: "/sysDictionary/findSysDictionary", Method: "GET"},
 	}
 }
+
+// PermissionExplain 权限模拟 指定用户时按用户的当前角色判断 也可以只指定角色
+type PermissionExplain struct {
+	UserID      uint   `json:"userId"`      // 用户ID
+	AuthorityId uint   `json:"authorityId"` // 角色ID 指定用户时为空则使用用户的当前角色
+	Path        string `json:"path"`        // 接口路径
+	Method      string `json:"method"`      // 请求方法
+}
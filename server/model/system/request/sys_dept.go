@@ -0,0 +1,7 @@
+package request
+
+// SetUserDept 设置用户所属部门
+type SetUserDept struct {
+	UserId uint `json:"userId" binding:"required"` // 用户ID
+	DeptId uint `json:"deptId"`                    // 部门ID 0为清除
+}
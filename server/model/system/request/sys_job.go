@@ -0,0 +1,20 @@
+package request
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+// SysJobSearch 分页获取定时任务
+type SysJobSearch struct {
+	request.PageInfo
+	Name    string `json:"name" form:"name"`       // 任务名称
+	Handler string `json:"handler" form:"handler"` // 处理函数名称
+	Enabled *bool  `json:"enabled" form:"enabled"` // 是否启用
+}
+
+// SysJobRunSearch 分页获取定时任务执行记录
+type SysJobRunSearch struct {
+	request.PageInfo
+	JobID  uint   `json:"jobId" form:"jobId"`   // 定时任务ID
+	Status string `json:"status" form:"status"` // 状态
+}
@@ -1,6 +1,8 @@
 package request
 
 import (
+	"time"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 )
@@ -8,4 +10,13 @@ import (
 type SysOperationRecordSearch struct {
 	system.SysOperationRecord
 	request.PageInfo
+	CreatedAtRange []time.Time `json:"createdAtRange" form:"createdAtRange[]"` // 创建时间范围
+	Keyword        string      `json:"keyword" form:"keyword"`                 // 关键字 匹配路径、请求Body和错误信息
+}
+
+// SysOperationRecordStatistics 操作记录统计条件
+type SysOperationRecordStatistics struct {
+	StartTime *time.Time `json:"startTime" form:"startTime"` // 开始时间 为空时取结束时间前24小时
+	EndTime   *time.Time `json:"endTime" form:"endTime"`     // 结束时间 为空时取当前时间
+	Top       int        `json:"top" form:"top"`             // 按用户、路径统计时返回的条数 默认10
 }
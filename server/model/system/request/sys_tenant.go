@@ -0,0 +1,36 @@
+package request
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
+)
+
+// CreateTenant 创建租户 同时创建租户管理员角色和租户管理员
+type CreateTenant struct {
+	Name                string     `json:"name" binding:"required"`                // 租户名称
+	Code                string     `json:"code" binding:"required"`                // 租户编码
+	ExpiresAt           *time.Time `json:"expiresAt"`                              // 到期时间 为空时不限
+	Remark              string     `json:"remark"`                                 // 备注
+	AuthorityId         uint       `json:"authorityId" binding:"required"`         // 租户管理员角色ID
+	TemplateAuthorityId uint       `json:"templateAuthorityId" binding:"required"` // 复制该角色的菜单、按钮和接口权限作为租户管理员的权限
+	Username            string     `json:"userName" binding:"required"`            // 租户管理员用户名
+	Password            string     `json:"passWord" binding:"required"`            // 租户管理员密码
+	NickName            string     `json:"nickName"`                               // 租户管理员昵称
+}
+
+// UpdateTenant 修改租户
+type UpdateTenant struct {
+	ID        uint       `json:"ID" binding:"required"` // 租户ID
+	Name      string     `json:"name"`                  // 租户名称
+	Enable    int        `json:"enable"`                // 租户状态 1正常 2停用
+	ExpiresAt *time.Time `json:"expiresAt"`             // 到期时间 为空时不限
+	Remark    string     `json:"remark"`                // 备注
+}
+
+// GetTenantList 分页获取租户
+type GetTenantList struct {
+	request.PageInfo
+	Name string `json:"name" form:"name"` // 租户名称
+	Code string `json:"code" form:"code"` // 租户编码
+}
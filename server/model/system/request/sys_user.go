@@ -1,6 +1,8 @@
 package request
 
 import (
+	"time"
+
 	common "github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 )
@@ -16,6 +18,7 @@ type Register struct {
 	AuthorityIds []uint `json:"authorityIds" swaggertype:"string" example:"[]uint 角色id"`
 	Phone        string `json:"phone" example:"电话号码"`
 	Email        string `json:"email" example:"电子邮箱"`
+	DeptId       uint   `json:"deptId" swaggertype:"string" example:"int 部门id"`
 }
 
 // Login User login structure
@@ -26,6 +29,11 @@ type Login struct {
 	CaptchaId string `json:"captchaId"` // 验证码ID
 }
 
+// RefreshToken 刷新令牌请求
+type RefreshToken struct {
+	RefreshToken string `json:"refreshToken" binding:"required"` // 刷新令牌
+}
+
 // ChangePasswordReq Modify password structure
 type ChangePasswordReq struct {
 	ID          uint   `json:"-"`           // 从 JWT 中提取 user id，避免越权
@@ -57,6 +65,7 @@ type ChangeUserInfo struct {
 	Email        string                `json:"email"  gorm:"comment:用户邮箱"`                                                           // 用户邮箱
 	HeaderImg    string                `json:"headerImg" gorm:"default:https://qmplusimg.henrongyi.top/gva_header.jpg;comment:用户头像"` // 用户头像
 	Enable       int                   `json:"enable" gorm:"comment:冻结用户"`                                                           //冻结用户
+	DeptId       uint                  `json:"deptId" gorm:"comment:所属部门ID"`                                                         // 所属部门ID
 	Authorities  []system.SysAuthority `json:"-" gorm:"many2many:sys_user_authority;"`
 }
 
@@ -66,4 +75,37 @@ type GetUserList struct {
 	NickName string `json:"nickName" form:"nickName"`
 	Phone    string `json:"phone" form:"phone"`
 	Email    string `json:"email" form:"email"`
+	DeptId   uint   `json:"deptId" form:"deptId"`
+	TenantID *uint  `json:"tenantId" form:"tenantId"` // 所属租户ID 0为平台用户 租户管理员只能查询本租户
+}
+
+// GetSessionList 管理员查询在线会话
+type GetSessionList struct {
+	common.PageInfo
+	Username string `json:"username" form:"username"` // 用户名
+	TenantID *uint  `json:"-"`                        // 所属租户ID 租户管理员只能查询本租户用户的会话
+}
+
+// RevokeSession 撤销会话
+type RevokeSession struct {
+	SessionID string `json:"sessionId" form:"sessionId"` // 会话ID
+}
+
+// CreateUserToken 创建个人访问令牌
+type CreateUserToken struct {
+	Name      string       `json:"name" binding:"required"` // 令牌名称
+	ExpiresAt *time.Time   `json:"expiresAt"`               // 过期时间 为空时长期有效
+	Scopes    []CasbinInfo `json:"scopes"`                  // 允许访问的接口 为空时与所属用户权限相同
+}
+
+// UpdateUserToken 修改个人访问令牌 令牌本身不变
+type UpdateUserToken struct {
+	ID uint `json:"id" binding:"required"` // 令牌ID
+	CreateUserToken
+}
+
+// OidcLogin 单点登录回调 前端将身份提供方返回的code和state原样提交
+type OidcLogin struct {
+	Code  string `json:"code" binding:"required"`  // 授权码
+	State string `json:"state" binding:"required"` // 发起授权时生成的state
 }
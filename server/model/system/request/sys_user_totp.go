@@ -0,0 +1,19 @@
+package request
+
+// TotpLogin 两步登录 提交验证码或恢复码
+type TotpLogin struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"` // 挑战令牌
+	Code           string `json:"code"`                              // 身份验证器验证码
+	RecoveryCode   string `json:"recoveryCode"`                      // 恢复码 无法使用验证器时使用
+}
+
+// TotpChallenge 强制绑定身份验证器
+type TotpChallenge struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"` // 挑战令牌
+}
+
+// TotpVerify 启用/关闭两步验证 重新生成恢复码
+type TotpVerify struct {
+	Code         string `json:"code"`         // 身份验证器验证码
+	RecoveryCode string `json:"recoveryCode"` // 恢复码 仅关闭两步验证时可用
+}
@@ -7,3 +7,28 @@ import (
 type PolicyPathResponse struct {
 	Paths []request.CasbinInfo `json:"paths"`
 }
+
+// PermissionExplain 权限模拟结果 说明接口是否放行及原因 以及角色可见的菜单、按钮和生效的数据权限
+type PermissionExplain struct {
+	UserID         uint             `json:"userId"`                   // 用户ID
+	AuthorityId    uint             `json:"authorityId"`              // 角色ID
+	AuthorityName  string           `json:"authorityName"`            // 角色名称
+	Domain         string           `json:"domain"`                   // 判断时使用的域 为空时为默认域
+	Path           string           `json:"path"`                     // 接口路径
+	Method         string           `json:"method"`                   // 请求方法
+	Allowed        bool             `json:"allowed"`                  // 是否放行
+	Policy         []string         `json:"policy"`                   // 命中的策略 未命中时为空
+	ApiRegistered  bool             `json:"apiRegistered"`            // 接口是否已在API管理中登记
+	Reason         string           `json:"reason"`                   // 放行或拒绝的原因
+	Menus          []PermissionMenu `json:"menus"`                    // 角色可见的菜单及按钮
+	DataPermission interface{}      `json:"dataPermission,omitempty"` // 生效的数据权限 未启用数据权限插件时为空
+}
+
+// PermissionMenu 角色可见的菜单及菜单下可见的按钮
+type PermissionMenu struct {
+	ID       uint     `json:"id"`       // 菜单ID
+	ParentId uint     `json:"parentId"` // 父菜单ID
+	Name     string   `json:"name"`     // 路由name
+	Title    string   `json:"title"`    // 菜单名
+	Btns     []string `json:"btns"`     // 可见的按钮
+}
@@ -0,0 +1,19 @@
+package response
+
+// JSONWebKey RFC 7517 格式的公钥
+type JSONWebKey struct {
+	Kty string `json:"kty"`           // 密钥类型 RSA EC OKP
+	Kid string `json:"kid"`           // 密钥ID 对应令牌头部kid
+	Use string `json:"use"`           // 用途 固定为sig
+	Alg string `json:"alg"`           // 签名算法
+	N   string `json:"n,omitempty"`   // RSA模数
+	E   string `json:"e,omitempty"`   // RSA指数
+	Crv string `json:"crv,omitempty"` // 曲线 P-256 Ed25519
+	X   string `json:"x,omitempty"`   // 曲线公钥X坐标 Ed25519公钥
+	Y   string `json:"y,omitempty"`   // 曲线公钥Y坐标
+}
+
+// JSONWebKeySet 下游服务验签使用的公钥集合
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
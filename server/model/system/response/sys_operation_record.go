@@ -0,0 +1,81 @@
+package response
+
+import "time"
+
+// OperationRecordWriterStats 操作记录写入器运行指标
+type OperationRecordWriterStats struct {
+	Async         bool   `json:"async"`         // 是否异步写入
+	DropPolicy    string `json:"dropPolicy"`    // 队列满时的处理策略
+	QueueDepth    int    `json:"queueDepth"`    // 队列中待写入的记录数
+	QueueCapacity int    `json:"queueCapacity"` // 队列容量
+	Enqueued      uint64 `json:"enqueued"`      // 入队记录数
+	Written       uint64 `json:"written"`       // 已写入记录数
+	Dropped       uint64 `json:"dropped"`       // 因队列已满丢弃的记录数
+	Failed        uint64 `json:"failed"`        // 写入失败的记录数
+	SyncWrites    uint64 `json:"syncWrites"`    // 同步写入次数
+	Batches       uint64 `json:"batches"`       // 批量写入次数
+}
+
+// OperationRecordStatistics 操作记录统计
+type OperationRecordStatistics struct {
+	StartTime    time.Time                   `json:"startTime"`    // 统计开始时间
+	EndTime      time.Time                   `json:"endTime"`      // 统计结束时间
+	Total        int64                       `json:"total"`        // 请求总数
+	ErrorCount   int64                       `json:"errorCount"`   // 状态码>=400的请求数
+	AvgLatencyMs float64                     `json:"avgLatencyMs"` // 平均耗时(毫秒)
+	Latency      OperationRecordPercentiles  `json:"latency"`      // 耗时分位数
+	ByUser       []OperationRecordUserStat   `json:"byUser"`       // 按用户统计 按请求数降序
+	ByPath       []OperationRecordPathStat   `json:"byPath"`       // 按路径统计 按请求数降序
+	ByStatus     []OperationRecordStatusStat `json:"byStatus"`     // 按状态码统计
+}
+
+// OperationRecordPercentiles 耗时分位数(毫秒)
+type OperationRecordPercentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// OperationRecordUserStat 按用户统计
+type OperationRecordUserStat struct {
+	UserID       int     `json:"userId"`       // 用户id
+	Username     string  `json:"username"`     // 用户名
+	Count        int64   `json:"count"`        // 请求数
+	ErrorCount   int64   `json:"errorCount"`   // 失败请求数
+	AvgLatencyMs float64 `json:"avgLatencyMs"` // 平均耗时(毫秒)
+}
+
+// OperationRecordPathStat 按路径统计
+type OperationRecordPathStat struct {
+	Method       string  `json:"method"`       // 请求方法
+	Path         string  `json:"path"`         // 请求路径
+	Count        int64   `json:"count"`        // 请求数
+	ErrorCount   int64   `json:"errorCount"`   // 失败请求数
+	AvgLatencyMs float64 `json:"avgLatencyMs"` // 平均耗时(毫秒)
+	MaxLatencyMs float64 `json:"maxLatencyMs"` // 最大耗时(毫秒)
+}
+
+// OperationRecordStatusStat 按状态码统计
+type OperationRecordStatusStat struct {
+	Status int   `json:"status"` // 状态码
+	Count  int64 `json:"count"`  // 请求数
+}
+
+// OperationRecordChainVerification 操作记录哈希链校验结果
+type OperationRecordChainVerification struct {
+	Valid        bool                        `json:"valid"`        // 哈希链是否完整
+	Checked      int64                       `json:"checked"`      // 校验的记录数 含已删除的记录
+	Legacy       int64                       `json:"legacy"`       // 启用哈希链之前写入的记录数
+	CheckpointID uint                        `json:"checkpointId"` // 校验起点 清理检查点中最后一条被清理记录的ID
+	LastID       uint                        `json:"lastId"`       // 最后一条校验的记录ID
+	Breaks       []OperationRecordChainBreak `json:"breaks"`       // 断裂位置 最多返回100条
+	Truncated    bool                        `json:"truncated"`    // 断裂位置是否超过返回上限
+}
+
+// OperationRecordChainBreak 哈希链断裂位置
+type OperationRecordChainBreak struct {
+	ID     uint   `json:"id"`     // 记录ID
+	Reason string `json:"reason"` // 断裂原因
+}
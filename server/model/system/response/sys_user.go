@@ -9,7 +9,37 @@ type SysUserResponse struct {
 }
 
 type LoginResponse struct {
-	User      system.SysUser `json:"user"`
-	Token     string         `json:"token"`
-	ExpiresAt int64          `json:"expiresAt"`
+	User             system.SysUser `json:"user"`
+	Token            string         `json:"token"`
+	ExpiresAt        int64          `json:"expiresAt"`
+	RefreshToken     string         `json:"refreshToken,omitempty"`     // 刷新令牌 仅开启刷新令牌模式时返回
+	RefreshExpiresAt int64          `json:"refreshExpiresAt,omitempty"` // 刷新令牌过期时间
+	RecoveryCodes    []string       `json:"recoveryCodes,omitempty"`    // 登录时首次启用两步验证返回的恢复码
+	PasswordState    PasswordState  `json:"passwordState"`              // 密码状态 须修改或即将过期时前端应引导修改密码
+}
+
+// 须修改密码的原因
+const (
+	PasswordChangeRequired = "required" // 首次登录或管理员重置后须修改密码
+	PasswordChangeExpired  = "expired"  // 密码已过期 处于宽限期内
+)
+
+// PasswordState 登录用户的密码状态
+type PasswordState struct {
+	MustChange     bool   `json:"mustChange"`               // 是否须修改密码
+	Reason         string `json:"reason,omitempty"`         // 须修改密码的原因 required/expired
+	ExpiresAt      int64  `json:"expiresAt,omitempty"`      // 密码过期时间 未设置有效期时为空
+	GraceExpiresAt int64  `json:"graceExpiresAt,omitempty"` // 宽限期结束时间 之后禁止登录
+}
+
+// OidcAuthorize 单点登录授权地址
+type OidcAuthorize struct {
+	Name    string `json:"name"`    // 身份提供方名称
+	AuthURL string `json:"authUrl"` // 前端跳转的授权地址
+}
+
+// UserTokenCreated 创建个人访问令牌 令牌明文只在创建时返回一次
+type UserTokenCreated struct {
+	Token string              `json:"token"` // 令牌明文
+	Info  system.SysUserToken `json:"info"`  // 令牌信息
 }
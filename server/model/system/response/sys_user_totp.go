@@ -0,0 +1,27 @@
+package response
+
+// TotpChallengeResponse 密码校验通过 需继续提交两步验证码
+type TotpChallengeResponse struct {
+	NeedTotp       bool   `json:"needTotp"`       // 需要两步验证
+	NeedEnroll     bool   `json:"needEnroll"`     // 角色强制两步验证但尚未绑定 需先绑定身份验证器
+	ChallengeToken string `json:"challengeToken"` // 挑战令牌
+	ExpiresAt      int64  `json:"expiresAt"`      // 挑战令牌过期时间
+}
+
+// TotpSetupResponse 绑定身份验证器
+type TotpSetupResponse struct {
+	Secret string `json:"secret"` // 密钥 无法扫码时手动输入
+	Uri    string `json:"uri"`    // otpauth:// 链接 前端生成二维码
+}
+
+// TotpStatusResponse 两步验证状态
+type TotpStatusResponse struct {
+	Enabled           bool  `json:"enabled"`           // 是否已启用
+	Required          bool  `json:"required"`          // 所属角色是否强制启用
+	RecoveryCodesLeft int64 `json:"recoveryCodesLeft"` // 剩余恢复码数量
+}
+
+// TotpRecoveryCodesResponse 恢复码 仅在生成时返回一次
+type TotpRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
@@ -2,6 +2,8 @@ package system
 
 import (
 	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
 )
 
 type SysAuthority struct {
@@ -16,6 +18,8 @@ type SysAuthority struct {
 	SysBaseMenus    []SysBaseMenu   `json:"menus" gorm:"many2many:sys_authority_menus;"`
 	Users           []SysUser       `json:"-" gorm:"many2many:sys_user_authority;"`
 	DefaultRouter   string          `json:"defaultRouter" gorm:"comment:默认菜单;default:dashboard"` // 默认菜单(默认dashboard)
+	RequireTotp     bool            `json:"requireTotp" gorm:"default:false;comment:是否强制两步验证"`   // 是否强制该角色用户使用两步验证
+	global.GVA_TENANT
 }
 
 func (SysAuthority) TableName() string {
@@ -0,0 +1,58 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// 临时授权状态
+const (
+	GrantStatusPending  = "pending"  // 待审批
+	GrantStatusApproved = "approved" // 已批准 未到开始时间
+	GrantStatusActive   = "active"   // 生效中
+	GrantStatusRejected = "rejected" // 已驳回
+	GrantStatusRevoked  = "revoked"  // 已撤销
+	GrantStatusExpired  = "expired"  // 已到期
+)
+
+// SysAuthorityGrant 临时授权 在开始和结束时间之间为用户附加角色 到期后由定时任务收回
+type SysAuthorityGrant struct {
+	global.GVA_MODEL
+	UserID          uint                   `json:"userId" gorm:"index;comment:被授权用户ID"`         // 被授权用户ID
+	AuthorityId     uint                   `json:"authorityId" gorm:"comment:授予的角色ID"`          // 授予的角色ID
+	StartAt         time.Time              `json:"startAt" gorm:"comment:开始时间"`                 // 开始时间
+	EndAt           time.Time              `json:"endAt" gorm:"index;comment:结束时间"`             // 结束时间
+	Reason          string                 `json:"reason" gorm:"comment:授权原因"`                  // 授权原因
+	Status          string                 `json:"status" gorm:"index;size:16;comment:状态"`      // 状态 pending approved active rejected revoked expired
+	RequireApproval bool                   `json:"requireApproval" gorm:"comment:是否需要审批"`       // 是否需要审批
+	GrantedBy       uint                   `json:"grantedBy" gorm:"comment:申请人ID"`              // 申请人ID
+	ApprovedBy      uint                   `json:"approvedBy" gorm:"comment:审批人ID"`             // 审批人ID
+	ApprovedAt      *time.Time             `json:"approvedAt" gorm:"comment:审批时间"`              // 审批时间
+	RevokedBy       uint                   `json:"revokedBy" gorm:"comment:撤销人ID 0为到期自动收回"`     // 撤销人ID 0为到期自动收回
+	RevokedAt       *time.Time             `json:"revokedAt" gorm:"comment:收回时间"`               // 收回时间
+	Assigned        bool                   `json:"-" gorm:"comment:生效时是否新增了用户角色 收回时只删除新增的用户角色"` // 生效时用户尚未拥有该角色 收回时删除
+	User            SysUser                `json:"user" gorm:"foreignKey:UserID"`
+	Authority       SysAuthority           `json:"authority" gorm:"foreignKey:AuthorityId;references:AuthorityId"`
+	Logs            []SysAuthorityGrantLog `json:"logs" gorm:"foreignKey:GrantID"`
+}
+
+func (SysAuthorityGrant) TableName() string {
+	return "sys_authority_grants"
+}
+
+// SysAuthorityGrantLog 临时授权审计记录 记录申请、审批、生效和收回
+type SysAuthorityGrantLog struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time `json:"createdAt"`
+	GrantID     uint      `json:"grantId" gorm:"index;comment:临时授权ID"`
+	UserID      uint      `json:"userId" gorm:"comment:被授权用户ID"`
+	AuthorityId uint      `json:"authorityId" gorm:"comment:角色ID"`
+	Action      string    `json:"action" gorm:"size:16;comment:操作 create approve reject activate revoke expire"`
+	OperatorID  uint      `json:"operatorId" gorm:"comment:操作人ID 0为定时任务"`
+	Remark      string    `json:"remark" gorm:"comment:备注"`
+}
+
+func (SysAuthorityGrantLog) TableName() string {
+	return "sys_authority_grant_logs"
+}
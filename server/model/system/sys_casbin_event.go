@@ -0,0 +1,15 @@
+package system
+
+import "time"
+
+// SysCasbinEvent 策略变更事件 多节点使用数据库轮询同步策略时写入
+type SysCasbinEvent struct {
+	ID        uint      `gorm:"primarykey"`
+	Node      string    `gorm:"size:64;comment:发布事件的节点"`
+	Payload   string    `gorm:"type:text;comment:策略变更内容"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (SysCasbinEvent) TableName() string {
+	return "sys_casbin_events"
+}
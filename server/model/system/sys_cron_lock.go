@@ -0,0 +1,15 @@
+package system
+
+import "time"
+
+// SysCronLock 定时任务的分布式锁 未启用redis时使用 记录最近一次已被节点领取的触发时间
+type SysCronLock struct {
+	Name      string `gorm:"primarykey;size:128;comment:任务标识"`
+	LastFire  int64  `gorm:"comment:最近一次执行的触发时间 unix秒"`
+	Node      string `gorm:"size:128;comment:执行的节点"`
+	UpdatedAt time.Time
+}
+
+func (SysCronLock) TableName() string {
+	return "sys_cron_locks"
+}
@@ -0,0 +1,22 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysDept 部门/组织机构 通过ParentId构成树形结构
+type SysDept struct {
+	global.GVA_MODEL
+	ParentId uint      `json:"parentId" gorm:"index;default:0;comment:父部门ID 0为根部门"` // 父部门ID
+	DeptName string    `json:"deptName" gorm:"comment:部门名称"`                        // 部门名称
+	Leader   string    `json:"leader" gorm:"comment:负责人"`                           // 负责人
+	Phone    string    `json:"phone" gorm:"comment:联系电话"`                           // 联系电话
+	Email    string    `json:"email" gorm:"comment:邮箱"`                             // 邮箱
+	Sort     int       `json:"sort" gorm:"default:0;comment:排序"`                    // 排序
+	Enable   int       `json:"enable" gorm:"default:1;comment:部门状态 1正常 2停用"`        // 部门状态 1正常 2停用
+	Children []SysDept `json:"children" gorm:"-"`
+}
+
+func (SysDept) TableName() string {
+	return "sys_depts"
+}
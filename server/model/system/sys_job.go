@@ -0,0 +1,54 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// 定时任务执行状态
+const (
+	JobRunStatusRunning = "running" // 执行中
+	JobRunStatusSuccess = "success" // 成功
+	JobRunStatusFailed  = "failed"  // 失败
+)
+
+// 定时任务触发方式
+const (
+	JobTriggerCron   = "cron"   // 按cron表达式触发
+	JobTriggerManual = "manual" // 手动立即执行
+)
+
+// SysJob 定时任务 执行的处理函数在代码中按名称注册 启动和重新加载配置时从数据库加载
+type SysJob struct {
+	global.GVA_MODEL
+	Name        string `json:"name" form:"name" gorm:"index;size:64;comment:任务名称" binding:"required"`   // 任务名称
+	Spec        string `json:"spec" form:"spec" gorm:"size:64;comment:cron表达式 支持秒" binding:"required"`  // cron表达式 支持秒
+	Handler     string `json:"handler" form:"handler" gorm:"size:64;comment:处理函数名称" binding:"required"` // 处理函数名称
+	Args        string `json:"args" form:"args" gorm:"type:text;comment:JSON参数"`                        // JSON参数 传给处理函数
+	Enabled     bool   `json:"enabled" form:"enabled" gorm:"comment:是否启用"`                              // 是否启用 暂停时为false
+	Description string `json:"description" form:"description" gorm:"comment:任务说明"`                      // 任务说明
+}
+
+func (SysJob) TableName() string {
+	return "sys_jobs"
+}
+
+// SysJobRun 定时任务执行记录
+type SysJobRun struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"index"`
+	JobID      uint       `json:"jobId" gorm:"index;comment:定时任务ID"`
+	JobName    string     `json:"jobName" gorm:"size:64;comment:任务名称"`
+	Handler    string     `json:"handler" gorm:"size:64;comment:处理函数名称"`
+	Trigger    string     `json:"trigger" gorm:"size:16;comment:触发方式 cron manual"`
+	Status     string     `json:"status" gorm:"index;size:16;comment:状态 running success failed"`
+	StartedAt  time.Time  `json:"startedAt" gorm:"comment:开始时间"`
+	FinishedAt *time.Time `json:"finishedAt" gorm:"comment:结束时间"`
+	Duration   int64      `json:"duration" gorm:"comment:耗时 毫秒"`
+	Error      string     `json:"error" gorm:"type:text;comment:错误信息"`
+}
+
+func (SysJobRun) TableName() string {
+	return "sys_job_runs"
+}
@@ -0,0 +1,21 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysJwtKey jwt非对称签名密钥 未退役的最新密钥用于签名 退役后在宽限期内继续用于验签
+type SysJwtKey struct {
+	global.GVA_MODEL
+	Kid        string     `json:"kid" gorm:"size:64;uniqueIndex;comment:密钥ID"`   // 密钥ID 写入令牌头部kid
+	Algorithm  string     `json:"algorithm" gorm:"size:16;index;comment:签名算法"`   // 签名算法
+	PrivateKey string     `json:"-" gorm:"type:text;comment:私钥PEM"`              // 私钥 PKCS8 PEM
+	PublicKey  string     `json:"publicKey" gorm:"type:text;comment:公钥PEM"`      // 公钥 PKIX PEM
+	RetiredAt  *time.Time `json:"retiredAt" gorm:"index;comment:退役时间 退役后不再用于签名"` // 退役时间
+}
+
+func (SysJwtKey) TableName() string {
+	return "sys_jwt_keys"
+}
@@ -20,5 +20,25 @@ type SysOperationRecord struct {
 	Body         string        `json:"body" form:"body" gorm:"type:text;column:body;comment:请求Body"`                 // 请求Body
 	Resp         string        `json:"resp" form:"resp" gorm:"type:text;column:resp;comment:响应Body"`                 // 响应Body
 	UserID       int           `json:"user_id" form:"user_id" gorm:"column:user_id;comment:用户id"`                    // 用户id
+	PrevHash     string        `json:"prev_hash" form:"-" gorm:"size:64;column:prev_hash;comment:上一条记录哈希"`           // 上一条记录哈希
+	Hash         string        `json:"hash" form:"-" gorm:"size:64;column:hash;comment:记录哈希"`                        // 记录哈希 由上一条记录哈希和本条内容计算
 	User         SysUser       `json:"user"`
 }
+
+// SysOperationRecordChainHead 操作记录哈希链头 写入和清理记录的事务先锁定该行 使各节点按顺序接续哈希链
+type SysOperationRecordChainHead struct {
+	ID        uint `gorm:"primarykey"`
+	UpdatedAt time.Time
+}
+
+func (SysOperationRecordChainHead) TableName() string {
+	return "sys_operation_record_chain_heads"
+}
+
+// SysOperationRecordCheckpoint 操作记录清理检查点 记录被清理的最后一条记录的哈希 作为哈希链新的起点
+type SysOperationRecordCheckpoint struct {
+	global.GVA_MODEL
+	RecordID uint   `json:"recordId" gorm:"index;comment:被清理的最后一条记录ID"` // 被清理的最后一条记录ID
+	Hash     string `json:"hash" gorm:"size:64;comment:被清理的最后一条记录哈希"`   // 被清理的最后一条记录哈希
+	Pruned   int64  `json:"pruned" gorm:"comment:清理的记录数"`               // 清理的记录数
+}
@@ -0,0 +1,22 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysTenant 租户 同一部署中相互隔离的客户
+type SysTenant struct {
+	global.GVA_MODEL
+	Name        string     `json:"name" gorm:"comment:租户名称"`                     // 租户名称
+	Code        string     `json:"code" gorm:"uniqueIndex;size:64;comment:租户编码"` // 租户编码
+	AuthorityId uint       `json:"authorityId" gorm:"comment:租户管理员角色ID"`         // 租户管理员角色ID
+	Enable      int        `json:"enable" gorm:"default:1;comment:租户状态 1正常 2停用"` // 租户状态 1正常 2停用
+	ExpiresAt   *time.Time `json:"expiresAt" gorm:"comment:到期时间 为空时不限"`          // 到期时间 为空时不限
+	Remark      string     `json:"remark" gorm:"comment:备注"`                     // 备注
+}
+
+func (SysTenant) TableName() string {
+	return "sys_tenants"
+}
@@ -1,6 +1,8 @@
 package system
 
 import (
+	"time"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common"
 	"github.com/google/uuid"
@@ -12,6 +14,9 @@ type Login interface {
 	GetUUID() uuid.UUID
 	GetUserId() uint
 	GetAuthorityId() uint
+	GetAuthorityIds() []uint
+	GetTenantId() uint
+	GetMustChangePassword() bool
 	GetUserInfo() any
 }
 
@@ -19,18 +24,22 @@ var _ Login = new(SysUser)
 
 type SysUser struct {
 	global.GVA_MODEL
-	UUID          uuid.UUID      `json:"uuid" gorm:"index;comment:用户UUID"`                                                                   // 用户UUID
-	Username      string         `json:"userName" gorm:"index;comment:用户登录名"`                                                                // 用户登录名
-	Password      string         `json:"-"  gorm:"comment:用户登录密码"`                                                                           // 用户登录密码
-	NickName      string         `json:"nickName" gorm:"default:系统用户;comment:用户昵称"`                                                          // 用户昵称
-	HeaderImg     string         `json:"headerImg" gorm:"default:https://qmplusimg.henrongyi.top/gva_header.jpg;comment:用户头像"`               // 用户头像
-	AuthorityId   uint           `json:"authorityId" gorm:"default:888;comment:用户角色ID"`                                                      // 用户角色ID
-	Authority     SysAuthority   `json:"authority" gorm:"foreignKey:AuthorityId;references:AuthorityId;comment:用户角色"`                        // 用户角色
-	Authorities   []SysAuthority `json:"authorities" gorm:"many2many:sys_user_authority;"`                                                   // 多用户角色
-	Phone         string         `json:"phone"  gorm:"comment:用户手机号"`                                                                        // 用户手机号
-	Email         string         `json:"email"  gorm:"comment:用户邮箱"`                                                                         // 用户邮箱
-	Enable        int            `json:"enable" gorm:"default:1;comment:用户是否被冻结 1正常 2冻结"`                                                    //用户是否被冻结 1正常 2冻结
-	OriginSetting common.JSONMap `json:"originSetting" form:"originSetting" gorm:"type:text;default:null;column:origin_setting;comment:配置;"` //配置
+	global.GVA_TENANT
+	UUID               uuid.UUID      `json:"uuid" gorm:"index;comment:用户UUID"`                                                                   // 用户UUID
+	Username           string         `json:"userName" gorm:"index;comment:用户登录名"`                                                                // 用户登录名
+	Password           string         `json:"-"  gorm:"comment:用户登录密码"`                                                                           // 用户登录密码
+	NickName           string         `json:"nickName" gorm:"default:系统用户;comment:用户昵称"`                                                          // 用户昵称
+	HeaderImg          string         `json:"headerImg" gorm:"default:https://qmplusimg.henrongyi.top/gva_header.jpg;comment:用户头像"`               // 用户头像
+	AuthorityId        uint           `json:"authorityId" gorm:"default:888;comment:用户角色ID"`                                                      // 用户角色ID
+	Authority          SysAuthority   `json:"authority" gorm:"foreignKey:AuthorityId;references:AuthorityId;comment:用户角色"`                        // 用户角色
+	Authorities        []SysAuthority `json:"authorities" gorm:"many2many:sys_user_authority;"`                                                   // 多用户角色
+	Phone              string         `json:"phone"  gorm:"comment:用户手机号"`                                                                        // 用户手机号
+	Email              string         `json:"email"  gorm:"comment:用户邮箱"`                                                                         // 用户邮箱
+	Enable             int            `json:"enable" gorm:"default:1;comment:用户是否被冻结 1正常 2冻结"`                                                    //用户是否被冻结 1正常 2冻结
+	DeptId             uint           `json:"deptId" gorm:"index;default:0;comment:所属部门ID"`                                                       // 所属部门ID
+	OriginSetting      common.JSONMap `json:"originSetting" form:"originSetting" gorm:"type:text;default:null;column:origin_setting;comment:配置;"` //配置
+	PasswordChangedAt  *time.Time     `json:"passwordChangedAt" gorm:"comment:密码修改时间"`                                                            // 密码修改时间 用于计算密码有效期
+	MustChangePassword bool           `json:"mustChangePassword" gorm:"comment:下次登录须修改密码"`                                                        // 下次登录须修改密码
 }
 
 func (SysUser) TableName() string {
@@ -57,6 +66,22 @@ func (s *SysUser) GetAuthorityId() uint {
 	return s.AuthorityId
 }
 
+func (s *SysUser) GetAuthorityIds() []uint {
+	ids := make([]uint, 0, len(s.Authorities))
+	for i := range s.Authorities {
+		ids = append(ids, s.Authorities[i].AuthorityId)
+	}
+	return ids
+}
+
+func (s *SysUser) GetMustChangePassword() bool {
+	return s.MustChangePassword
+}
+
+func (s *SysUser) GetTenantId() uint {
+	return s.TenantID
+}
+
 func (s *SysUser) GetUserInfo() any {
 	return *s
 }
@@ -0,0 +1,17 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysUserIdentity 外部身份与本地用户的关联 同一身份提供方的subject只能关联一个用户
+type SysUserIdentity struct {
+	global.GVA_MODEL
+	UserID   uint   `json:"userId" gorm:"index;comment:用户ID"`                                        // 用户ID
+	Provider string `json:"provider" gorm:"size:32;uniqueIndex:idx_provider_subject;comment:身份提供方"`  // 身份提供方 如oidc
+	Subject  string `json:"subject" gorm:"size:191;uniqueIndex:idx_provider_subject;comment:外部用户标识"` // 身份提供方中的用户唯一标识
+}
+
+func (SysUserIdentity) TableName() string {
+	return "sys_user_identities"
+}
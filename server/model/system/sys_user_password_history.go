@@ -0,0 +1,16 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysUserPasswordHistory 用户历史密码 用于限制重复使用最近的密码
+type SysUserPasswordHistory struct {
+	global.GVA_MODEL
+	UserID   uint   `json:"userId" gorm:"index;comment:用户ID"` // 用户ID
+	Password string `json:"-" gorm:"comment:密码哈希"`            // 密码哈希
+}
+
+func (SysUserPasswordHistory) TableName() string {
+	return "sys_user_password_histories"
+}
@@ -0,0 +1,27 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysUserSession 用户登录会话 每次登录一条 刷新令牌或续期时更新当前令牌
+type SysUserSession struct {
+	global.GVA_MODEL
+	SessionID  string     `json:"sessionId" gorm:"size:64;uniqueIndex;comment:会话ID"` // 会话ID 即令牌中的FamilyID
+	UserID     uint       `json:"userId" gorm:"index;comment:用户ID"`                  // 用户ID
+	Username   string     `json:"username" gorm:"index;comment:用户名"`                 // 用户名
+	Token      string     `json:"-" gorm:"type:text;comment:当前访问令牌"`                 // 当前访问令牌 撤销时加入黑名单
+	Ip         string     `json:"ip" gorm:"comment:登录ip"`                            // 登录ip
+	UserAgent  string     `json:"userAgent" gorm:"type:text;comment:登录代理"`           // 登录代理
+	IssuedAt   time.Time  `json:"issuedAt" gorm:"comment:登录时间"`                      // 登录时间
+	LastSeenAt time.Time  `json:"lastSeenAt" gorm:"comment:最近访问时间"`                  // 最近访问时间 每分钟最多更新一次
+	ExpiresAt  time.Time  `json:"expiresAt" gorm:"index;comment:过期时间"`               // 过期时间 开启刷新令牌时为刷新令牌过期时间
+	RevokedAt  *time.Time `json:"revokedAt" gorm:"comment:撤销时间"`                     // 撤销时间
+	Current    bool       `json:"current" gorm:"-"`                                  // 是否为当前请求所属会话
+}
+
+func (SysUserSession) TableName() string {
+	return "sys_user_sessions"
+}
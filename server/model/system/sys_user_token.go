@@ -0,0 +1,30 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysUserToken 个人访问令牌 供脚本、CI等程序调用接口 只保存令牌哈希
+type SysUserToken struct {
+	global.GVA_MODEL
+	UserID     uint                `json:"userId" gorm:"index;comment:所属用户ID"`                      // 所属用户ID
+	Name       string              `json:"name" gorm:"comment:令牌名称"`                                // 令牌名称
+	Prefix     string              `json:"prefix" gorm:"comment:令牌前缀"`                              // 令牌前缀 用于辨认令牌
+	TokenHash  string              `json:"-" gorm:"size:64;uniqueIndex;comment:令牌哈希"`               // 令牌的sha256哈希
+	Scopes     []SysUserTokenScope `json:"scopes" gorm:"serializer:json;type:text;comment:允许访问的接口"` // 允许访问的接口 为空时与所属用户权限相同
+	ExpiresAt  *time.Time          `json:"expiresAt" gorm:"comment:过期时间"`                           // 过期时间 为空时长期有效
+	LastUsedAt *time.Time          `json:"lastUsedAt" gorm:"comment:最近使用时间"`                        // 最近使用时间 每分钟最多更新一次
+	LastUsedIp string              `json:"lastUsedIp" gorm:"comment:最近使用ip"`                        // 最近使用ip
+}
+
+// SysUserTokenScope 令牌允许访问的接口 须为所属用户角色拥有的casbin策略
+type SysUserTokenScope struct {
+	Path   string `json:"path"`   // 路径
+	Method string `json:"method"` // 方法
+}
+
+func (SysUserToken) TableName() string {
+	return "sys_user_tokens"
+}
@@ -0,0 +1,33 @@
+package system
+
+import (
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+// SysUserTotp 用户两步验证(TOTP)设置
+type SysUserTotp struct {
+	global.GVA_MODEL
+	UserID      uint       `json:"userId" gorm:"uniqueIndex;comment:用户ID"` // 用户ID
+	Secret      string     `json:"-" gorm:"comment:TOTP密钥"`                // TOTP密钥
+	Enabled     bool       `json:"enabled" gorm:"comment:是否已启用"`           // 是否已启用 绑定验证器并校验通过后启用
+	LastCounter uint64     `json:"-" gorm:"comment:最近一次使用的时间步"`            // 最近一次使用的时间步 防止验证码重放
+	EnabledAt   *time.Time `json:"enabledAt" gorm:"comment:启用时间"`          // 启用时间
+}
+
+func (SysUserTotp) TableName() string {
+	return "sys_user_totps"
+}
+
+// SysUserRecoveryCode 两步验证恢复码 每个仅能使用一次
+type SysUserRecoveryCode struct {
+	global.GVA_MODEL
+	UserID   uint       `json:"userId" gorm:"index;comment:用户ID"` // 用户ID
+	CodeHash string     `json:"-" gorm:"size:64;comment:恢复码哈希"`   // 恢复码哈希
+	UsedAt   *time.Time `json:"usedAt" gorm:"comment:使用时间"`       // 使用时间
+}
+
+func (SysUserRecoveryCode) TableName() string {
+	return "sys_user_recovery_codes"
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
-	"gorm.io/gen"
-	"path/filepath" //go:generate go mod tidy
-	//go:generate go mod download
-	//go:generate go run gen.go
+	"path/filepath"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/plugin/announcement/model"
+	"gorm.io/gen"
 )
 
+//go:generate go mod tidy
+//go:generate go mod download
+//go:generate go run gen.go
+
 func main() {
 	g := gen.NewGenerator(gen.Config{OutPath: filepath.Join("..", "..", "..", "announcement", "blender", "model", "dao"), Mode: gen.WithoutContext | gen.WithDefaultQuery | gen.WithQueryInterface})
 	g.ApplyBasic(
@@ -7,6 +7,7 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/response"
 	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model/request"
 	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/service"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -254,3 +255,61 @@ func (api *DataPermissionApi) GetStatistics(c *gin.Context) {
 
 	response.OkWithDetailed(stats, "获取成功", c)
 }
+
+// GetCacheStats 获取权限缓存统计
+// @Tags DataPermission
+// @Summary 获取权限缓存命中统计
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Success 200 {object} response.Response{data=global.CacheStats,msg=string} "获取成功"
+// @Router /datapermission/cacheStats [get]
+func (api *DataPermissionApi) GetCacheStats(c *gin.Context) {
+	response.OkWithDetailed(dataPermissionService.GetCacheStats(), "获取成功", c)
+}
+
+// ClearCache 清除权限缓存
+// @Tags DataPermission
+// @Summary 清除所有节点的权限缓存并重置命中统计
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Success 200 {object} response.Response{msg=string} "清除成功"
+// @Router /datapermission/clearCache [post]
+func (api *DataPermissionApi) ClearCache(c *gin.Context) {
+	dataPermissionService.ClearCache()
+	response.OkWithMessage("清除成功", c)
+}
+
+// TestPermission 测试数据权限
+// @Tags DataPermission
+// @Summary 测试角色和用户在受控表上的数据权限 返回编译后的条件
+// @Security ApiKeyAuth
+// @accept application/json
+// @Produce application/json
+// @Param data body request.PermissionTestRequest true "角色ID, 表名, 用户ID(为空时使用当前用户)"
+// @Success 200 {object} response.Response{data=response.PermissionTestResponse,msg=string} "测试成功"
+// @Router /datapermission/testPermission [post]
+func (api *DataPermissionApi) TestPermission(c *gin.Context) {
+	var req request.PermissionTestRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	if req.AuthorityID == 0 || req.Table == "" {
+		response.FailWithMessage("参数不能为空", c)
+		return
+	}
+	if req.UserID == 0 {
+		req.UserID = utils.GetUserID(c)
+	}
+
+	result, err := dataPermissionService.TestPermission(req)
+	if err != nil {
+		global.GVA_LOG.Error("测试失败!", zap.Error(err))
+		response.FailWithMessage("测试失败:"+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(result, "测试成功", c)
+}
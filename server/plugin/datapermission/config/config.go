@@ -31,6 +31,8 @@ type CacheConfig struct {
 	Expiration int `mapstructure:"expiration" json:"expiration" yaml:"expiration"`
 	// 缓存键前缀
 	KeyPrefix string `mapstructure:"key-prefix" json:"keyPrefix" yaml:"key-prefix"`
+	// 是否使用Redis作为共享的二级缓存（需开启 system.use-redis）
+	Redis bool `mapstructure:"redis" json:"redis" yaml:"redis"`
 }
 
 // LogConfig 日志配置
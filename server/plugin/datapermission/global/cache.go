@@ -0,0 +1,373 @@
+package global
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gvaGlobal "github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// PermissionCache 权限缓存 按表名分组缓存受控表、角色数据权限和角色字段权限
+// 进程内缓存为一级缓存 开启 redis 后以Redis哈希作为多节点共享的二级缓存
+// 配置变更时通过Redis发布订阅通知所有节点失效
+// Redis中每张表另有一个失效时递增的版本号 加载前读取 写入时版本已变化则放弃 避免旧数据覆盖
+type PermissionCache struct {
+	mu sync.RWMutex
+	// 缓存条目 key: tableName -> table / data:authorityId / field:authorityId
+	entries map[string]map[string]cacheEntry
+	config  config.CacheConfig
+	// generation 每次失效递增 加载期间发生失效时不写入缓存 避免旧数据覆盖
+	generation atomic.Uint64
+
+	hits          atomic.Uint64
+	redisHits     atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+
+	subscribeOnce sync.Once
+}
+
+// cacheEntry 缓存条目 value 为 nil 表示数据库中不存在该记录
+type cacheEntry struct {
+	value    interface{}
+	expireAt time.Time
+}
+
+// CacheStats 缓存统计
+type CacheStats struct {
+	Enabled       bool    `json:"enabled"`       // 是否启用缓存
+	Redis         bool    `json:"redis"`         // 是否使用Redis二级缓存
+	Tables        int     `json:"tables"`        // 已缓存的表数量
+	Entries       int     `json:"entries"`       // 进程内缓存条目数量
+	Hits          uint64  `json:"hits"`          // 进程内缓存命中次数
+	RedisHits     uint64  `json:"redisHits"`     // Redis缓存命中次数
+	Misses        uint64  `json:"misses"`        // 未命中(查询数据库)次数
+	Invalidations uint64  `json:"invalidations"` // 失效次数
+	HitRate       float64 `json:"hitRate"`       // 命中率
+}
+
+const (
+	cacheKeyTable = "table"
+	cacheKeyData  = "data:"
+	cacheKeyField = "field:"
+)
+
+// redisSetScript 版本号未变化时写入缓存字段 哈希没有过期时间时才设置 不延长已有字段的有效期
+// KEYS: 缓存哈希, 版本号 ARGV: 字段, 值, 加载前读取的版本号, 有效期毫秒
+var redisSetScript = redis.NewScript(`
+if (redis.call('GET', KEYS[2]) or '0') ~= ARGV[3] then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+if tonumber(ARGV[4]) > 0 and redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[4])
+end
+return 1
+`)
+
+// NewPermissionCache 创建新的权限缓存
+func NewPermissionCache(cfg config.CacheConfig) *PermissionCache {
+	return &PermissionCache{
+		entries: make(map[string]map[string]cacheEntry),
+		config:  cfg,
+	}
+}
+
+// SetConfig 更新缓存配置 并清空进程内缓存
+func (c *PermissionCache) SetConfig(cfg config.CacheConfig) {
+	c.mu.Lock()
+	c.config = cfg
+	c.entries = make(map[string]map[string]cacheEntry)
+	c.mu.Unlock()
+	c.generation.Add(1)
+}
+
+// GetControlledTable 获取启用的受控表 表不受控时返回 nil 返回值为共享数据 不可修改
+func (c *PermissionCache) GetControlledTable(tableName string, loader func() (*model.ControlledTable, error)) (*model.ControlledTable, error) {
+	return load(c, tableName, cacheKeyTable, loader)
+}
+
+// GetDataPermission 获取角色在表上启用的数据权限配置 未配置时返回 nil 返回值为共享数据 不可修改
+func (c *PermissionCache) GetDataPermission(authorityId uint, tableName string, loader func() (*model.RoleDataPermission, error)) (*model.RoleDataPermission, error) {
+	return load(c, tableName, cacheKeyData+strconv.FormatUint(uint64(authorityId), 10), loader)
+}
+
+// GetFieldPermissions 获取角色在表上启用的字段权限 key 为字段名 返回值为共享数据 不可修改
+func (c *PermissionCache) GetFieldPermissions(authorityId uint, tableName string, loader func() (map[string]model.RoleFieldPermission, error)) (map[string]model.RoleFieldPermission, error) {
+	return load(c, tableName, cacheKeyField+strconv.FormatUint(uint64(authorityId), 10), loader)
+}
+
+// Invalidate 使指定表的缓存失效 并通知其他节点
+func (c *PermissionCache) Invalidate(tableNames ...string) {
+	if c == nil || len(tableNames) == 0 {
+		return
+	}
+	c.invalidateLocal(tableNames)
+	if c.redisEnabled() {
+		// 先递增版本号 使加载中的旧数据无法写入
+		ctx := context.Background()
+		pipe := gvaGlobal.GVA_REDIS.Pipeline()
+		for _, tableName := range tableNames {
+			pipe.Incr(ctx, c.generationKey(tableName))
+			pipe.Del(ctx, c.redisKey(tableName))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			gvaGlobal.GVA_LOG.Error("数据权限插件: 删除Redis缓存失败", zap.Error(err))
+		}
+	}
+	c.publish(tableNames)
+}
+
+// ClearAllCache 清除所有缓存 并通知其他节点
+func (c *PermissionCache) ClearAllCache() {
+	if c == nil {
+		return
+	}
+	c.invalidateLocal(nil)
+	if c.redisEnabled() {
+		ctx := context.Background()
+		// 加载时会创建版本号 递增全部版本号即可使加载中的旧数据无法写入
+		iter := gvaGlobal.GVA_REDIS.Scan(ctx, 0, c.cfg().KeyPrefix+"generation:*", 100).Iterator()
+		for iter.Next(ctx) {
+			gvaGlobal.GVA_REDIS.Incr(ctx, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			gvaGlobal.GVA_LOG.Error("数据权限插件: 清除Redis缓存失败", zap.Error(err))
+		}
+		iter = gvaGlobal.GVA_REDIS.Scan(ctx, 0, c.cfg().KeyPrefix+"cache:*", 100).Iterator()
+		for iter.Next(ctx) {
+			gvaGlobal.GVA_REDIS.Del(ctx, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			gvaGlobal.GVA_LOG.Error("数据权限插件: 清除Redis缓存失败", zap.Error(err))
+		}
+	}
+	c.publish(nil)
+}
+
+// Stats 获取缓存统计
+func (c *PermissionCache) Stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.RLock()
+	stats := CacheStats{Enabled: c.config.Enabled, Tables: len(c.entries)}
+	for _, entries := range c.entries {
+		stats.Entries += len(entries)
+	}
+	c.mu.RUnlock()
+	stats.Redis = c.redisEnabled()
+	stats.Hits = c.hits.Load()
+	stats.RedisHits = c.redisHits.Load()
+	stats.Misses = c.misses.Load()
+	stats.Invalidations = c.invalidations.Load()
+	if total := stats.Hits + stats.RedisHits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits+stats.RedisHits) / float64(total)
+	}
+	return stats
+}
+
+// ResetStats 重置命中统计
+func (c *PermissionCache) ResetStats() {
+	if c == nil {
+		return
+	}
+	c.hits.Store(0)
+	c.redisHits.Store(0)
+	c.misses.Store(0)
+	c.invalidations.Store(0)
+}
+
+// Subscribe 订阅其他节点发布的失效通知 只订阅一次 未启用Redis时不订阅
+func (c *PermissionCache) Subscribe() {
+	if c == nil || !c.pubSubEnabled() {
+		return
+	}
+	c.subscribeOnce.Do(func() {
+		pubSub := gvaGlobal.GVA_REDIS.Subscribe(context.Background(), c.channel())
+		go func() {
+			for msg := range pubSub.Channel() {
+				var tableNames []string
+				if err := json.Unmarshal([]byte(msg.Payload), &tableNames); err != nil {
+					gvaGlobal.GVA_LOG.Error("数据权限插件: 解析缓存失效通知失败", zap.Error(err))
+					continue
+				}
+				c.invalidateLocal(tableNames)
+			}
+		}()
+	})
+}
+
+// load 读取缓存 依次查询进程内缓存、Redis缓存 都未命中时从数据库加载并写入缓存
+func load[T any](c *PermissionCache, tableName, key string, loader func() (T, error)) (T, error) {
+	if c == nil || !c.enabled() {
+		return loader()
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[tableName][key]
+	c.mu.RUnlock()
+	if ok && (entry.expireAt.IsZero() || time.Now().Before(entry.expireAt)) {
+		c.hits.Add(1)
+		return cachedValue[T](entry.value), nil
+	}
+
+	generation := c.generation.Load()
+	redisGeneration, redisOk := c.redisGeneration(tableName)
+	if value, ok := redisGet[T](c, tableName, key); ok {
+		c.redisHits.Add(1)
+		c.store(generation, tableName, key, value)
+		return value, nil
+	}
+
+	c.misses.Add(1)
+	value, err := loader()
+	if err != nil {
+		return value, err
+	}
+	if c.store(generation, tableName, key, value) && redisOk {
+		c.redisSet(tableName, key, value, redisGeneration)
+	}
+	return value, nil
+}
+
+// cachedValue 将缓存值还原为具体类型 nil 表示记录不存在
+func cachedValue[T any](value interface{}) T {
+	v, _ := value.(T)
+	return v
+}
+
+// redisGet 从Redis读取缓存
+func redisGet[T any](c *PermissionCache, tableName, key string) (T, bool) {
+	var value T
+	if !c.redisEnabled() {
+		return value, false
+	}
+	data, err := gvaGlobal.GVA_REDIS.HGet(context.Background(), c.redisKey(tableName), key).Bytes()
+	if err != nil {
+		return value, false
+	}
+	if err = json.Unmarshal(data, &value); err != nil {
+		return value, false
+	}
+	return value, true
+}
+
+// redisGeneration 读取表在Redis中的版本号 不存在时创建 供加载后写入时比较
+func (c *PermissionCache) redisGeneration(tableName string) (int64, bool) {
+	if !c.redisEnabled() {
+		return 0, false
+	}
+	generation, err := gvaGlobal.GVA_REDIS.IncrBy(context.Background(), c.generationKey(tableName), 0).Result()
+	if err != nil {
+		gvaGlobal.GVA_LOG.Error("数据权限插件: 读取Redis缓存版本失败", zap.Error(err))
+		return 0, false
+	}
+	return generation, true
+}
+
+// redisSet 写入Redis缓存 加载期间版本号变化时放弃写入
+func (c *PermissionCache) redisSet(tableName, key string, value interface{}, generation int64) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	keys := []string{c.redisKey(tableName), c.generationKey(tableName)}
+	err = redisSetScript.Run(context.Background(), gvaGlobal.GVA_REDIS, keys, key, data, generation, c.ttl().Milliseconds()).Err()
+	if err != nil {
+		gvaGlobal.GVA_LOG.Error("数据权限插件: 写入Redis缓存失败", zap.Error(err))
+	}
+}
+
+// store 写入进程内缓存 加载期间发生过失效时放弃写入
+func (c *PermissionCache) store(generation uint64, tableName, key string, value interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation.Load() != generation {
+		return false
+	}
+	entry := cacheEntry{value: value}
+	if c.config.Expiration > 0 {
+		entry.expireAt = time.Now().Add(time.Duration(c.config.Expiration) * time.Second)
+	}
+	if c.entries[tableName] == nil {
+		c.entries[tableName] = make(map[string]cacheEntry)
+	}
+	c.entries[tableName][key] = entry
+	return true
+}
+
+// invalidateLocal 使进程内缓存失效 tableNames 为空时清除全部
+func (c *PermissionCache) invalidateLocal(tableNames []string) {
+	c.mu.Lock()
+	c.generation.Add(1)
+	if len(tableNames) == 0 {
+		c.entries = make(map[string]map[string]cacheEntry)
+	}
+	for _, tableName := range tableNames {
+		delete(c.entries, tableName)
+	}
+	c.mu.Unlock()
+	c.invalidations.Add(1)
+}
+
+// publish 通知其他节点缓存失效 tableNames 为空表示全部失效
+func (c *PermissionCache) publish(tableNames []string) {
+	if !c.pubSubEnabled() {
+		return
+	}
+	if tableNames == nil {
+		tableNames = []string{}
+	}
+	data, _ := json.Marshal(tableNames)
+	if err := gvaGlobal.GVA_REDIS.Publish(context.Background(), c.channel(), data).Err(); err != nil {
+		gvaGlobal.GVA_LOG.Error("数据权限插件: 发布缓存失效通知失败", zap.Error(err))
+	}
+}
+
+// cfg 读取当前缓存配置
+func (c *PermissionCache) cfg() config.CacheConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.config
+}
+
+func (c *PermissionCache) enabled() bool {
+	return c.cfg().Enabled
+}
+
+func (c *PermissionCache) ttl() time.Duration {
+	return time.Duration(c.cfg().Expiration) * time.Second
+}
+
+// pubSubEnabled 系统启用Redis时通过发布订阅同步失效
+func (c *PermissionCache) pubSubEnabled() bool {
+	return gvaGlobal.GVA_CONFIG.System.UseRedis && gvaGlobal.GVA_REDIS != nil
+}
+
+// redisEnabled 是否使用Redis二级缓存
+func (c *PermissionCache) redisEnabled() bool {
+	return c.cfg().Redis && c.pubSubEnabled()
+}
+
+// redisKey 表的缓存哈希 表名作为hash tag 与版本号位于同一集群槽位
+func (c *PermissionCache) redisKey(tableName string) string {
+	return c.cfg().KeyPrefix + "cache:{" + tableName + "}"
+}
+
+// generationKey 表的缓存版本号 不设置过期时间 避免重置后与加载前读取的版本号相同
+func (c *PermissionCache) generationKey(tableName string) string {
+	return c.cfg().KeyPrefix + "generation:{" + tableName + "}"
+}
+
+func (c *PermissionCache) channel() string {
+	return c.cfg().KeyPrefix + "invalidate"
+}
@@ -2,16 +2,29 @@ package datapermission
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http/httptest"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/config"
+	dpGlobal "github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model"
+	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/service"
 	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/utils"
 )
 
@@ -546,3 +559,237 @@ func TestFullHTTPFlow(t *testing.T) {
 		})
 	}
 }
+
+// 并发测试订单模型
+type RaceOrder struct {
+	ID        uint   `gorm:"primarykey"`
+	CreatedBy uint   `gorm:"column:created_by"`
+	Title     string `gorm:"column:title"`
+}
+
+func (RaceOrder) TableName() string {
+	return "race_orders"
+}
+
+// setupRaceTest 使用sqlite初始化全局数据库 受控表 race_orders 按 created_by 限制为本人数据
+func setupRaceTest(t *testing.T, users int) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// 内存数据库每个连接相互独立
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&system.SysUser{}, &model.ControlledTable{}, &model.RoleDataPermission{}, &model.RoleFieldPermission{}, &RaceOrder{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	global.GVA_DB = db
+	global.GVA_LOG = zap.NewNop()
+
+	table := model.ControlledTable{Table: "race_orders", Enabled: true, DataScope: "self", UserField: "created_by"}
+	if err = db.Create(&table).Error; err != nil {
+		t.Fatalf("create controlled table: %v", err)
+	}
+	if err = db.Create(&model.RoleDataPermission{AuthorityID: 1, ControlledTableID: table.ID, DataScope: "self", Enabled: true}).Error; err != nil {
+		t.Fatalf("create role permission: %v", err)
+	}
+	for i := 1; i <= users; i++ {
+		for j := 0; j < 3; j++ {
+			if err = db.Create(&RaceOrder{CreatedBy: uint(i), Title: fmt.Sprintf("order-%d-%d", i, j)}).Error; err != nil {
+				t.Fatalf("create order: %v", err)
+			}
+		}
+	}
+	// 启动时注册一次回调 重复调用不会再次注册
+	if err = utils.RegisterDataPermissionCallbacks(db); err != nil {
+		t.Fatalf("register callbacks: %v", err)
+	}
+	if err = utils.RegisterDataPermissionCallbacks(db); err != nil {
+		t.Fatalf("register callbacks twice: %v", err)
+	}
+}
+
+// 多用户并发请求时每个请求只能看到自己的数据 使用 go test -race 运行
+func TestDataPermissionInterceptorConcurrentUsers(t *testing.T) {
+	const users = 20
+	const requestsPerUser = 10
+	setupRaceTest(t, users)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// 模拟JWTAuth写入claims
+	router.Use(func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.GetHeader("X-User-Id"))
+		c.Set("claims", &systemReq.CustomClaims{
+			BaseClaims: systemReq.BaseClaims{ID: uint(id), AuthorityId: 1, AuthorityIds: []uint{1}},
+		})
+		c.Next()
+	})
+	router.Use(utils.DataPermissionInterceptorMiddleware())
+	router.GET("/orders", func(c *gin.Context) {
+		var orders []RaceOrder
+		if err := utils.GetInterceptorDB(c).Find(&orders).Error; err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, orders)
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, users*requestsPerUser)
+	for i := 1; i <= users; i++ {
+		for j := 0; j < requestsPerUser; j++ {
+			wg.Add(1)
+			go func(userID int) {
+				defer wg.Done()
+				req := httptest.NewRequest("GET", "/orders", nil)
+				req.Header.Set("X-User-Id", strconv.Itoa(userID))
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != 200 {
+					errs <- fmt.Errorf("user %d: status %d %s", userID, w.Code, w.Body.String())
+					return
+				}
+				var orders []RaceOrder
+				if err := json.Unmarshal(w.Body.Bytes(), &orders); err != nil {
+					errs <- err
+					return
+				}
+				if len(orders) != 3 {
+					errs <- fmt.Errorf("user %d: got %d orders, want 3", userID, len(orders))
+					return
+				}
+				for _, order := range orders {
+					if order.CreatedBy != uint(userID) {
+						errs <- fmt.Errorf("user %d saw order of user %d", userID, order.CreatedBy)
+						return
+					}
+				}
+			}(i)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// 不携带作用域的语句(系统任务等)不受数据权限限制
+	var total int64
+	if err := global.GVA_DB.Model(&RaceOrder{}).Count(&total).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if total != users*3 {
+		t.Errorf("unscoped count = %d, want %d", total, users*3)
+	}
+}
+
+// 多角色合并策略 角色1只能看本人数据 角色2可看全部数据
+func TestResolveConditionStrategies(t *testing.T) {
+	const users = 3
+	setupRaceTest(t, users)
+	db := global.GVA_DB
+	// 角色3的自定义条件为空 不追加条件 即不限制数据范围
+	for _, permission := range []model.RoleDataPermission{
+		{AuthorityID: 2, ControlledTableID: 1, DataScope: "all", Enabled: true},
+		{AuthorityID: 3, ControlledTableID: 1, DataScope: "custom", Enabled: true},
+	} {
+		if err := db.Create(&permission).Error; err != nil {
+			t.Fatalf("create role permission: %v", err)
+		}
+	}
+
+	tests := []struct {
+		strategy     string
+		authorityID  uint
+		authorityIds []uint
+		want         int64
+		parts        int
+	}{
+		{strategy: model.StrategyUnion, authorityID: 1, want: users * 3, parts: 2},
+		{strategy: model.StrategyStrictest, authorityID: 1, want: 3, parts: 2},
+		{strategy: model.StrategyActive, authorityID: 1, want: 3, parts: 1},
+		{strategy: model.StrategyActive, authorityID: 2, want: users * 3, parts: 1},
+		{strategy: model.StrategyUnion, authorityID: 1, authorityIds: []uint{1, 3}, want: users * 3, parts: 2},
+		{strategy: model.StrategyStrictest, authorityID: 1, authorityIds: []uint{1, 3}, want: 3, parts: 2},
+	}
+	for _, tt := range tests {
+		if tt.authorityIds == nil {
+			tt.authorityIds = []uint{1, 2}
+		}
+		t.Run(fmt.Sprintf("%s-%d-%v", tt.strategy, tt.authorityID, tt.authorityIds), func(t *testing.T) {
+			if err := db.Model(&model.ControlledTable{}).Where("table_name = ?", "race_orders").Update("multi_role_strategy", tt.strategy).Error; err != nil {
+				t.Fatalf("update strategy: %v", err)
+			}
+			scope := utils.PermissionScope{UserID: 1, AuthorityID: tt.authorityID, AuthorityIds: tt.authorityIds}
+			effective, err := (&utils.DataPermissionMiddleware{}).ResolveCondition("race_orders", scope)
+			if err != nil {
+				t.Fatalf("ResolveCondition: %v", err)
+			}
+			if effective.Strategy != tt.strategy || len(effective.Parts) != tt.parts {
+				t.Errorf("strategy = %s parts = %d, want %s %d", effective.Strategy, len(effective.Parts), tt.strategy, tt.parts)
+			}
+			query := db.Model(&RaceOrder{})
+			if !effective.Condition.IsEmpty() && !effective.Condition.IsAll() {
+				query = query.Where(effective.Condition.Expr())
+			}
+			var count int64
+			if err = query.Count(&count).Error; err != nil {
+				t.Fatalf("count: %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("count = %d, want %d", count, tt.want)
+			}
+		})
+	}
+}
+
+// 权限配置读取走缓存 通过插件服务修改配置后缓存失效
+func TestPermissionCacheInvalidation(t *testing.T) {
+	setupRaceTest(t, 2)
+	dpGlobal.GVA_DP_CACHE = dpGlobal.NewPermissionCache(config.CacheConfig{Enabled: true, Expiration: 60})
+	defer func() { dpGlobal.GVA_DP_CACHE = nil }()
+
+	middleware := &utils.DataPermissionMiddleware{}
+	scope := utils.PermissionScope{UserID: 1, AuthorityID: 1, AuthorityIds: []uint{1}}
+	resolve := func() utils.EffectiveCondition {
+		t.Helper()
+		effective, err := middleware.ResolveCondition("race_orders", scope)
+		if err != nil {
+			t.Fatalf("ResolveCondition: %v", err)
+		}
+		return effective
+	}
+
+	resolve()
+	misses := dpGlobal.GVA_DP_CACHE.Stats().Misses
+	if misses == 0 {
+		t.Fatal("first lookup should miss")
+	}
+	resolve()
+	stats := dpGlobal.GVA_DP_CACHE.Stats()
+	if stats.Misses != misses || stats.Hits == 0 {
+		t.Errorf("second lookup should hit cache: %+v", stats)
+	}
+
+	// 绕过服务直接修改数据库时缓存仍返回旧配置
+	global.GVA_DB.Model(&model.RoleDataPermission{}).Where("authority_id = ?", 1).Update("data_scope", "all")
+	if resolve().Condition.IsAll() {
+		t.Error("cached config should still be used")
+	}
+
+	// 通过服务保存配置后缓存失效
+	err := service.ServiceGroupApp.DataPermissionService.SaveDataPermissionConfig(request.SaveDataPermissionConfigRequest{
+		AuthorityID: 1, Table: "race_orders", DataScope: "all", UserField: "created_by",
+	})
+	if err != nil {
+		t.Fatalf("SaveDataPermissionConfig: %v", err)
+	}
+	if !resolve().Condition.IsAll() {
+		t.Error("cache should be invalidated after saving config")
+	}
+	if dpGlobal.GVA_DP_CACHE.Stats().Invalidations == 0 {
+		t.Error("invalidations should be counted")
+	}
+}
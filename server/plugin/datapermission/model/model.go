@@ -14,6 +14,8 @@ type ControlledTable struct {
 	DataScope   string `json:"dataScope" form:"dataScope" gorm:"column:data_scope;comment:数据范围;default:'all'"`
 	UserField   string `json:"userField" form:"userField" gorm:"column:user_field;comment:用户字段;"`
 	DeptField   string `json:"deptField" form:"deptField" gorm:"column:dept_field;comment:部门字段;"`
+	// MultiRoleStrategy 用户拥有多个角色时的合并策略 为空时按并集处理
+	MultiRoleStrategy string `json:"multiRoleStrategy" form:"multiRoleStrategy" gorm:"column:multi_role_strategy;comment:多角色合并策略;size:20;default:'union'"`
 }
 
 // TableName ControlledTable 表名
@@ -21,6 +23,32 @@ func (ControlledTable) TableName() string {
 	return "data_permission_controlled_tables"
 }
 
+// 多角色合并策略
+const (
+	StrategyUnion     = "union"     // 并集 任一角色可见的数据均可见
+	StrategyStrictest = "strictest" // 最严 数据需同时满足全部角色的条件
+	StrategyActive    = "active"    // 仅使用当前激活的角色
+)
+
+// Strategy 返回受控表的多角色合并策略
+func (t ControlledTable) Strategy() string {
+	switch t.MultiRoleStrategy {
+	case StrategyStrictest, StrategyActive:
+		return t.MultiRoleStrategy
+	default:
+		return StrategyUnion
+	}
+}
+
+// ValidStrategy 校验多角色合并策略 空值表示默认并集
+func ValidStrategy(strategy string) bool {
+	switch strategy {
+	case "", StrategyUnion, StrategyStrictest, StrategyActive:
+		return true
+	}
+	return false
+}
+
 // RoleDataPermission 角色数据权限配置
 type RoleDataPermission struct {
 	global.GVA_MODEL
@@ -108,5 +136,19 @@ type PermissionTestResponse struct {
 	DataScope        string                         `json:"dataScope"`
 	CustomCondition  string                         `json:"customCondition"`
 	FieldPermissions map[string]FieldPermissionItem `json:"fieldPermissions"`
-	SQLCondition     string                         `json:"sqlCondition"`
+	SQLCondition     string                         `json:"sqlCondition"`  // 代入参数后的SQL 仅用于展示
+	SQLClause        string                         `json:"sqlClause"`     // 实际执行的参数化SQL
+	SQLVars          []interface{}                  `json:"sqlVars"`       // 绑定参数
+	Strategy         string                         `json:"strategy"`      // 多角色合并策略
+	Contributions    []RoleContribution             `json:"contributions"` // 各角色对最终条件的贡献
+}
+
+// RoleContribution 单个角色对最终数据权限条件的贡献
+type RoleContribution struct {
+	AuthorityID     uint   `json:"authorityID"`
+	Configured      bool   `json:"configured"` // 未配置时使用默认的本人数据权限
+	DataScope       string `json:"dataScope"`
+	CustomCondition string `json:"customCondition"`
+	Priority        int    `json:"priority"`
+	SQLCondition    string `json:"sqlCondition"` // 该角色的条件 代入参数后的SQL
 }
@@ -20,25 +20,27 @@ type ControlledTableSearch struct {
 
 // CreateControlledTableRequest 创建受控表请求
 type CreateControlledTableRequest struct {
-	Table            string                      `json:"table" binding:"required"`
-	Description      string                      `json:"description"`
-	Enabled          bool                        `json:"enabled"`
-	DataScope        string                      `json:"dataScope"`
-	UserField        string                      `json:"userField"`
-	DeptField        string                      `json:"deptField"`
-	FieldPermissions []model.FieldPermissionItem `json:"fieldPermissions"`
+	Table             string                      `json:"table" binding:"required"`
+	Description       string                      `json:"description"`
+	Enabled           bool                        `json:"enabled"`
+	DataScope         string                      `json:"dataScope"`
+	UserField         string                      `json:"userField"`
+	DeptField         string                      `json:"deptField"`
+	MultiRoleStrategy string                      `json:"multiRoleStrategy"` // 多角色合并策略 union/strictest/active
+	FieldPermissions  []model.FieldPermissionItem `json:"fieldPermissions"`
 }
 
 // UpdateControlledTableRequest 更新受控表请求
 type UpdateControlledTableRequest struct {
-	ID               uint                        `json:"id" binding:"required"`
-	Table            string                      `json:"table" binding:"required"`
-	Description      string                      `json:"description"`
-	Enabled          bool                        `json:"enabled"`
-	DataScope        string                      `json:"dataScope"`
-	UserField        string                      `json:"userField"`
-	DeptField        string                      `json:"deptField"`
-	FieldPermissions []model.FieldPermissionItem `json:"fieldPermissions"`
+	ID                uint                        `json:"id" binding:"required"`
+	Table             string                      `json:"table" binding:"required"`
+	Description       string                      `json:"description"`
+	Enabled           bool                        `json:"enabled"`
+	DataScope         string                      `json:"dataScope"`
+	UserField         string                      `json:"userField"`
+	DeptField         string                      `json:"deptField"`
+	MultiRoleStrategy string                      `json:"multiRoleStrategy"` // 多角色合并策略 union/strictest/active
+	FieldPermissions  []model.FieldPermissionItem `json:"fieldPermissions"`
 }
 
 // RoleDataPermissionSearch 角色数据权限搜索条件
@@ -133,9 +135,10 @@ type BatchDeleteRequest struct {
 
 // PermissionTestRequest 权限测试请求
 type PermissionTestRequest struct {
-	AuthorityID uint   `json:"authorityId" form:"authorityId"` // 角色ID
-	Table       string `json:"tableName" form:"tableName"`     // 表名
-	UserID      uint   `json:"userId" form:"userId"`           // 用户ID
+	AuthorityID  uint   `json:"authorityId" form:"authorityId"`   // 角色ID
+	Table        string `json:"tableName" form:"tableName"`       // 表名
+	UserID       uint   `json:"userId" form:"userId"`             // 用户ID
+	AuthorityIds []uint `json:"authorityIds" form:"authorityIds"` // 用户拥有的全部角色ID 为空时按用户的角色查询
 }
 
 // PermissionTestResponse 权限测试响应
@@ -5,8 +5,12 @@ import (
 	dpGlobal "github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model"
 	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/router"
+	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/service"
+	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/utils"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	interfaces "github.com/flipped-aurora/gin-vue-admin/server/utils/plugin"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 var _ interfaces.Plugin = (*plugin)(nil)
@@ -43,9 +47,21 @@ func (p *plugin) Uninstall(ctx *gin.Context) error {
 func (p *plugin) autoInit() {
 	// 初始化插件独立配置
 	dpGlobal.InitConfig()
+	// 订阅其他节点的权限缓存失效通知
+	dpGlobal.GVA_DP_CACHE.Subscribe()
 
 	// 自动迁移数据库表
 	p.autoMigrate()
+
+	// 权限模拟结果中附带生效的数据权限
+	systemService.RegisterDataPermissionExplainer(service.ServiceGroupApp.DataPermissionService.ExplainPermissions)
+
+	// 注册数据权限拦截器回调 只在启动时注册一次
+	if global.GVA_DB != nil {
+		if err := utils.RegisterDataPermissionCallbacks(global.GVA_DB); err != nil {
+			global.GVA_LOG.Error("数据权限插件: 注册拦截器失败", zap.Error(err))
+		}
+	}
 }
 
 // autoMigrate 自动迁移数据库表
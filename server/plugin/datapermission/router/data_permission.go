@@ -26,9 +26,12 @@ func (s *DataPermissionRouter) InitDataPermissionRouter(Router *gin.RouterGroup)
 		dataPermissionRouterWithoutRecord.GET("tableList", dataPermissionApi.GetTableList)                     // 获取数据库表列表
 		dataPermissionRouterWithoutRecord.GET("fieldList", dataPermissionApi.GetFieldList)                     // 获取表字段列表
 		dataPermissionRouterWithoutRecord.GET("statistics", dataPermissionApi.GetStatistics)                   // 获取统计信息
+		dataPermissionRouterWithoutRecord.POST("testPermission", dataPermissionApi.TestPermission)             // 测试数据权限
+		dataPermissionRouterWithoutRecord.GET("cacheStats", dataPermissionApi.GetCacheStats)                   // 获取权限缓存统计
 	}
 	{
 		// 配置管理
 		dataPermissionRouter.POST("saveConfig", dataPermissionApi.SaveDataPermissionConfig) // 保存数据权限配置
+		dataPermissionRouter.POST("clearCache", dataPermissionApi.ClearCache)               // 清除权限缓存
 	}
 }
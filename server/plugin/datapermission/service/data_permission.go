@@ -6,9 +6,12 @@ import (
 	"strings"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	dpGlobal "github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model"
 	req "github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model/request"
 	resp "github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model/response"
+	dpUtils "github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/utils"
 	"gorm.io/gorm"
 )
 
@@ -16,6 +19,9 @@ type DataPermissionService struct{}
 
 // CreateControlledTable 创建受控表
 func (s *DataPermissionService) CreateControlledTable(request req.CreateControlledTableRequest) error {
+	if !model.ValidStrategy(request.MultiRoleStrategy) {
+		return errors.New("多角色合并策略无效")
+	}
 	// 检查表名是否已存在
 	var count int64
 	global.GVA_DB.Model(&model.ControlledTable{}).Where("table_name = ?", request.Table).Count(&count)
@@ -25,14 +31,17 @@ func (s *DataPermissionService) CreateControlledTable(request req.CreateControll
 
 	// 创建受控表记录
 	controlledTable := model.ControlledTable{
-		Table:       request.Table,
-		Description: request.Description,
-		Enabled:     request.Enabled,
-		DataScope:   request.DataScope,
-		UserField:   request.UserField,
-		DeptField:   request.DeptField,
+		Table:             request.Table,
+		Description:       request.Description,
+		Enabled:           request.Enabled,
+		DataScope:         request.DataScope,
+		UserField:         request.UserField,
+		DeptField:         request.DeptField,
+		MultiRoleStrategy: request.MultiRoleStrategy,
 	}
 
+	// 表在创建前可能已被缓存为不受控
+	defer dpGlobal.GVA_DP_CACHE.Invalidate(request.Table)
 	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
 		// 创建受控表
 		if err := tx.Create(&controlledTable).Error; err != nil {
@@ -65,6 +74,9 @@ func (s *DataPermissionService) CreateControlledTable(request req.CreateControll
 
 // UpdateControlledTable 更新受控表
 func (s *DataPermissionService) UpdateControlledTable(request req.UpdateControlledTableRequest) error {
+	if !model.ValidStrategy(request.MultiRoleStrategy) {
+		return errors.New("多角色合并策略无效")
+	}
 	// 检查表是否存在
 	var controlledTable model.ControlledTable
 	if err := global.GVA_DB.First(&controlledTable, request.ID).Error; err != nil {
@@ -78,6 +90,9 @@ func (s *DataPermissionService) UpdateControlledTable(request req.UpdateControll
 		return errors.New("表名已被其他记录使用")
 	}
 
+	// 表名可能变更 新旧表名的缓存都需要失效
+	defer dpGlobal.GVA_DP_CACHE.Invalidate(controlledTable.Table, request.Table)
+
 	// 更新受控表
 	controlledTable.Table = request.Table
 	controlledTable.Description = request.Description
@@ -85,12 +100,18 @@ func (s *DataPermissionService) UpdateControlledTable(request req.UpdateControll
 	controlledTable.DataScope = request.DataScope
 	controlledTable.UserField = request.UserField
 	controlledTable.DeptField = request.DeptField
+	controlledTable.MultiRoleStrategy = request.MultiRoleStrategy
 
 	return global.GVA_DB.Save(&controlledTable).Error
 }
 
 // DeleteControlledTable 删除受控表
 func (s *DataPermissionService) DeleteControlledTable(id uint) error {
+	var controlledTable model.ControlledTable
+	if err := global.GVA_DB.First(&controlledTable, id).Error; err != nil {
+		return errors.New("受控表不存在")
+	}
+	defer dpGlobal.GVA_DP_CACHE.Invalidate(controlledTable.Table)
 	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
 		// 删除相关的角色数据权限
 		if err := tx.Where("controlled_table_id = ?", id).Unscoped().Delete(&model.RoleDataPermission{}).Error; err != nil {
@@ -163,6 +184,13 @@ func (s *DataPermissionService) SaveDataPermissionConfig(request req.SaveDataPer
 	if err := global.GVA_DB.Where("table_name = ?", request.Table).First(&controlledTable).Error; err != nil {
 		return errors.New("受控表不存在，请先添加受控表")
 	}
+	// 校验自定义条件
+	if request.DataScope == "custom" || request.CustomSQL != "" {
+		if err := dpUtils.ValidateCustomCondition(request.Table, request.CustomSQL); err != nil {
+			return fmt.Errorf("自定义条件无效: %w", err)
+		}
+	}
+	defer dpGlobal.GVA_DP_CACHE.Invalidate(controlledTable.Table)
 	// 更新受控表的用戶字段和部门字段
 	controlledTable.UserField = request.UserField
 	controlledTable.DeptField = request.DeptField
@@ -359,3 +387,134 @@ func (s *DataPermissionService) GetStatistics() (resp.StatisticsResponse, error)
 
 	return response, nil
 }
+
+// TestPermission 测试指定角色和用户在受控表上的数据权限 返回编译后的条件
+func (s *DataPermissionService) TestPermission(request req.PermissionTestRequest) (resp.PermissionTestResponse, error) {
+	var response resp.PermissionTestResponse
+	response.FieldPermissions = make(map[string]model.FieldPermissionItem)
+
+	var controlledTable model.ControlledTable
+	if err := global.GVA_DB.Where("table_name = ? AND enabled = ?", request.Table, true).First(&controlledTable).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.DataScope = "all"
+			response.SQLCondition = dpUtils.AllowAll.SQL
+			response.SQLClause = dpUtils.AllowAll.SQL
+			response.Message = "该表不受数据权限控制"
+			return response, nil
+		}
+		return response, err
+	}
+
+	var roleDataPermission model.RoleDataPermission
+	err := global.GVA_DB.Where("authority_id = ? AND controlled_table_id = ? AND enabled = ?", request.AuthorityID, controlledTable.ID, true).First(&roleDataPermission).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.DataScope = "self"
+		response.Message = "角色未配置数据权限, 使用默认的本人数据权限"
+	case err != nil:
+		return response, err
+	default:
+		response.DataScope = roleDataPermission.DataScope
+		response.CustomCondition = roleDataPermission.CustomCondition
+		response.Message = "测试成功"
+	}
+
+	// 自定义条件无效时运行期会拒绝访问 这里返回具体原因
+	if response.DataScope == "custom" && response.CustomCondition != "" {
+		if err = dpUtils.ValidateCustomCondition(request.Table, response.CustomCondition); err != nil {
+			response.Message = "自定义条件无效, 将拒绝访问: " + err.Error()
+		}
+	}
+
+	// 按受控表的多角色合并策略计算最终条件 并列出每个角色贡献的部分
+	scope := dpUtils.PermissionScope{UserID: request.UserID, AuthorityID: request.AuthorityID, AuthorityIds: s.testAuthorityIds(request)}
+	effective, err := (&dpUtils.DataPermissionMiddleware{}).ResolveCondition(request.Table, scope)
+	if err != nil {
+		return response, err
+	}
+	response.Strategy = effective.Strategy
+	response.SQLClause, response.SQLVars, response.SQLCondition = dpUtils.ExplainCondition(global.GVA_DB, effective.Condition)
+	for _, part := range effective.Parts {
+		_, _, explained := dpUtils.ExplainCondition(global.GVA_DB, part.Condition)
+		response.Contributions = append(response.Contributions, model.RoleContribution{
+			AuthorityID:     part.AuthorityID,
+			Configured:      part.Configured,
+			DataScope:       part.DataScope,
+			CustomCondition: part.CustomCondition,
+			Priority:        part.Priority,
+			SQLCondition:    explained,
+		})
+	}
+
+	var fieldPermissions []model.RoleFieldPermission
+	if err = global.GVA_DB.Where("authority_id = ? AND controlled_table_id = ? AND enabled = ?", request.AuthorityID, controlledTable.ID, true).Find(&fieldPermissions).Error; err != nil {
+		return response, err
+	}
+	for _, fieldPerm := range fieldPermissions {
+		response.FieldPermissions[fieldPerm.FieldName] = model.FieldPermissionItem{
+			FieldName:    fieldPerm.FieldName,
+			FieldChinese: fieldPerm.FieldChinese,
+			FieldDesc:    fieldPerm.FieldDesc,
+			Visible:      fieldPerm.Visibility,
+			Editable:     fieldPerm.EditPermission,
+			Exportable:   fieldPerm.Exportable,
+			Queryable:    fieldPerm.Queryable,
+		}
+	}
+	return response, nil
+}
+
+// ExplainPermissions 用户以该角色访问时在各受控表上生效的行、字段权限 键为表名 未启用数据权限时返回nil
+func (s *DataPermissionService) ExplainPermissions(userID, authorityID uint, authorityIds []uint) (interface{}, error) {
+	if !dpGlobal.IsEnabled() {
+		return nil, nil
+	}
+	var tables []model.ControlledTable
+	if err := global.GVA_DB.Where("enabled = ?", true).Order("table_name").Find(&tables).Error; err != nil {
+		return nil, err
+	}
+	permissions := make(map[string]resp.PermissionTestResponse, len(tables))
+	for _, table := range tables {
+		permission, err := s.TestPermission(req.PermissionTestRequest{
+			AuthorityID:  authorityID,
+			Table:        table.Table,
+			UserID:       userID,
+			AuthorityIds: authorityIds,
+		})
+		if err != nil {
+			return nil, err
+		}
+		permissions[table.Table] = permission
+	}
+	return permissions, nil
+}
+
+// testAuthorityIds 权限测试使用的角色列表 未指定时使用用户拥有的全部角色 测试角色始终包含在内
+func (s *DataPermissionService) testAuthorityIds(request req.PermissionTestRequest) []uint {
+	authorityIds := request.AuthorityIds
+	if len(authorityIds) == 0 && request.UserID != 0 {
+		var user system.SysUser
+		if err := global.GVA_DB.Preload("Authorities").First(&user, request.UserID).Error; err == nil {
+			for _, authority := range user.Authorities {
+				authorityIds = append(authorityIds, authority.AuthorityId)
+			}
+		}
+	}
+	for _, authorityID := range authorityIds {
+		if authorityID == request.AuthorityID {
+			return authorityIds
+		}
+	}
+	return append([]uint{request.AuthorityID}, authorityIds...)
+}
+
+// GetCacheStats 获取权限缓存统计
+func (s *DataPermissionService) GetCacheStats() dpGlobal.CacheStats {
+	return dpGlobal.GVA_DP_CACHE.Stats()
+}
+
+// ClearCache 清除权限缓存并重置统计 所有节点同步失效
+func (s *DataPermissionService) ClearCache() {
+	dpGlobal.GVA_DP_CACHE.ClearAllCache()
+	dpGlobal.GVA_DP_CACHE.ResetStats()
+}
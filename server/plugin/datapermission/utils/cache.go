@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"errors"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	dpGlobal "github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model"
+	"gorm.io/gorm"
+)
+
+// loadControlledTable 读取启用的受控表 优先使用权限缓存 表不受控时返回 nil
+func loadControlledTable(tableName string) (*model.ControlledTable, error) {
+	return dpGlobal.GVA_DP_CACHE.GetControlledTable(tableName, func() (*model.ControlledTable, error) {
+		var controlledTable model.ControlledTable
+		err := SkipDataPermission(global.GVA_DB).Where("table_name = ? AND enabled = ?", tableName, true).First(&controlledTable).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &controlledTable, nil
+	})
+}
+
+// loadRoleDataPermission 读取角色在受控表上启用的数据权限配置 未配置时返回 nil
+func loadRoleDataPermission(controlledTable *model.ControlledTable, authorityID uint) (*model.RoleDataPermission, error) {
+	return dpGlobal.GVA_DP_CACHE.GetDataPermission(authorityID, controlledTable.Table, func() (*model.RoleDataPermission, error) {
+		var roleDataPermission model.RoleDataPermission
+		err := SkipDataPermission(global.GVA_DB).Where("authority_id = ? AND controlled_table_id = ? AND enabled = ?", authorityID, controlledTable.ID, true).First(&roleDataPermission).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &roleDataPermission, nil
+	})
+}
+
+// loadRoleFieldPermissions 读取角色在受控表上启用的字段权限 key 为字段名 返回的map不可修改
+func loadRoleFieldPermissions(controlledTable *model.ControlledTable, authorityID uint) (map[string]model.RoleFieldPermission, error) {
+	return dpGlobal.GVA_DP_CACHE.GetFieldPermissions(authorityID, controlledTable.Table, func() (map[string]model.RoleFieldPermission, error) {
+		var fieldPermissions []model.RoleFieldPermission
+		if err := SkipDataPermission(global.GVA_DB).Where("authority_id = ? AND controlled_table_id = ? AND enabled = ?", authorityID, controlledTable.ID, true).Find(&fieldPermissions).Error; err != nil {
+			return nil, err
+		}
+		permissionMap := make(map[string]model.RoleFieldPermission, len(fieldPermissions))
+		for _, perm := range fieldPermissions {
+			permissionMap[perm.FieldName] = perm
+		}
+		return permissionMap, nil
+	})
+}
@@ -0,0 +1,548 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Condition 编译后的数据权限条件 列名以 clause.Column 绑定 取值全部作为参数绑定
+type Condition struct {
+	SQL  string
+	Vars []interface{}
+}
+
+var (
+	// NoCondition 不追加任何条件
+	NoCondition = Condition{}
+	// AllowAll 不限制数据范围
+	AllowAll = Condition{SQL: "1=1"}
+	// DenyAll 不可见任何数据
+	DenyAll = Condition{SQL: "1=0"}
+)
+
+// IsEmpty 是否为空条件
+func (c Condition) IsEmpty() bool {
+	return c.SQL == ""
+}
+
+// IsAll 是否为不限制条件
+func (c Condition) IsAll() bool {
+	return c.SQL == AllowAll.SQL
+}
+
+// Expr 转换为GORM表达式 外层加括号避免与业务条件拼接时优先级错乱
+func (c Condition) Expr() clause.Expr {
+	return clause.Expr{SQL: "(" + c.SQL + ")", Vars: c.Vars}
+}
+
+// key 用于条件去重
+func (c Condition) key() string {
+	return c.SQL + fmt.Sprint(c.Vars...)
+}
+
+// ColumnEquals 生成 column = value 条件
+func ColumnEquals(column string, value interface{}) Condition {
+	return Condition{SQL: "? = ?", Vars: []interface{}{clause.Column{Name: column}, value}}
+}
+
+// OrConditions 以OR合并多个条件 任一条件为空或不限制时返回不限制 重复条件只保留一个
+// 空条件表示该部分不追加条件 即可见全部数据 与之OR后同样不限制
+func OrConditions(conditions ...Condition) Condition {
+	unique := make([]Condition, 0, len(conditions))
+	seen := make(map[string]bool, len(conditions))
+	for _, c := range conditions {
+		if c.IsEmpty() || c.IsAll() {
+			return AllowAll
+		}
+		if seen[c.key()] {
+			continue
+		}
+		seen[c.key()] = true
+		unique = append(unique, c)
+	}
+	switch len(unique) {
+	case 0:
+		return NoCondition
+	case 1:
+		return unique[0]
+	}
+	parts := make([]string, 0, len(unique))
+	vars := make([]interface{}, 0)
+	for _, c := range unique {
+		parts = append(parts, "("+c.SQL+")")
+		vars = append(vars, c.Vars...)
+	}
+	return Condition{SQL: strings.Join(parts, " OR "), Vars: vars}
+}
+
+// AndConditions 以AND合并多个条件 任一条件拒绝访问时返回拒绝 不限制的条件不参与合并
+func AndConditions(conditions ...Condition) Condition {
+	unique := make([]Condition, 0, len(conditions))
+	seen := make(map[string]bool, len(conditions))
+	for _, c := range conditions {
+		if c.IsEmpty() || c.IsAll() {
+			continue
+		}
+		if c.SQL == DenyAll.SQL {
+			return DenyAll
+		}
+		if seen[c.key()] {
+			continue
+		}
+		seen[c.key()] = true
+		unique = append(unique, c)
+	}
+	switch len(unique) {
+	case 0:
+		for _, c := range conditions {
+			if c.IsAll() {
+				return AllowAll
+			}
+		}
+		return NoCondition
+	case 1:
+		return unique[0]
+	}
+	parts := make([]string, 0, len(unique))
+	vars := make([]interface{}, 0)
+	for _, c := range unique {
+		parts = append(parts, "("+c.SQL+")")
+		vars = append(vars, c.Vars...)
+	}
+	return Condition{SQL: strings.Join(parts, " AND "), Vars: vars}
+}
+
+// ExplainCondition 按当前数据库方言编译条件 返回带占位符的SQL 绑定参数以及代入参数后的SQL
+func ExplainCondition(db *gorm.DB, c Condition) (sql string, vars []interface{}, explained string) {
+	if c.IsEmpty() {
+		return "", nil, ""
+	}
+	stmt := &gorm.Statement{DB: db, Clauses: map[string]clause.Clause{}}
+	clause.Expr{SQL: c.SQL, Vars: c.Vars}.Build(stmt)
+	sql = stmt.SQL.String()
+	return sql, stmt.Vars, db.Dialector.Explain(sql, stmt.Vars...)
+}
+
+// TableColumns 获取表的全部列名
+func TableColumns(db *gorm.DB, tableName string) (map[string]bool, error) {
+	columnTypes, err := db.Migrator().ColumnTypes(tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(columnTypes) == 0 {
+		return nil, fmt.Errorf("表 %s 不存在或没有字段", tableName)
+	}
+	columns := make(map[string]bool, len(columnTypes))
+	for _, ct := range columnTypes {
+		columns[strings.ToLower(ct.Name())] = true
+	}
+	return columns, nil
+}
+
+// 自定义条件占位符
+const (
+	PlaceholderUserID      = "USER_ID"      // 当前用户ID
+	PlaceholderAuthorityID = "AUTHORITY_ID" // 当前角色ID
+	PlaceholderDeptID      = "DEPT_ID"      // 当前用户部门ID
+	PlaceholderCurrentDate = "CURRENT_DATE" // 当前日期 yyyy-MM-dd
+	PlaceholderNow         = "NOW"          // 当前时间
+)
+
+// ConditionVars 自定义条件占位符取值
+type ConditionVars struct {
+	UserID      uint
+	AuthorityID uint
+	DeptID      uint
+	Now         time.Time
+}
+
+const (
+	maxConditionLength = 2000
+	maxConditionDepth  = 16
+)
+
+// CompileCondition 将自定义条件编译为参数化条件
+// 语法: 谓词之间使用 AND / OR / NOT 与括号组合, 谓词支持
+//
+//	column = | != | <> | > | >= | < | <= value
+//	column [NOT] IN (value, ...)
+//	column [NOT] LIKE value
+//	column [NOT] BETWEEN value AND value
+//	column IS [NOT] NULL
+//
+// value 为数字, 单引号字符串, TRUE/FALSE 或占位符 ${USER_ID} ${AUTHORITY_ID} ${DEPT_ID} ${CURRENT_DATE} ${NOW}
+// columns 不为空时校验条件引用的列必须存在于受控表中
+func CompileCondition(expr string, columns map[string]bool, vars ConditionVars) (Condition, error) {
+	if strings.TrimSpace(expr) == "" {
+		return NoCondition, errors.New("自定义条件不能为空")
+	}
+	if len(expr) > maxConditionLength {
+		return NoCondition, fmt.Errorf("自定义条件长度不能超过%d", maxConditionLength)
+	}
+	tokens, err := lexCondition(expr)
+	if err != nil {
+		return NoCondition, err
+	}
+	if vars.Now.IsZero() {
+		vars.Now = time.Now()
+	}
+	p := &conditionParser{tokens: tokens, columns: columns, vars: vars}
+	sql, err := p.parseOr(0)
+	if err != nil {
+		return NoCondition, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return NoCondition, p.errorf(tok, "多余的内容 %q", tok.text)
+	}
+	return Condition{SQL: sql, Vars: p.out}, nil
+}
+
+// ConditionPlaceholders 返回条件中使用的占位符 用于按需查询占位符取值
+func ConditionPlaceholders(expr string) map[string]bool {
+	used := make(map[string]bool)
+	tokens, err := lexCondition(expr)
+	if err != nil {
+		return used
+	}
+	for _, tok := range tokens {
+		if tok.kind == tokenPlaceholder {
+			used[tok.text] = true
+		}
+	}
+	return used
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenPlaceholder
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type conditionToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lexCondition(expr string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, conditionToken{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, conditionToken{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, conditionToken{kind: tokenComma, text: ",", pos: i})
+			i++
+		case r == '=' || r == '<' || r == '>' || r == '!':
+			start := i
+			i++
+			if i < len(runes) && (runes[i] == '=' || (r == '<' && runes[i] == '>')) {
+				i++
+			}
+			op := string(runes[start:i])
+			if op == "!" {
+				return nil, fmt.Errorf("条件第%d个字符附近存在非法运算符 %q", start+1, op)
+			}
+			tokens = append(tokens, conditionToken{kind: tokenOperator, text: op, pos: start})
+		case r == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\'' {
+					// 两个单引号表示转义
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						sb.WriteRune('\'')
+						i += 2
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("条件第%d个字符开始的字符串未闭合", start+1)
+			}
+			tokens = append(tokens, conditionToken{kind: tokenString, text: sb.String(), pos: start})
+		case r == '$':
+			start := i
+			if i+1 >= len(runes) || runes[i+1] != '{' {
+				return nil, fmt.Errorf("条件第%d个字符附近存在非法占位符", start+1)
+			}
+			end := i + 2
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("条件第%d个字符开始的占位符未闭合", start+1)
+			}
+			name := strings.ToUpper(strings.TrimSpace(string(runes[i+2 : end])))
+			tokens = append(tokens, conditionToken{kind: tokenPlaceholder, text: name, pos: start})
+			i = end + 1
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return nil, fmt.Errorf("条件第%d个字符附近存在非法数字 %q", start+1, text)
+			}
+			tokens = append(tokens, conditionToken{kind: tokenNumber, text: text, pos: start})
+		case r == '_' || (r < unicode.MaxASCII && unicode.IsLetter(r)):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || (runes[i] < unicode.MaxASCII && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])))) {
+				i++
+			}
+			tokens = append(tokens, conditionToken{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		default:
+			return nil, fmt.Errorf("条件第%d个字符 %q 不被支持", i+1, string(r))
+		}
+	}
+	return append(tokens, conditionToken{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type conditionParser struct {
+	tokens  []conditionToken
+	pos     int
+	columns map[string]bool
+	vars    ConditionVars
+	out     []interface{}
+}
+
+func (p *conditionParser) peek() conditionToken {
+	return p.tokens[p.pos]
+}
+
+func (p *conditionParser) next() conditionToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword 下一个标记为指定关键字时消费并返回true
+func (p *conditionParser) keyword(word string) bool {
+	tok := p.peek()
+	if tok.kind == tokenIdent && strings.EqualFold(tok.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *conditionParser) errorf(tok conditionToken, format string, args ...interface{}) error {
+	return fmt.Errorf("条件第%d个字符附近: %s", tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *conditionParser) parseOr(depth int) (string, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return "", err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return "", err
+		}
+		left = left + " OR " + right
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd(depth int) (string, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return "", err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return "", err
+		}
+		left = left + " AND " + right
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseUnary(depth int) (string, error) {
+	if depth > maxConditionDepth {
+		return "", p.errorf(p.peek(), "嵌套层级不能超过%d", maxConditionDepth)
+	}
+	if p.keyword("NOT") {
+		inner, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + inner, nil
+	}
+	if p.peek().kind == tokenLParen {
+		p.next()
+		inner, err := p.parseOr(depth + 1)
+		if err != nil {
+			return "", err
+		}
+		if tok := p.next(); tok.kind != tokenRParen {
+			return "", p.errorf(tok, "缺少右括号")
+		}
+		return "(" + inner + ")", nil
+	}
+	return p.parsePredicate()
+}
+
+var conditionKeywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "IN": true, "IS": true, "NULL": true,
+	"LIKE": true, "BETWEEN": true, "TRUE": true, "FALSE": true,
+}
+
+func (p *conditionParser) parseColumn() error {
+	tok := p.next()
+	if tok.kind != tokenIdent || conditionKeywords[strings.ToUpper(tok.text)] {
+		return p.errorf(tok, "此处应为列名")
+	}
+	if p.columns != nil && !p.columns[strings.ToLower(tok.text)] {
+		return p.errorf(tok, "列 %s 不存在于受控表中", tok.text)
+	}
+	p.out = append(p.out, clause.Column{Name: tok.text})
+	return nil
+}
+
+func (p *conditionParser) parsePredicate() (string, error) {
+	if err := p.parseColumn(); err != nil {
+		return "", err
+	}
+	if p.keyword("IS") {
+		not := p.keyword("NOT")
+		if !p.keyword("NULL") {
+			return "", p.errorf(p.peek(), "IS 后应为 NULL 或 NOT NULL")
+		}
+		if not {
+			return "? IS NOT NULL", nil
+		}
+		return "? IS NULL", nil
+	}
+	prefix := "? "
+	if p.keyword("NOT") {
+		prefix = "? NOT "
+	}
+	switch {
+	case p.keyword("IN"):
+		if tok := p.next(); tok.kind != tokenLParen {
+			return "", p.errorf(tok, "IN 后应为左括号")
+		}
+		placeholders := make([]string, 0)
+		for {
+			if err := p.parseValue(); err != nil {
+				return "", err
+			}
+			placeholders = append(placeholders, "?")
+			tok := p.next()
+			if tok.kind == tokenRParen {
+				break
+			}
+			if tok.kind != tokenComma {
+				return "", p.errorf(tok, "IN 列表中应为逗号或右括号")
+			}
+		}
+		return prefix + "IN (" + strings.Join(placeholders, ",") + ")", nil
+	case p.keyword("LIKE"):
+		if err := p.parseValue(); err != nil {
+			return "", err
+		}
+		return prefix + "LIKE ?", nil
+	case p.keyword("BETWEEN"):
+		if err := p.parseValue(); err != nil {
+			return "", err
+		}
+		if !p.keyword("AND") {
+			return "", p.errorf(p.peek(), "BETWEEN 缺少 AND")
+		}
+		if err := p.parseValue(); err != nil {
+			return "", err
+		}
+		return prefix + "BETWEEN ? AND ?", nil
+	}
+	if prefix != "? " {
+		return "", p.errorf(p.peek(), "NOT 后应为 IN, LIKE 或 BETWEEN")
+	}
+	tok := p.next()
+	if tok.kind != tokenOperator {
+		return "", p.errorf(tok, "此处应为比较运算符")
+	}
+	if err := p.parseValue(); err != nil {
+		return "", err
+	}
+	return "? " + tok.text + " ?", nil
+}
+
+func (p *conditionParser) parseValue() error {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		if i, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			p.out = append(p.out, i)
+			return nil
+		}
+		f, _ := strconv.ParseFloat(tok.text, 64)
+		p.out = append(p.out, f)
+		return nil
+	case tokenString:
+		p.out = append(p.out, tok.text)
+		return nil
+	case tokenPlaceholder:
+		switch tok.text {
+		case PlaceholderUserID:
+			p.out = append(p.out, p.vars.UserID)
+		case PlaceholderAuthorityID:
+			p.out = append(p.out, p.vars.AuthorityID)
+		case PlaceholderDeptID:
+			p.out = append(p.out, p.vars.DeptID)
+		case PlaceholderCurrentDate:
+			p.out = append(p.out, p.vars.Now.Format(time.DateOnly))
+		case PlaceholderNow:
+			p.out = append(p.out, p.vars.Now)
+		default:
+			return p.errorf(tok, "不支持的占位符 ${%s}", tok.text)
+		}
+		return nil
+	case tokenIdent:
+		switch strings.ToUpper(tok.text) {
+		case "TRUE":
+			p.out = append(p.out, true)
+			return nil
+		case "FALSE":
+			p.out = append(p.out, false)
+			return nil
+		}
+	}
+	return p.errorf(tok, "此处应为数字, 字符串或占位符")
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type conditionTestOrder struct {
+	ID        uint
+	CreatedBy uint
+	DeptID    uint
+	Status    string
+	Amount    float64
+	OrderDate string
+}
+
+func setupConditionTest(t *testing.T) (*gorm.DB, map[string]bool) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err = db.AutoMigrate(&conditionTestOrder{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	orders := []conditionTestOrder{
+		{CreatedBy: 1, DeptID: 10, Status: "open", Amount: 10, OrderDate: "2026-01-01"},
+		{CreatedBy: 2, DeptID: 10, Status: "closed", Amount: 20, OrderDate: "2026-01-02"},
+		{CreatedBy: 3, DeptID: 20, Status: "open", Amount: 30, OrderDate: "2026-01-03"},
+	}
+	if err = db.Create(&orders).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	columns, err := TableColumns(db, "condition_test_orders")
+	if err != nil {
+		t.Fatalf("TableColumns: %v", err)
+	}
+	return db, columns
+}
+
+func TestCompileCondition(t *testing.T) {
+	db, columns := setupConditionTest(t)
+	vars := ConditionVars{UserID: 1, AuthorityID: 888, DeptID: 20, Now: time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name string
+		expr string
+		want int64
+	}{
+		{name: "placeholder", expr: "created_by = ${USER_ID}", want: 1},
+		{name: "or with dept", expr: "created_by = ${user_id} OR dept_id = ${DEPT_ID}", want: 2},
+		{name: "in and not", expr: "dept_id IN (10, 30) AND NOT status = 'closed'", want: 1},
+		{name: "date", expr: "order_date <= ${CURRENT_DATE}", want: 2},
+		{name: "between", expr: "amount BETWEEN 15 AND 35", want: 2},
+		{name: "like", expr: "status NOT LIKE 'clo%'", want: 2},
+		{name: "null", expr: "(status IS NOT NULL)", want: 3},
+		{name: "quoted string stays a value", expr: "status = 'open'' OR ''1''=''1'", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, err := CompileCondition(tt.expr, columns, vars)
+			if err != nil {
+				t.Fatalf("CompileCondition() error = %v", err)
+			}
+			var count int64
+			if err = db.Model(&conditionTestOrder{}).Where(condition.Expr()).Count(&count).Error; err != nil {
+				t.Fatalf("query error = %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("count = %d, want %d", count, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileCondition_Reject(t *testing.T) {
+	_, columns := setupConditionTest(t)
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "unknown column", expr: "password = '1'"},
+		{name: "subquery", expr: "created_by IN (SELECT id FROM sys_users)"},
+		{name: "statement", expr: "created_by = 1; DROP TABLE sys_users"},
+		{name: "comment", expr: "created_by = 1 -- x"},
+		{name: "function", expr: "created_by = version()"},
+		{name: "column compare", expr: "created_by = dept_id"},
+		{name: "unknown placeholder", expr: "created_by = ${PASSWORD}"},
+		{name: "unclosed", expr: "(created_by = 1"},
+		{name: "empty", expr: "  "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CompileCondition(tt.expr, columns, ConditionVars{}); err == nil {
+				t.Errorf("CompileCondition(%q) should fail", tt.expr)
+			}
+		})
+	}
+}
+
+func TestExplainCondition(t *testing.T) {
+	db, _ := setupConditionTest(t)
+	condition := OrConditions(ColumnEquals("created_by", uint(1)), buildInCondition("dept_id", []uint{10, 20}), ColumnEquals("created_by", uint(1)))
+	sql, vars, explained := ExplainCondition(db, condition)
+	if sql != "(`created_by` = ?) OR (`dept_id` IN (?,?))" {
+		t.Errorf("sql = %s", sql)
+	}
+	if len(vars) != 3 {
+		t.Errorf("vars = %v", vars)
+	}
+	if explained != "(`created_by` = 1) OR (`dept_id` IN (10,20))" {
+		t.Errorf("explained = %s", explained)
+	}
+	if got := OrConditions(condition, AllowAll); !got.IsAll() {
+		t.Errorf("OrConditions with AllowAll = %v", got)
+	}
+}
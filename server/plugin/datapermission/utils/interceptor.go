@@ -1,28 +1,94 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"unicode"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	system "github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model"
+	gvaUtils "github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 // DataPermissionInterceptor 数据权限拦截器
-type DataPermissionInterceptor struct {
-	ginContext *gin.Context
-}
+// 回调只在启动时注册一次 每条语句从 Statement.Context 中读取当前请求的数据权限作用域
+type DataPermissionInterceptor struct{}
 
 // NewDataPermissionInterceptor 创建数据权限拦截器
-func NewDataPermissionInterceptor(c *gin.Context) *DataPermissionInterceptor {
-	return &DataPermissionInterceptor{
-		ginContext: c,
+func NewDataPermissionInterceptor() *DataPermissionInterceptor {
+	return &DataPermissionInterceptor{}
+}
+
+// PermissionScope 数据权限作用域 由 DataPermissionInterceptorMiddleware 写入请求的 context.Context
+type PermissionScope struct {
+	UserID       uint   // 用户ID
+	AuthorityID  uint   // 当前角色ID
+	AuthorityIds []uint // 用户全部角色ID
+}
+
+type permissionScopeKey struct{}
+
+// WithPermissionScope 将数据权限作用域写入context
+func WithPermissionScope(ctx context.Context, scope PermissionScope) context.Context {
+	return context.WithValue(ctx, permissionScopeKey{}, scope)
+}
+
+// PermissionScopeFromContext 从context读取数据权限作用域
+func PermissionScopeFromContext(ctx context.Context) (PermissionScope, bool) {
+	if ctx == nil {
+		return PermissionScope{}, false
+	}
+	scope, ok := ctx.Value(permissionScopeKey{}).(PermissionScope)
+	return scope, ok
+}
+
+// PermissionScopeFromGin 根据gin上下文中的登录信息构建数据权限作用域
+func PermissionScopeFromGin(c *gin.Context) (PermissionScope, bool) {
+	claims := gvaUtils.GetUserInfo(c)
+	if claims == nil || claims.BaseClaims.ID == 0 {
+		return PermissionScope{}, false
 	}
+	scope := PermissionScope{
+		UserID:       claims.BaseClaims.ID,
+		AuthorityID:  claims.AuthorityId,
+		AuthorityIds: claims.AuthorityIds,
+	}
+	if len(scope.AuthorityIds) == 0 && scope.AuthorityID != 0 {
+		scope.AuthorityIds = []uint{scope.AuthorityID}
+	}
+	return scope, true
+}
+
+var (
+	registerMu sync.Mutex
+	registered sync.Map // *gorm.Config -> struct{}
+)
+
+// RegisterDataPermissionCallbacks 为数据库实例注册数据权限回调 同一实例只注册一次
+func RegisterDataPermissionCallbacks(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	if _, ok := registered.Load(db.Config); ok {
+		return nil
+	}
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	if _, ok := registered.Load(db.Config); ok {
+		return nil
+	}
+	if err := NewDataPermissionInterceptor().Initialize(db); err != nil {
+		return err
+	}
+	registered.Store(db.Config, struct{}{})
+	return nil
 }
 
 // Name 插件名称
@@ -30,8 +96,12 @@ func (interceptor *DataPermissionInterceptor) Name() string {
 	return "data_permission_interceptor"
 }
 
-// Initialize 初始化插件
+// Initialize 初始化插件 回调已存在时不重复注册
 func (interceptor *DataPermissionInterceptor) Initialize(db *gorm.DB) error {
+	if db.Callback().Query().Get("data_permission:before_query") != nil {
+		return nil
+	}
+
 	// 注册查询回调
 	err := db.Callback().Query().Before("gorm:query").Register("data_permission:before_query", interceptor.beforeQuery)
 	if err != nil {
@@ -68,7 +138,8 @@ func (interceptor *DataPermissionInterceptor) Initialize(db *gorm.DB) error {
 // beforeQuery 查询前拦截
 func (interceptor *DataPermissionInterceptor) beforeQuery(db *gorm.DB) {
 	// 检查是否需要跳过数据权限
-	if interceptor.shouldSkipDataPermission(db) {
+	scope, ok := interceptor.permissionScope(db)
+	if !ok {
 		return
 	}
 
@@ -79,35 +150,36 @@ func (interceptor *DataPermissionInterceptor) beforeQuery(db *gorm.DB) {
 	}
 
 	// 应用数据权限条件
-	condition, err := interceptor.getDataPermissionCondition(tableName)
+	condition, err := interceptor.getDataPermissionCondition(scope, tableName)
 	if err != nil {
 		// 记录错误但不中断查询
 		global.GVA_LOG.Error(fmt.Sprintf("获取数据权限条件失败: %v", err))
 		return
 	}
 
-	if condition == "1=1" {
+	if condition.IsAll() {
 		return
 	}
 
 	// 应用权限条件
-	if condition != "" {
+	if !condition.IsEmpty() {
 		// 检查是否已经应用了数据权限
 		if applied, exists := db.Get("data_permission_applied"); exists && applied.(bool) {
 			return
 		}
 		// 标记已应用数据权限
 		db = db.Set("data_permission_applied", true)
-		db.Where(condition)
+		db.Where(condition.Expr())
 	}
 	// 应用字段权限过滤
-	interceptor.applyFieldPermissionFilter(db, tableName)
+	interceptor.applyFieldPermissionFilter(db, scope, tableName)
 }
 
 // beforeCreate 创建前拦截
 func (interceptor *DataPermissionInterceptor) beforeCreate(db *gorm.DB) {
 	// 检查是否需要跳过数据权限
-	if interceptor.shouldSkipDataPermission(db) {
+	scope, ok := interceptor.permissionScope(db)
+	if !ok {
 		return
 	}
 
@@ -118,19 +190,20 @@ func (interceptor *DataPermissionInterceptor) beforeCreate(db *gorm.DB) {
 	}
 
 	// 检查创建权限
-	if !interceptor.checkCreatePermission(tableName) {
+	if !interceptor.checkCreatePermission(scope, tableName) {
 		db.AddError(errors.New("没有创建权限"))
 		return
 	}
 
 	// 自动填充用户相关字段
-	interceptor.autoFillUserFields(db, tableName)
+	interceptor.autoFillUserFields(db, scope, tableName)
 }
 
 // beforeUpdate 更新前拦截
 func (interceptor *DataPermissionInterceptor) beforeUpdate(db *gorm.DB) {
 	// 检查是否需要跳过数据权限
-	if interceptor.shouldSkipDataPermission(db) {
+	scope, ok := interceptor.permissionScope(db)
+	if !ok {
 		return
 	}
 
@@ -141,32 +214,33 @@ func (interceptor *DataPermissionInterceptor) beforeUpdate(db *gorm.DB) {
 	}
 
 	// 应用数据权限条件（确保只能更新有权限的数据）
-	condition, err := interceptor.getDataPermissionCondition(tableName)
+	condition, err := interceptor.getDataPermissionCondition(scope, tableName)
 	if err != nil {
 		db.AddError(fmt.Errorf("获取数据权限条件失败: %v", err))
 		return
 	}
 
 	// 应用权限条件
-	if condition != "" && condition != "1=1" {
+	if !condition.IsEmpty() && !condition.IsAll() {
 		// 检查是否已经应用了数据权限
 		if applied, exists := db.Get("data_permission_applied"); exists && applied.(bool) {
 			goto checkFieldPermission
 		}
 		// 标记已应用数据权限
 		db = db.Set("data_permission_applied", true)
-		db.Where(condition)
+		db.Where(condition.Expr())
 	}
 
 checkFieldPermission:
 	// 检查字段编辑权限
-	interceptor.checkFieldEditPermission(db, tableName)
+	interceptor.checkFieldEditPermission(db, scope, tableName)
 }
 
 // beforeDelete 删除前拦截
 func (interceptor *DataPermissionInterceptor) beforeDelete(db *gorm.DB) {
 	// 检查是否需要跳过数据权限
-	if interceptor.shouldSkipDataPermission(db) {
+	scope, ok := interceptor.permissionScope(db)
+	if !ok {
 		return
 	}
 
@@ -177,28 +251,28 @@ func (interceptor *DataPermissionInterceptor) beforeDelete(db *gorm.DB) {
 	}
 
 	// 应用数据权限条件（确保只能删除有权限的数据）
-	condition, err := interceptor.getDataPermissionCondition(tableName)
+	condition, err := interceptor.getDataPermissionCondition(scope, tableName)
 	if err != nil {
 		db.AddError(fmt.Errorf("获取数据权限条件失败: %v", err))
 		return
 	}
 
 	// 应用权限条件
-	if condition != "" && condition != "1=1" {
+	if !condition.IsEmpty() && !condition.IsAll() {
 		// 检查是否已经应用了数据权限
 		if applied, exists := db.Get("data_permission_applied"); exists && applied.(bool) {
 			return
 		}
 		// 标记已应用数据权限
 		db = db.Set("data_permission_applied", true)
-		db.Where(condition)
+		db.Where(condition.Expr())
 	}
 }
 
 // afterQuery 查询后拦截，用于字段过滤
 func (interceptor *DataPermissionInterceptor) afterQuery(db *gorm.DB) {
 	// 检查是否需要跳过数据权限
-	if interceptor.shouldSkipDataPermission(db) {
+	if _, ok := interceptor.permissionScope(db); !ok {
 		return
 	}
 
@@ -217,10 +291,18 @@ func (interceptor *DataPermissionInterceptor) afterQuery(db *gorm.DB) {
 	interceptor.filterResultFields(db, hiddenFields)
 }
 
+// permissionScope 获取语句的数据权限作用域 语句未携带作用域或显式跳过时返回false
+func (interceptor *DataPermissionInterceptor) permissionScope(db *gorm.DB) (PermissionScope, bool) {
+	if interceptor.shouldSkipDataPermission(db) {
+		return PermissionScope{}, false
+	}
+	return PermissionScopeFromContext(db.Statement.Context)
+}
+
 // shouldSkipDataPermission 检查是否应该跳过数据权限
 func (interceptor *DataPermissionInterceptor) shouldSkipDataPermission(db *gorm.DB) bool {
-	// 如果没有Gin上下文，跳过权限检查
-	if interceptor.ginContext == nil {
+	// 非请求内的语句(启动任务, 定时任务等)没有作用域 跳过权限检查
+	if db.Statement == nil {
 		return true
 	}
 
@@ -268,72 +350,52 @@ func (interceptor *DataPermissionInterceptor) getTableName(db *gorm.DB) string {
 }
 
 // getDataPermissionCondition 获取数据权限条件
-func (interceptor *DataPermissionInterceptor) getDataPermissionCondition(tableName string) (string, error) {
-	// 从上下文获取用户信息
-	userID, exists := interceptor.ginContext.Get("userID")
-	if !exists {
-		return "1=1", nil
-	}
-	middleware := &DataPermissionMiddleware{}
-	allConditions := make([]string, 0)
-	if authorityIds, exists := interceptor.ginContext.Get("authorityIds"); exists {
-		if roleIds, ok := authorityIds.([]uint); ok {
-			for _, authorityID := range roleIds {
-				condition, err := middleware.getDataPermissionCondition(tableName, authorityID, userID.(uint))
-				if err != nil {
-					return "", err
-				}
-				// allConditions 中已经包含 condition则不执行下面的逻辑
-				if strings.Contains(strings.Join(allConditions, " OR "), condition) {
-					continue
-				}
-				allConditions = append(allConditions, condition)
-			}
-		}
-	}
-	if len(allConditions) > 0 {
-		finalCondition := strings.Join(allConditions, " OR ")
-		return finalCondition, nil
+func (interceptor *DataPermissionInterceptor) getDataPermissionCondition(scope PermissionScope, tableName string) (Condition, error) {
+	effective, err := (&DataPermissionMiddleware{}).ResolveCondition(tableName, scope)
+	if err != nil {
+		return NoCondition, err
 	}
-	return "", nil
+	return effective.Condition, nil
 }
 
 // checkCreatePermission 检查创建权限
-func (interceptor *DataPermissionInterceptor) checkCreatePermission(tableName string) bool {
+func (interceptor *DataPermissionInterceptor) checkCreatePermission(scope PermissionScope, tableName string) bool {
 	// 检查表是否受控制
-	var controlledTable model.ControlledTable
-	if err := global.GVA_DB.Where("table_name = ? AND enabled = ?", tableName, true).First(&controlledTable).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return true // 如果表不受控制，默认允许
-		}
+	controlledTable, err := loadControlledTable(tableName)
+	if err != nil {
 		return false
 	}
+	if controlledTable == nil {
+		return true // 如果表不受控制，默认允许
+	}
 
-	// 获取用户权限信息
-	authorityID, exists := interceptor.ginContext.Get("authorityId")
-	if !exists {
+	// 没有角色信息时默认允许
+	if len(scope.AuthorityIds) == 0 {
 		return true
 	}
 
-	// 检查角色是否有权限配置
-	var count int64
-	global.GVA_DB.Model(&model.RoleDataPermission{}).Where("authority_id = ? AND controlled_table_id = ? AND enabled = ?", authorityID, controlledTable.ID, true).Count(&count)
-	return count > 0
+	// 检查任一角色是否有权限配置
+	for _, authorityID := range scope.AuthorityIds {
+		roleDataPermission, err := loadRoleDataPermission(controlledTable, authorityID)
+		if err != nil {
+			return false
+		}
+		if roleDataPermission != nil {
+			return true
+		}
+	}
+	return false
 }
 
 // autoFillUserFields 自动填充用户相关字段
-func (interceptor *DataPermissionInterceptor) autoFillUserFields(db *gorm.DB, tableName string) {
+func (interceptor *DataPermissionInterceptor) autoFillUserFields(db *gorm.DB, scope PermissionScope, tableName string) {
 	// 获取受控表配置
-	var controlledTable model.ControlledTable
-	if err := global.GVA_DB.Where("table_name = ? AND enabled = ?", tableName, true).First(&controlledTable).Error; err != nil {
+	controlledTable, err := loadControlledTable(tableName)
+	if err != nil || controlledTable == nil {
 		return
 	}
 
-	// 获取用户ID
-	userID, exists := interceptor.ginContext.Get("userID")
-	if !exists {
-		return
-	}
+	userID := scope.UserID
 
 	// 自动填充用户字段
 	if controlledTable.UserField != "" {
@@ -344,7 +406,7 @@ func (interceptor *DataPermissionInterceptor) autoFillUserFields(db *gorm.DB, ta
 	if controlledTable.DeptField != "" {
 		// 获取用户部门ID
 		var userDeptID uint
-		global.GVA_DB.Raw("SELECT dept_id FROM sys_users WHERE id = ?", userID).Scan(&userDeptID)
+		SkipDataPermission(global.GVA_DB).Model(&system.SysUser{}).Select("dept_id").Where("id = ?", userID).Scan(&userDeptID)
 		if userDeptID > 0 {
 			db.Set(controlledTable.DeptField, userDeptID)
 		}
@@ -354,36 +416,24 @@ func (interceptor *DataPermissionInterceptor) autoFillUserFields(db *gorm.DB, ta
 // getFieldPermissions 获取字段权限配置（在interceptor中的实现）
 func (interceptor *DataPermissionInterceptor) getFieldPermissions(tableName string, authorityID uint) (map[string]model.RoleFieldPermission, error) {
 	// 获取受控表信息
-	var controlledTable model.ControlledTable
-	if err := SkipDataPermission(global.GVA_DB).Where("table_name = ? AND enabled = ?", tableName, true).First(&controlledTable).Error; err != nil {
+	controlledTable, err := loadControlledTable(tableName)
+	if err != nil || controlledTable == nil {
 		return nil, err
 	}
 
 	// 获取字段权限配置
-	var fieldPermissions []model.RoleFieldPermission
-	if err := SkipDataPermission(global.GVA_DB).Where("authority_id = ? AND controlled_table_id = ? AND enabled = ?", authorityID, controlledTable.ID, true).Find(&fieldPermissions).Error; err != nil {
-		return nil, err
-	}
-
-	// 转换为map
-	permissionMap := make(map[string]model.RoleFieldPermission)
-	for _, perm := range fieldPermissions {
-		permissionMap[perm.FieldName] = perm
-	}
-
-	return permissionMap, nil
+	return loadRoleFieldPermissions(controlledTable, authorityID)
 }
 
 // checkFieldEditPermission 检查字段编辑权限
-func (interceptor *DataPermissionInterceptor) checkFieldEditPermission(db *gorm.DB, tableName string) {
+func (interceptor *DataPermissionInterceptor) checkFieldEditPermission(db *gorm.DB, scope PermissionScope, tableName string) {
 	// 获取用户权限信息
-	authorityID, exists := interceptor.ginContext.Get("authorityId")
-	if !exists {
+	if scope.AuthorityID == 0 {
 		return
 	}
 
 	// 获取字段权限配置
-	fieldPermissions, err := interceptor.getFieldPermissions(tableName, authorityID.(uint))
+	fieldPermissions, err := interceptor.getFieldPermissions(tableName, scope.AuthorityID)
 	if err != nil || len(fieldPermissions) == 0 {
 		return
 	}
@@ -397,26 +447,36 @@ func (interceptor *DataPermissionInterceptor) checkFieldEditPermission(db *gorm.
 }
 
 // applyFieldPermissionFilter 应用字段权限过滤
-func (interceptor *DataPermissionInterceptor) applyFieldPermissionFilter(db *gorm.DB, tableName string) {
+func (interceptor *DataPermissionInterceptor) applyFieldPermissionFilter(db *gorm.DB, scope PermissionScope, tableName string) {
+	controlledTable, err := loadControlledTable(tableName)
+	if err != nil || controlledTable == nil {
+		return
+	}
+	strategy := controlledTable.Strategy()
+	authorityIds := scope.AuthorityIds
+	if strategy == model.StrategyActive && scope.AuthorityID != 0 {
+		authorityIds = []uint{scope.AuthorityID}
+	}
+
 	// 获取用户权限信息
 	allFieldPermissions := make(map[string]model.RoleFieldPermission)
-	if authorityIds, exists := interceptor.ginContext.Get("authorityIds"); exists {
-		if roleIds, ok := authorityIds.([]uint); ok {
-			for _, roleId := range roleIds {
-				fieldPermissions, err := interceptor.getFieldPermissions(tableName, roleId)
-				if err != nil || len(fieldPermissions) == 0 {
-					continue
-				}
-				// 合并字段权限，采用最宽松的权限策略
-				for fieldName, permission := range fieldPermissions {
-					if existingPerm, exists := allFieldPermissions[fieldName]; exists {
-						// 合并权限：任一角色有权限则允许
-						mergedPerm := interceptor.mergeFieldPermissions(existingPerm, permission)
-						allFieldPermissions[fieldName] = mergedPerm
-					} else {
-						allFieldPermissions[fieldName] = permission
-					}
-				}
+	for _, roleId := range authorityIds {
+		fieldPermissions, err := interceptor.getFieldPermissions(tableName, roleId)
+		if err != nil || len(fieldPermissions) == 0 {
+			continue
+		}
+		// 按受控表的多角色合并策略合并字段权限
+		for fieldName, permission := range fieldPermissions {
+			existingPerm, exists := allFieldPermissions[fieldName]
+			switch {
+			case !exists:
+				allFieldPermissions[fieldName] = permission
+			case strategy == model.StrategyStrictest:
+				// 任一角色无权限则禁止
+				allFieldPermissions[fieldName] = interceptor.mergeStrictestFieldPermissions(existingPerm, permission)
+			default:
+				// 任一角色有权限则允许
+				allFieldPermissions[fieldName] = interceptor.mergeFieldPermissions(existingPerm, permission)
 			}
 		}
 	}
@@ -427,6 +487,28 @@ func (interceptor *DataPermissionInterceptor) applyFieldPermissionFilter(db *gor
 	interceptor.applyFieldQueryFilter(db, allFieldPermissions)
 }
 
+// mergeStrictestFieldPermissions 合并字段权限（采用最严格策略） 未设置的权限视为允许
+func (interceptor *DataPermissionInterceptor) mergeStrictestFieldPermissions(perm1, perm2 model.RoleFieldPermission) model.RoleFieldPermission {
+	merged := perm1
+	merged.Visibility = strictestFlag(perm1.Visibility, perm2.Visibility)
+	merged.EditPermission = strictestFlag(perm1.EditPermission, perm2.EditPermission)
+	merged.Exportable = strictestFlag(perm1.Exportable, perm2.Exportable)
+	merged.Queryable = strictestFlag(perm1.Queryable, perm2.Queryable)
+	return merged
+}
+
+// strictestFlag 两个权限都允许时才允许
+func strictestFlag(flag1, flag2 *bool) *bool {
+	if flag1 == nil {
+		return flag2
+	}
+	if flag2 == nil {
+		return flag1
+	}
+	allowed := *flag1 && *flag2
+	return &allowed
+}
+
 // mergeFieldPermissions 合并字段权限（采用最宽松策略）
 func (interceptor *DataPermissionInterceptor) mergeFieldPermissions(perm1, perm2 model.RoleFieldPermission) model.RoleFieldPermission {
 	merged := perm1
@@ -744,24 +826,22 @@ func (interceptor *DataPermissionInterceptor) removeFieldFromUpdate(db *gorm.DB,
 }
 
 // DataPermissionInterceptorMiddleware 数据权限拦截器中间件
-// 这个中间件会为每个请求创建一个数据权限拦截器，并注册到GORM中
+// 将当前用户的数据权限作用域写入请求的context 拦截器回调在启动时已注册 这里不再修改全局回调
 func DataPermissionInterceptorMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-
-		// 创建数据权限拦截器
-		interceptor := NewDataPermissionInterceptor(c)
-
-		// 为当前请求创建一个带有拦截器的数据库实例
-		db := global.GVA_DB.Session(&gorm.Session{})
-
-		// 注册拦截器
-		if err := interceptor.Initialize(db); err != nil {
+		// 初始化数据库后 global.GVA_DB 会被替换 确保新实例也注册了回调
+		if err := RegisterDataPermissionCallbacks(global.GVA_DB); err != nil {
 			global.GVA_LOG.Error(fmt.Sprintf("初始化数据权限拦截器失败: %v", err))
 		}
 
-		// 将带有拦截器的数据库实例存储到上下文中
-		c.Set("interceptor_db", db)
-		c.Set("data_permission_interceptor", interceptor)
+		if scope, ok := PermissionScopeFromGin(c); ok {
+			c.Request = c.Request.WithContext(WithPermissionScope(c.Request.Context(), scope))
+		}
+
+		// 将携带作用域的数据库实例存储到上下文中
+		if global.GVA_DB != nil {
+			c.Set("interceptor_db", global.GVA_DB.WithContext(c.Request.Context()))
+		}
 
 		c.Next()
 	}
@@ -772,7 +852,7 @@ func GetInterceptorDB(c *gin.Context) *gorm.DB {
 	if db, exists := c.Get("interceptor_db"); exists {
 		return db.(*gorm.DB)
 	}
-	return global.GVA_DB
+	return global.GVA_DB.WithContext(c.Request.Context())
 }
 
 // SkipDataPermission 跳过数据权限检查
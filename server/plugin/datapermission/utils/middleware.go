@@ -1,16 +1,16 @@
 package utils
 
 import (
-	"errors"
-	"fmt"
 	"strings"
 
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	system "github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/plugin/datapermission/model"
+	systemService "github.com/flipped-aurora/gin-vue-admin/server/service/system"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // DataPermissionMiddleware 数据权限中间件
@@ -36,37 +36,16 @@ func (m *DataPermissionMiddleware) ApplyDataPermission(db *gorm.DB, c *gin.Conte
 		return db
 	}
 
-	// 获取所有角色的数据权限配置
-	allConditions := make([]string, 0)
-	for _, authorityId := range authorityIds {
-		condition, err := m.getDataPermissionCondition(tableName, authorityId, userID.(uint))
-		if err != nil {
-			global.GVA_LOG.Error("获取数据权限配置失败", zap.Error(err))
-			continue
-		}
-		if condition == "1=1" {
-			allConditions = []string{"1=1"}
-			break
-		}
-		if condition != "" {
-			allConditions = append(allConditions, condition)
-		}
+	// 主角色排在首位 即当前激活的角色
+	effective, err := m.ResolveCondition(tableName, PermissionScope{UserID: userID.(uint), AuthorityID: authorityIds[0], AuthorityIds: authorityIds})
+	if err != nil {
+		global.GVA_LOG.Error("获取数据权限配置失败", zap.Error(err))
+		return db
 	}
-
-	// 如果有多个条件，使用OR连接
-	if len(allConditions) > 0 {
-		finalCondition := strings.Join(allConditions, " OR ")
-		// 检查是否已经应用了相同的条件，避免重复
-		if db.Statement != nil && db.Statement.SQL.String() != "" {
-			// 如果SQL已经包含了相同的条件，跳过
-			if strings.Contains(db.Statement.SQL.String(), finalCondition) {
-				return db
-			}
-		}
-		db = db.Where("(" + finalCondition + ")")
+	if effective.Condition.IsEmpty() || effective.Condition.IsAll() {
+		return db
 	}
-
-	return db
+	return db.Where(effective.Condition.Expr())
 }
 
 // ApplyFieldPermission 应用字段权限到查询结果
@@ -141,87 +120,209 @@ func (m *DataPermissionMiddleware) getUserAuthorityIds(userID uint) ([]uint, err
 	return authorityIds, nil
 }
 
-// getDataPermissionCondition 获取数据权限条件
-func (m *DataPermissionMiddleware) getDataPermissionCondition(tableName string, authorityID uint, userID uint) (string, error) {
+// getDataPermissionCondition 获取单个角色的数据权限条件
+func (m *DataPermissionMiddleware) getDataPermissionCondition(tableName string, authorityID uint, userID uint) (Condition, error) {
 	// 获取受控表信息
-	var controlledTable model.ControlledTable
-	if err := SkipDataPermission(global.GVA_DB).Where("table_name = ? AND enabled = ?", tableName, true).First(&controlledTable).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// 如果表不受控制，返回无限制条件
-			return "1=1", nil
-		}
-		return "", err
+	controlledTable, err := loadControlledTable(tableName)
+	if err != nil {
+		return NoCondition, err
+	}
+	if controlledTable == nil {
+		// 如果表不受控制，返回无限制条件
+		return AllowAll, nil
+	}
+	part, err := m.roleCondition(controlledTable, authorityID, userID)
+	return part.Condition, err
+}
+
+// RolePart 单个角色在受控表上生成的条件
+type RolePart struct {
+	AuthorityID     uint
+	Configured      bool // 未配置时使用默认的本人数据权限
+	DataScope       string
+	CustomCondition string
+	Priority        int
+	Condition       Condition
+}
+
+// EffectiveCondition 按受控表的多角色合并策略得到的最终条件
+type EffectiveCondition struct {
+	Controlled bool   // 表是否受控
+	Strategy   string // 多角色合并策略
+	Condition  Condition
+	Parts      []RolePart // 参与合并的各角色条件
+}
+
+// ResolveCondition 按受控表配置的多角色合并策略计算用户的最终数据权限条件
+// union 以OR合并全部角色 strictest 以AND合并全部角色 active 只使用当前激活角色
+func (m *DataPermissionMiddleware) ResolveCondition(tableName string, scope PermissionScope) (EffectiveCondition, error) {
+	controlledTable, err := loadControlledTable(tableName)
+	if err != nil {
+		return EffectiveCondition{}, err
+	}
+	if controlledTable == nil {
+		return EffectiveCondition{Condition: AllowAll}, nil
 	}
 
-	// 获取角色数据权限配置
-	var roleDataPermission model.RoleDataPermission
-	if err := SkipDataPermission(global.GVA_DB).Where("authority_id = ? AND controlled_table_id = ? AND enabled = ?", authorityID, controlledTable.ID, true).First(&roleDataPermission).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// 如果没有配置权限，使用默认的自己数据权限
-			return m.generateSQLCondition("self", "", controlledTable, userID), nil
+	result := EffectiveCondition{Controlled: true, Strategy: controlledTable.Strategy()}
+	authorityIds := scope.AuthorityIds
+	if result.Strategy == model.StrategyActive && scope.AuthorityID != 0 {
+		authorityIds = []uint{scope.AuthorityID}
+	}
+	conditions := make([]Condition, 0, len(authorityIds))
+	for _, authorityID := range authorityIds {
+		part, err := m.roleCondition(controlledTable, authorityID, scope.UserID)
+		if err != nil {
+			return EffectiveCondition{}, err
 		}
-		return "", err
+		result.Parts = append(result.Parts, part)
+		conditions = append(conditions, part.Condition)
 	}
 
-	// 生成SQL条件
-	return m.generateSQLCondition(roleDataPermission.DataScope, roleDataPermission.CustomCondition, controlledTable, userID), nil
+	if result.Strategy == model.StrategyStrictest {
+		result.Condition = AndConditions(conditions...)
+	} else {
+		result.Condition = OrConditions(conditions...)
+	}
+	return result, nil
+}
+
+// roleCondition 生成单个角色在受控表上的条件 角色未配置时使用默认的本人数据权限
+func (m *DataPermissionMiddleware) roleCondition(controlledTable *model.ControlledTable, authorityID, userID uint) (RolePart, error) {
+	part := RolePart{AuthorityID: authorityID, DataScope: "self"}
+	roleDataPermission, err := loadRoleDataPermission(controlledTable, authorityID)
+	if err != nil {
+		return part, err
+	}
+	if roleDataPermission != nil {
+		part.Configured = true
+		part.DataScope = roleDataPermission.DataScope
+		part.CustomCondition = roleDataPermission.CustomCondition
+		part.Priority = roleDataPermission.Priority
+	}
+	part.Condition = m.generateSQLCondition(part.DataScope, part.CustomCondition, *controlledTable, userID, authorityID)
+	return part, nil
+}
+
+// CompileTableCondition 编译指定角色和用户在表上的数据权限条件
+func (m *DataPermissionMiddleware) CompileTableCondition(tableName string, authorityID, userID uint) (Condition, error) {
+	return m.getDataPermissionCondition(tableName, authorityID, userID)
+}
+
+// ValidateCustomCondition 校验自定义条件的语法及引用的列是否存在于受控表中
+func ValidateCustomCondition(tableName, customCondition string) error {
+	columns, err := TableColumns(global.GVA_DB, tableName)
+	if err != nil {
+		return err
+	}
+	_, err = CompileCondition(customCondition, columns, ConditionVars{})
+	return err
 }
 
 // getFieldPermissions 获取字段权限配置
 func (m *DataPermissionMiddleware) getFieldPermissions(tableName string, authorityID uint) (map[string]model.RoleFieldPermission, error) {
 	// 获取受控表信息
-	var controlledTable model.ControlledTable
-	if err := SkipDataPermission(global.GVA_DB).Where("table_name = ? AND enabled = ?", tableName, true).First(&controlledTable).Error; err != nil {
+	controlledTable, err := loadControlledTable(tableName)
+	if err != nil || controlledTable == nil {
 		return nil, err
 	}
 
 	// 获取字段权限配置
-	var fieldPermissions []model.RoleFieldPermission
-	if err := SkipDataPermission(global.GVA_DB).Where("authority_id = ? AND controlled_table_id = ? AND enabled = ?", authorityID, controlledTable.ID, true).Find(&fieldPermissions).Error; err != nil {
-		return nil, err
-	}
-
-	// 转换为map
-	permissionMap := make(map[string]model.RoleFieldPermission)
-	for _, perm := range fieldPermissions {
-		permissionMap[perm.FieldName] = perm
-	}
-
-	return permissionMap, nil
+	return loadRoleFieldPermissions(controlledTable, authorityID)
 }
 
-// generateSQLCondition 生成SQL条件
-func (m *DataPermissionMiddleware) generateSQLCondition(dataScope, customCondition string, table model.ControlledTable, userID uint) string {
+// generateSQLCondition 生成SQL条件 所有取值均以参数绑定
+func (m *DataPermissionMiddleware) generateSQLCondition(dataScope, customCondition string, table model.ControlledTable, userID, authorityID uint) Condition {
 	switch dataScope {
 	case "self":
 		if table.UserField != "" {
-			return fmt.Sprintf("%s = %d", table.UserField, userID)
+			return ColumnEquals(table.UserField, userID)
 		}
-		return ""
+		return NoCondition
 	case "dept":
 		if table.DeptField != "" {
-			// 获取用户部门ID，可以和组织机构插件结合
-			return fmt.Sprintf("%s = (SELECT %s FROM sys_users WHERE id = %d)", table.DeptField, table.DeptField, userID)
+			deptID, err := m.getUserDeptId(userID)
+			if err != nil || deptID == 0 {
+				// 用户未分配部门时不可见任何部门数据
+				return DenyAll
+			}
+			return ColumnEquals(table.DeptField, deptID)
 		}
-		return ""
+		return NoCondition
 	case "dept_and_child":
 		if table.DeptField != "" {
-			// 获取用户部门及子部门，可以和组织机构插件结合
-			return fmt.Sprintf("%s IN (SELECT id FROM sys_dept WHERE find_in_set(id, get_child_dept((SELECT dept_id FROM sys_users WHERE id = %d))))", table.DeptField, userID)
+			deptID, err := m.getUserDeptId(userID)
+			if err != nil || deptID == 0 {
+				return DenyAll
+			}
+			// 部门树在应用层展开 生成通用的IN条件 兼容各类数据库
+			deptIds, err := systemService.DeptAndChildIds(SkipDataPermission(global.GVA_DB), deptID)
+			if err != nil {
+				global.GVA_LOG.Error("获取下级部门失败", zap.Error(err))
+				return DenyAll
+			}
+			return buildInCondition(table.DeptField, deptIds)
 		}
-		return ""
+		return NoCondition
 	case "all":
-		return "1=1"
+		return AllowAll
 	case "custom":
 		if customCondition != "" {
-			// 替换自定义条件中的占位符
-			condition := strings.ReplaceAll(customCondition, "${USER_ID}", fmt.Sprintf("%d", userID))
+			condition, err := m.compileCustomCondition(customCondition, nil, userID, authorityID)
+			if err != nil {
+				// 自定义条件无效时拒绝访问 避免放大数据范围
+				global.GVA_LOG.Error("自定义数据权限条件无效", zap.String("table", table.Table), zap.Uint("authorityId", authorityID), zap.Error(err))
+				return DenyAll
+			}
 			return condition
 		}
-		return ""
+		return NoCondition
 	default:
-		return ""
+		return NoCondition
+	}
+}
+
+// compileCustomCondition 编译自定义条件 仅在条件使用 ${DEPT_ID} 时查询用户部门
+func (m *DataPermissionMiddleware) compileCustomCondition(customCondition string, columns map[string]bool, userID, authorityID uint) (Condition, error) {
+	vars := ConditionVars{UserID: userID, AuthorityID: authorityID}
+	if ConditionPlaceholders(customCondition)[PlaceholderDeptID] {
+		deptID, err := m.getUserDeptId(userID)
+		if err != nil {
+			return NoCondition, err
+		}
+		vars.DeptID = deptID
+	}
+	return CompileCondition(customCondition, columns, vars)
+}
+
+// getUserDeptId 获取用户所属部门ID
+func (m *DataPermissionMiddleware) getUserDeptId(userID uint) (uint, error) {
+	var user system.SysUser
+	if err := SkipDataPermission(global.GVA_DB).Select("dept_id").Where("id = ?", userID).First(&user).Error; err != nil {
+		return 0, err
+	}
+	return user.DeptId, nil
+}
+
+// maxInListSize Oracle单个IN列表最多1000项
+const maxInListSize = 1000
+
+// buildInCondition 生成 field IN (...) 条件 超出上限时拆分为多个IN并以OR连接
+func buildInCondition(field string, ids []uint) Condition {
+	if len(ids) == 0 {
+		return DenyAll
+	}
+	groups := make([]string, 0, len(ids)/maxInListSize+1)
+	vars := make([]interface{}, 0, (len(ids)/maxInListSize+1)*2)
+	for start := 0; start < len(ids); start += maxInListSize {
+		end := start + maxInListSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		groups = append(groups, "? IN ?")
+		vars = append(vars, clause.Column{Name: field}, ids[start:end])
 	}
+	return Condition{SQL: strings.Join(groups, " OR "), Vars: vars}
 }
 
 // filterFieldsByPermission 根据权限过滤字段
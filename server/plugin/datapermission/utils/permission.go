@@ -2,7 +2,6 @@ package utils
 
 import (
 	"errors"
-	"fmt"
 	"reflect"
 	"strings"
 
@@ -43,18 +42,17 @@ func (p *PermissionHelper) CheckTablePermission(c *gin.Context, tableName string
 	}
 
 	// 检查表是否受控制
-	var controlledTable model.ControlledTable
-	if err := global.GVA_DB.Where("table_name = ? AND enabled = ?", tableName, true).First(&controlledTable).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return true // 如果表不受控制，默认允许
-		}
+	controlledTable, err := loadControlledTable(tableName)
+	if err != nil {
 		return false
 	}
+	if controlledTable == nil {
+		return true // 如果表不受控制，默认允许
+	}
 
 	// 检查角色是否有权限配置
-	var count int64
-	global.GVA_DB.Model(&model.RoleDataPermission{}).Where("authority_id = ? AND controlled_table_id = ? AND enabled = ?", authorityID, controlledTable.ID, true).Count(&count)
-	return count > 0
+	roleDataPermission, err := loadRoleDataPermission(controlledTable, authorityID.(uint))
+	return err == nil && roleDataPermission != nil
 }
 
 // CheckFieldPermission 检查字段权限
@@ -72,49 +70,61 @@ func (p *PermissionHelper) GetUserDataScope(c *gin.Context, tableName string) (s
 	}
 
 	// 获取受控表信息
-	var controlledTable model.ControlledTable
-	if err := global.GVA_DB.Where("table_name = ? AND enabled = ?", tableName, true).First(&controlledTable).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "all", nil // 如果表不受控制，返回全部权限
-		}
+	controlledTable, err := loadControlledTable(tableName)
+	if err != nil {
 		return "self", err
 	}
+	if controlledTable == nil {
+		return "all", nil // 如果表不受控制，返回全部权限
+	}
 
 	// 获取角色数据权限配置
-	var roleDataPermission model.RoleDataPermission
-	if err := global.GVA_DB.Where("authority_id = ? AND controlled_table_id = ? AND enabled = ?", authorityID, controlledTable.ID, true).First(&roleDataPermission).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "self", nil // 如果没有配置权限，默认返回自己数据权限
-		}
+	roleDataPermission, err := loadRoleDataPermission(controlledTable, authorityID.(uint))
+	if err != nil {
 		return "self", err
 	}
+	if roleDataPermission == nil {
+		return "self", nil // 如果没有配置权限，默认返回自己数据权限
+	}
 
 	return roleDataPermission.DataScope, nil
 }
 
-// GetDataPermissionSQL 获取数据权限SQL条件
-func (p *PermissionHelper) GetDataPermissionSQL(c *gin.Context, tableName string) (string, error) {
+// GetDataPermissionCondition 获取当前用户全部角色在指定表的数据权限条件
+func (p *PermissionHelper) GetDataPermissionCondition(c *gin.Context, tableName string) (Condition, error) {
 	// 从上下文获取用户信息
 	userID, exists := c.Get("userID")
 	if !exists {
-		return "1=1", errors.New("无法获取用户ID")
+		return AllowAll, errors.New("无法获取用户ID")
 	}
 
 	authorityIds, exists := c.Get("authorityIds")
 	if !exists {
-		return "1=1", errors.New("无法获取用户权限信息")
+		return AllowAll, errors.New("无法获取用户权限信息")
 	}
 
-	middleware := &DataPermissionMiddleware{}
-	for authorityID, _ := range authorityIds.([]uint) {
-		condition, err := middleware.getDataPermissionCondition(tableName, uint(authorityID), userID.(uint))
-		if err != nil {
-			return "1=1", err
-		}
+	scope := PermissionScope{UserID: userID.(uint), AuthorityIds: authorityIds.([]uint)}
+	if authorityID, ok := c.Get("authorityId"); ok {
+		scope.AuthorityID, _ = authorityID.(uint)
+	}
+	effective, err := (&DataPermissionMiddleware{}).ResolveCondition(tableName, scope)
+	if err != nil {
+		return AllowAll, err
+	}
+	if effective.Condition.IsEmpty() {
+		return AllowAll, nil
+	}
+	return effective.Condition, nil
+}
 
-		return condition, nil
+// GetDataPermissionSQL 获取数据权限SQL条件 参数已按当前数据库方言代入 仅用于展示和调试
+func (p *PermissionHelper) GetDataPermissionSQL(c *gin.Context, tableName string) (string, error) {
+	condition, err := p.GetDataPermissionCondition(c, tableName)
+	if err != nil {
+		return "1=1", err
 	}
-	return "1=1", nil
+	_, _, explained := ExplainCondition(global.GVA_DB, condition)
+	return explained, nil
 }
 
 // FilterFieldsByPermission 根据权限过滤结构体字段
@@ -264,21 +274,20 @@ func (p *PermissionHelper) GetEditableFields(c *gin.Context, tableName string) (
 
 // ValidateDataAccess 验证用户是否有权限访问指定数据
 func (p *PermissionHelper) ValidateDataAccess(c *gin.Context, tableName string, recordID uint) (bool, error) {
-	// 获取数据权限SQL条件
-	condition, err := p.GetDataPermissionSQL(c, tableName)
+	// 获取数据权限条件
+	condition, err := p.GetDataPermissionCondition(c, tableName)
 	if err != nil {
 		return false, err
 	}
 
-	// 如果条件是1=1，表示有全部权限
-	if condition == "1=1" {
+	// 不限制时表示有全部权限
+	if condition.IsAll() {
 		return true, nil
 	}
 
 	// 检查记录是否满足权限条件
 	var count int64
-	sql := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id = ? AND (%s)", tableName, condition)
-	if err := global.GVA_DB.Raw(sql, recordID).Count(&count).Error; err != nil {
+	if err := SkipDataPermission(global.GVA_DB).Table(tableName).Where("id = ?", recordID).Where(condition.Expr()).Count(&count).Error; err != nil {
 		return false, err
 	}
 
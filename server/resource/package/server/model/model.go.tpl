@@ -10,7 +10,7 @@ package {{.Package}}
 
 {{- if not .OnlyTemplate}}
 import (
-	{{- if .GvaModel }}
+	{{- if or .GvaModel .AutoCreateTenant }}
 	"{{.Module}}/global"
 	{{- end }}
 	{{- if or .HasTimer }}
@@ -28,6 +28,9 @@ type {{.StructName}} struct {
 {{- if .GvaModel }}
     global.GVA_MODEL
 {{- end }}
+{{- if .AutoCreateTenant }}
+    global.GVA_TENANT
+{{- end }}
 {{- range .Fields}}
   {{ GenerateField . }}
 {{- end }}
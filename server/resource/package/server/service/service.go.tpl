@@ -4,6 +4,9 @@
 {{- else}}
  {{- $db =  printf "global.MustGetGlobalDBByDBName(\"%s\")" .BusinessDB   }}
 {{- end}}
+{{- if .AutoCreateTenant }}
+ {{- $db = printf "%s.WithContext(ctx)" $db }}
+{{- end}}
 
 {{- if .IsAdd}}
 
@@ -9,7 +9,7 @@ package model
 
 {{- if not .OnlyTemplate}}
 import (
-	{{- if .GvaModel }}
+	{{- if or .GvaModel .AutoCreateTenant }}
 	"{{.Module}}/global"
 	{{- end }}
 	{{- if or .HasTimer }}
@@ -27,6 +27,9 @@ type {{.StructName}} struct {
 {{- if .GvaModel }}
     global.GVA_MODEL
 {{- end }}
+{{- if .AutoCreateTenant }}
+    global.GVA_TENANT
+{{- end }}
 {{- range .Fields}}
   {{ GenerateField . }}
 {{- end }}
@@ -4,6 +4,9 @@
 {{- else}}
  {{- $db =  printf "global.MustGetGlobalDBByDBName(\"%s\")" .BusinessDB   }}
 {{- end}}
+{{- if .AutoCreateTenant }}
+ {{- $db = printf "%s.WithContext(ctx)" $db }}
+{{- end}}
 
 {{- if .IsAdd}}
 
@@ -59,6 +62,9 @@ type {{.Abbreviation}} struct {}
 {{- else}}
  {{- $db =  printf "global.MustGetGlobalDBByDBName(\"%s\")" .BusinessDB   }}
 {{- end}}
+{{- if .AutoCreateTenant }}
+ {{- $db = printf "%s.WithContext(ctx)" $db }}
+{{- end}}
 {{- if not .OnlyTemplate }}
 // Create{{.StructName}} 创建{{.Description}}记录
 // Author [yourname](https://github.com/yourname)
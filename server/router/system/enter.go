@@ -20,6 +20,10 @@ type RouterGroup struct {
 	SysExportTemplateRouter
 	SysParamsRouter
 	SysVersionRouter
+	DeptRouter
+	TenantRouter
+	AuthorityGrantRouter
+	SysJobRouter
 }
 
 var (
@@ -43,4 +47,8 @@ var (
 	autoCodeTemplateApi = api.ApiGroupApp.SystemApiGroup.AutoCodeTemplateApi
 	exportTemplateApi   = api.ApiGroupApp.SystemApiGroup.SysExportTemplateApi
 	sysVersionApi       = api.ApiGroupApp.SystemApiGroup.SysVersionApi
+	deptApi             = api.ApiGroupApp.SystemApiGroup.DeptApi
+	tenantApi           = api.ApiGroupApp.SystemApiGroup.TenantApi
+	authorityGrantApi   = api.ApiGroupApp.SystemApiGroup.AuthorityGrantApi
+	sysJobApi           = api.ApiGroupApp.SystemApiGroup.SysJobApi
 )
@@ -0,0 +1,22 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type AuthorityGrantRouter struct{}
+
+// InitAuthorityGrantRouter 初始化 临时授权 路由信息
+func (s *AuthorityGrantRouter) InitAuthorityGrantRouter(Router *gin.RouterGroup) {
+	grantRouter := Router.Group("authorityGrant").Use(middleware.OperationRecord())
+	grantRouterWithoutRecord := Router.Group("authorityGrant")
+	{
+		grantRouter.POST("createAuthorityGrant", authorityGrantApi.CreateAuthorityGrant) // 创建临时授权
+		grantRouter.POST("reviewAuthorityGrant", authorityGrantApi.ReviewAuthorityGrant) // 审批临时授权
+		grantRouter.POST("revokeAuthorityGrant", authorityGrantApi.RevokeAuthorityGrant) // 撤销临时授权
+	}
+	{
+		grantRouterWithoutRecord.GET("getAuthorityGrantList", authorityGrantApi.GetAuthorityGrantList) // 分页获取临时授权
+	}
+}
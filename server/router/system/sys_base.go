@@ -11,6 +11,11 @@ func (s *BaseRouter) InitBaseRouter(Router *gin.RouterGroup) (R gin.IRoutes) {
 	{
 		baseRouter.POST("login", baseApi.Login)
 		baseRouter.POST("captcha", baseApi.Captcha)
+		baseRouter.POST("refresh", baseApi.RefreshToken)
+		baseRouter.POST("totpLogin", baseApi.TotpLogin)
+		baseRouter.POST("totpEnroll", baseApi.TotpEnroll)
+		baseRouter.GET("oidcAuthorize", baseApi.OidcAuthorize)
+		baseRouter.POST("oidcLogin", baseApi.OidcLogin)
 	}
 	return baseRouter
 }
@@ -15,5 +15,6 @@ func (s *CasbinRouter) InitCasbinRouter(Router *gin.RouterGroup) {
 	}
 	{
 		casbinRouterWithoutRecord.POST("getPolicyPathByAuthorityId", casbinApi.GetPolicyPathByAuthorityId)
+		casbinRouterWithoutRecord.POST("explainPermission", casbinApi.ExplainPermission)
 	}
 }
@@ -0,0 +1,24 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type DeptRouter struct{}
+
+// InitDeptRouter 初始化 部门 路由信息
+func (s *DeptRouter) InitDeptRouter(Router *gin.RouterGroup) {
+	deptRouter := Router.Group("dept").Use(middleware.OperationRecord())
+	deptRouterWithoutRecord := Router.Group("dept")
+	{
+		deptRouter.POST("createDept", deptApi.CreateDept)   // 新建部门
+		deptRouter.POST("deleteDept", deptApi.DeleteDept)   // 删除部门
+		deptRouter.PUT("updateDept", deptApi.UpdateDept)    // 更新部门
+		deptRouter.POST("setUserDept", deptApi.SetUserDept) // 设置用户所属部门
+	}
+	{
+		deptRouterWithoutRecord.GET("findDept", deptApi.FindDept)       // 根据ID获取部门
+		deptRouterWithoutRecord.GET("getDeptTree", deptApi.GetDeptTree) // 获取部门树
+	}
+}
@@ -0,0 +1,28 @@
+package system
+
+import (
+	"github.com/flipped-aurora/gin-vue-admin/server/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type SysJobRouter struct{}
+
+// InitSysJobRouter 初始化 定时任务 路由信息
+func (s *SysJobRouter) InitSysJobRouter(Router *gin.RouterGroup) {
+	sysJobRouter := Router.Group("sysJob").Use(middleware.OperationRecord())
+	sysJobRouterWithoutRecord := Router.Group("sysJob")
+	{
+		sysJobRouter.POST("createSysJob", sysJobApi.CreateSysJob)               // 创建定时任务
+		sysJobRouter.PUT("updateSysJob", sysJobApi.UpdateSysJob)                // 更新定时任务
+		sysJobRouter.DELETE("deleteSysJobsByIds", sysJobApi.DeleteSysJobsByIds) // 删除定时任务
+		sysJobRouter.POST("pauseSysJob", sysJobApi.PauseSysJob)                 // 暂停定时任务
+		sysJobRouter.POST("resumeSysJob", sysJobApi.ResumeSysJob)               // 恢复定时任务
+		sysJobRouter.POST("runSysJob", sysJobApi.RunSysJob)                     // 立即执行定时任务
+	}
+	{
+		sysJobRouterWithoutRecord.GET("findSysJob", sysJobApi.FindSysJob)               // 根据ID获取定时任务
+		sysJobRouterWithoutRecord.GET("getSysJobList", sysJobApi.GetSysJobList)         // 分页获取定时任务
+		sysJobRouterWithoutRecord.GET("getSysJobRunList", sysJobApi.GetSysJobRunList)   // 分页获取执行记录
+		sysJobRouterWithoutRecord.GET("getSysJobHandlers", sysJobApi.GetSysJobHandlers) // 获取已注册的处理函数
+	}
+}
@@ -12,3 +12,8 @@ func (s *JwtRouter) InitJwtRouter(Router *gin.RouterGroup) {
 		jwtRouter.POST("jsonInBlacklist", jwtApi.JsonInBlacklist) // jwt加入黑名单
 	}
 }
+
+// InitJwksRouter 公开验签公钥 供下游服务验证令牌
+func (s *JwtRouter) InitJwksRouter(Router *gin.RouterGroup) {
+	Router.GET(".well-known/jwks.json", jwtApi.GetJwks) // 获取jwt验签公钥
+}
@@ -9,10 +9,13 @@ type OperationRecordRouter struct{}
 func (s *OperationRecordRouter) InitSysOperationRecordRouter(Router *gin.RouterGroup) {
 	operationRecordRouter := Router.Group("sysOperationRecord")
 	{
-		operationRecordRouter.DELETE("deleteSysOperationRecord", operationRecordApi.DeleteSysOperationRecord)           // 删除SysOperationRecord
-		operationRecordRouter.DELETE("deleteSysOperationRecordByIds", operationRecordApi.DeleteSysOperationRecordByIds) // 批量删除SysOperationRecord
-		operationRecordRouter.GET("findSysOperationRecord", operationRecordApi.FindSysOperationRecord)                  // 根据ID获取SysOperationRecord
-		operationRecordRouter.GET("getSysOperationRecordList", operationRecordApi.GetSysOperationRecordList)            // 获取SysOperationRecord列表
+		operationRecordRouter.DELETE("deleteSysOperationRecord", operationRecordApi.DeleteSysOperationRecord)            // 删除SysOperationRecord
+		operationRecordRouter.DELETE("deleteSysOperationRecordByIds", operationRecordApi.DeleteSysOperationRecordByIds)  // 批量删除SysOperationRecord
+		operationRecordRouter.GET("findSysOperationRecord", operationRecordApi.FindSysOperationRecord)                   // 根据ID获取SysOperationRecord
+		operationRecordRouter.GET("getSysOperationRecordList", operationRecordApi.GetSysOperationRecordList)             // 获取SysOperationRecord列表
+		operationRecordRouter.GET("getOperationRecordStats", operationRecordApi.GetOperationRecordStats)                 // 获取操作记录写入队列指标
+		operationRecordRouter.GET("getSysOperationRecordStatistics", operationRecordApi.GetSysOperationRecordStatistics) // 统计操作记录
+		operationRecordRouter.GET("verifySysOperationRecordChain", operationRecordApi.VerifySysOperationRecordChain)     // 校验操作记录哈希链
 
 	}
 }
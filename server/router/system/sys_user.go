@@ -11,18 +11,33 @@ func (s *UserRouter) InitUserRouter(Router *gin.RouterGroup) {
 	userRouter := Router.Group("user").Use(middleware.OperationRecord())
 	userRouterWithoutRecord := Router.Group("user")
 	{
-		userRouter.POST("admin_register", baseApi.Register)               // 管理员注册账号
-		userRouter.POST("changePassword", baseApi.ChangePassword)         // 用户修改密码
-		userRouter.POST("setUserAuthority", baseApi.SetUserAuthority)     // 设置用户权限
-		userRouter.DELETE("deleteUser", baseApi.DeleteUser)               // 删除用户
-		userRouter.PUT("setUserInfo", baseApi.SetUserInfo)                // 设置用户信息
-		userRouter.PUT("setSelfInfo", baseApi.SetSelfInfo)                // 设置自身信息
-		userRouter.POST("setUserAuthorities", baseApi.SetUserAuthorities) // 设置用户权限组
-		userRouter.POST("resetPassword", baseApi.ResetPassword)           // 设置用户权限组
-		userRouter.PUT("setSelfSetting", baseApi.SetSelfSetting)          // 用户界面配置
+		userRouter.POST("admin_register", baseApi.Register)                         // 管理员注册账号
+		userRouter.POST("changePassword", baseApi.ChangePassword)                   // 用户修改密码
+		userRouter.POST("setUserAuthority", baseApi.SetUserAuthority)               // 设置用户权限
+		userRouter.DELETE("deleteUser", baseApi.DeleteUser)                         // 删除用户
+		userRouter.PUT("setUserInfo", baseApi.SetUserInfo)                          // 设置用户信息
+		userRouter.PUT("setSelfInfo", baseApi.SetSelfInfo)                          // 设置自身信息
+		userRouter.POST("setUserAuthorities", baseApi.SetUserAuthorities)           // 设置用户权限组
+		userRouter.POST("resetPassword", baseApi.ResetPassword)                     // 设置用户权限组
+		userRouter.PUT("setSelfSetting", baseApi.SetSelfSetting)                    // 用户界面配置
+		userRouter.POST("setupTotp", baseApi.SetupTotp)                             // 生成两步验证密钥
+		userRouter.POST("enableTotp", baseApi.EnableTotp)                           // 启用两步验证
+		userRouter.POST("disableTotp", baseApi.DisableTotp)                         // 关闭两步验证
+		userRouter.POST("regenerateRecoveryCodes", baseApi.RegenerateRecoveryCodes) // 重新生成恢复码
+		userRouter.POST("resetUserTotp", baseApi.ResetUserTotp)                     // 管理员重置用户两步验证
+		userRouter.POST("unlockUser", baseApi.UnlockUser)                           // 管理员解除用户登录锁定
+		userRouter.POST("revokeSession", baseApi.RevokeSession)                     // 撤销自身的登录会话
+		userRouter.POST("forceLogout", baseApi.ForceLogout)                         // 管理员强制用户下线
+		userRouter.POST("createToken", baseApi.CreateUserToken)                     // 创建个人访问令牌
+		userRouter.PUT("updateToken", baseApi.UpdateUserToken)                      // 修改个人访问令牌
+		userRouter.DELETE("deleteToken", baseApi.DeleteUserToken)                   // 删除个人访问令牌
 	}
 	{
-		userRouterWithoutRecord.POST("getUserList", baseApi.GetUserList) // 分页获取用户列表
-		userRouterWithoutRecord.GET("getUserInfo", baseApi.GetUserInfo)  // 获取自身信息
+		userRouterWithoutRecord.POST("getUserList", baseApi.GetUserList)       // 分页获取用户列表
+		userRouterWithoutRecord.GET("getUserInfo", baseApi.GetUserInfo)        // 获取自身信息
+		userRouterWithoutRecord.GET("getTotpStatus", baseApi.GetTotpStatus)    // 获取两步验证状态
+		userRouterWithoutRecord.GET("getSessions", baseApi.GetSessions)        // 获取自身的登录会话
+		userRouterWithoutRecord.POST("getSessionList", baseApi.GetSessionList) // 分页获取全部在线会话
+		userRouterWithoutRecord.GET("getTokens", baseApi.GetUserTokens)        // 获取自身的个人访问令牌
 	}
 }
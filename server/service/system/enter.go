@@ -18,6 +18,14 @@ type ServiceGroup struct {
 	SysExportTemplateService
 	SysParamsService
 	SysVersionService
+	UserTotpService
+	UserSessionService
+	UserTokenService
+	OidcService
+	DeptService
+	TenantService
+	AuthorityGrantService
+	JobService
 	AutoCodePlugin   autoCodePlugin
 	AutoCodePackage  autoCodePackage
 	AutoCodeHistory  autoCodeHistory
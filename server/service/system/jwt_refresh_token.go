@@ -0,0 +1,231 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("刷新令牌无效或已过期")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用, 该登录下的全部令牌已失效")
+)
+
+const tokenFamilyKeyPrefix = "jwt_family:"
+
+// tokenFamily 令牌族状态 同一次登录签发及刷新得到的令牌属于同一族
+// 只有RefreshJti对应的刷新令牌可用 已轮换的刷新令牌再次出现视为被盗用
+type tokenFamily struct {
+	UserID      uint   `json:"userId"`
+	RefreshJti  string `json:"refreshJti"`  // 当前有效的刷新令牌id
+	AccessToken string `json:"accessToken"` // 当前访问令牌 令牌族撤销时一并拉黑
+}
+
+// familyMu 本地缓存模式下保证令牌轮换的原子性
+var familyMu sync.Mutex
+
+//@function: IssueTokenPair
+//@description: 登录时创建新的令牌族 签发访问令牌与刷新令牌
+//@param: user system.Login
+//@return: pair utils.TokenPair, err error
+
+func (jwtService *JwtService) IssueTokenPair(user system.Login) (pair utils.TokenPair, err error) {
+	familyID := uuid.New().String()
+	pair, err = utils.LoginTokenPair(user, familyID)
+	if err != nil {
+		return
+	}
+	err = jwtService.saveTokenFamily(familyID, tokenFamily{
+		UserID:      user.GetUserId(),
+		RefreshJti:  pair.RefreshClaims.RegisteredClaims.ID,
+		AccessToken: pair.Token,
+	}, time.Until(pair.RefreshClaims.ExpiresAt.Time), "")
+	return
+}
+
+//@function: RotateRefreshToken
+//@description: 使用刷新令牌换取新的令牌对 旧刷新令牌立即失效 重复使用已轮换的刷新令牌将撤销整个令牌族
+//@param: refreshToken string
+//@return: pair utils.TokenPair, user system.SysUser, err error
+
+func (jwtService *JwtService) RotateRefreshToken(refreshToken string) (pair utils.TokenPair, user system.SysUser, err error) {
+	claims, err := utils.NewJWT().ParseToken(refreshToken)
+	if err != nil || !utils.IsRefreshClaims(claims) || claims.FamilyID == "" {
+		return pair, user, ErrRefreshTokenInvalid
+	}
+	family, ok := jwtService.loadTokenFamily(claims.FamilyID)
+	if !ok {
+		return pair, user, ErrRefreshTokenInvalid
+	}
+	if family.RefreshJti != claims.RegisteredClaims.ID {
+		global.GVA_LOG.Warn("检测到刷新令牌重复使用, 撤销令牌族", zap.String("username", claims.Username), zap.String("family", claims.FamilyID))
+		_ = jwtService.RevokeTokenFamily(claims.FamilyID)
+		return pair, user, ErrRefreshTokenReused
+	}
+
+	err = global.GVA_DB.Preload("Authorities").Preload("Authority").Where("uuid = ?", claims.UUID).First(&user).Error
+	if err != nil || user.Enable != 1 {
+		_ = jwtService.RevokeTokenFamily(claims.FamilyID)
+		return pair, user, ErrRefreshTokenInvalid
+	}
+
+	// 返回的用户保留原始状态 供登录响应计算密码状态
+	login := user
+	UserServiceApp.RestrictPassword(&login)
+	pair, err = utils.LoginTokenPair(&login, claims.FamilyID)
+	if err != nil {
+		return
+	}
+	err = jwtService.saveTokenFamily(claims.FamilyID, tokenFamily{
+		UserID:      user.ID,
+		RefreshJti:  pair.RefreshClaims.RegisteredClaims.ID,
+		AccessToken: pair.Token,
+	}, time.Until(pair.RefreshClaims.ExpiresAt.Time), family.RefreshJti)
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// 并发请求已抢先轮换 同样视为重复使用
+		global.GVA_LOG.Warn("检测到刷新令牌重复使用, 撤销令牌族", zap.String("username", claims.Username), zap.String("family", claims.FamilyID))
+		_ = jwtService.RevokeTokenFamily(claims.FamilyID)
+		return utils.TokenPair{}, user, err
+	}
+	if err != nil {
+		return
+	}
+	if family.AccessToken != "" {
+		if e := jwtService.JsonInBlacklist(system.JwtBlacklist{Jwt: family.AccessToken}); e != nil {
+			global.GVA_LOG.Error("旧访问令牌作废失败!", zap.Error(e))
+		}
+	}
+	if global.GVA_CONFIG.System.UseMultipoint {
+		err = utils.SetRedisJWT(pair.Token, user.Username)
+	}
+	return
+}
+
+//@function: RevokeTokenFamily
+//@description: 撤销令牌族 刷新令牌失效 当前访问令牌加入黑名单
+//@param: familyID string
+//@return: err error
+
+func (jwtService *JwtService) RevokeTokenFamily(familyID string) (err error) {
+	if familyID == "" {
+		return nil
+	}
+	family, ok := jwtService.loadTokenFamily(familyID)
+	if !ok {
+		return nil
+	}
+	jwtService.deleteTokenFamily(familyID)
+	if family.AccessToken != "" {
+		_, blacked := global.BlackCache.Get(family.AccessToken)
+		if !blacked {
+			err = jwtService.JsonInBlacklist(system.JwtBlacklist{Jwt: family.AccessToken})
+		}
+	}
+	return err
+}
+
+//@function: RevokeTokenFamilyByToken
+//@description: 根据访问令牌撤销其所属令牌族 用于注销及多点登录挤下线
+//@param: token string
+//@return: err error
+
+func (jwtService *JwtService) RevokeTokenFamilyByToken(token string) (err error) {
+	claims, err := utils.NewJWT().ParseToken(token)
+	if err != nil {
+		// 令牌已过期或无效时无需撤销
+		return nil
+	}
+	return jwtService.RevokeTokenFamily(claims.FamilyID)
+}
+
+// useRedisTokenFamily 多点登录模式下令牌族存放于redis 否则存放于本地BlackCache
+func useRedisTokenFamily() bool {
+	return global.GVA_CONFIG.System.UseMultipoint && global.GVA_REDIS != nil
+}
+
+func (jwtService *JwtService) loadTokenFamily(familyID string) (family tokenFamily, ok bool) {
+	key := tokenFamilyKeyPrefix + familyID
+	if !useRedisTokenFamily() {
+		v, exist := global.BlackCache.Get(key)
+		if !exist {
+			return family, false
+		}
+		family, ok = v.(tokenFamily)
+		return family, ok
+	}
+	data, err := global.GVA_REDIS.Get(context.Background(), key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			global.GVA_LOG.Error("获取令牌族失败!", zap.Error(err))
+		}
+		return family, false
+	}
+	return family, json.Unmarshal(data, &family) == nil
+}
+
+// saveTokenFamily 保存令牌族 expectJti非空时仅当当前刷新令牌id与之相同才写入 否则返回ErrRefreshTokenReused
+func (jwtService *JwtService) saveTokenFamily(familyID string, family tokenFamily, ttl time.Duration, expectJti string) error {
+	key := tokenFamilyKeyPrefix + familyID
+	if !useRedisTokenFamily() {
+		familyMu.Lock()
+		defer familyMu.Unlock()
+		if expectJti != "" {
+			if old, ok := jwtService.loadTokenFamily(familyID); !ok || old.RefreshJti != expectJti {
+				return ErrRefreshTokenReused
+			}
+		}
+		global.BlackCache.Set(key, family, ttl)
+		return nil
+	}
+	data, err := json.Marshal(family)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if expectJti == "" {
+		return global.GVA_REDIS.Set(ctx, key, data, ttl).Err()
+	}
+	err = global.GVA_REDIS.Watch(ctx, func(tx *redis.Tx) error {
+		raw, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return ErrRefreshTokenReused
+			}
+			return err
+		}
+		var old tokenFamily
+		if err = json.Unmarshal(raw, &old); err != nil || old.RefreshJti != expectJti {
+			return ErrRefreshTokenReused
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, ttl)
+			return nil
+		})
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return ErrRefreshTokenReused
+	}
+	return err
+}
+
+func (jwtService *JwtService) deleteTokenFamily(familyID string) {
+	key := tokenFamilyKeyPrefix + familyID
+	if !useRedisTokenFamily() {
+		global.BlackCache.Delete(key)
+		return
+	}
+	if err := global.GVA_REDIS.Del(context.Background(), key).Err(); err != nil {
+		global.GVA_LOG.Error("删除令牌族失败!", zap.Error(err))
+	}
+}
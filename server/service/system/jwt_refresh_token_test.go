@@ -0,0 +1,87 @@
+package system
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/songzhibin97/gkit/cache/local_cache"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+func setupUserTest(t *testing.T) system.SysUser {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// 内存数据库每个连接相互独立
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&system.SysUser{}, &system.SysAuthority{}, &system.JwtBlacklist{}, &system.SysUserTotp{}, &system.SysUserRecoveryCode{}, &system.SysUserSession{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	global.GVA_DB = db
+	global.GVA_LOG = zap.NewNop()
+	global.GVA_CONFIG.JWT.SigningKey = "test"
+	global.GVA_CONFIG.JWT.ExpiresTime = "1h"
+	global.GVA_CONFIG.JWT.BufferTime = "0s"
+	global.GVA_CONFIG.JWT.RefreshExpiresTime = "7d"
+	global.GVA_CONFIG.JWT.UseRefreshToken = true
+	global.GVA_CONFIG.System.UseMultipoint = false
+	global.BlackCache = local_cache.NewCache(local_cache.SetDefaultExpire(time.Hour))
+
+	user := system.SysUser{UUID: uuid.New(), Username: "test", AuthorityId: 888, Enable: 1}
+	if err = db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func TestJwtService_RotateRefreshToken(t *testing.T) {
+	user := setupUserTest(t)
+	s := JwtServiceApp
+
+	first, err := s.IssueTokenPair(&user)
+	if err != nil {
+		t.Fatalf("IssueTokenPair: %v", err)
+	}
+	second, _, err := s.RotateRefreshToken(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+	if second.Claims.FamilyID != first.Claims.FamilyID {
+		t.Errorf("rotation should keep family %s, got %s", first.Claims.FamilyID, second.Claims.FamilyID)
+	}
+	if _, ok := global.BlackCache.Get(first.Token); !ok {
+		t.Error("previous access token should be blacklisted after rotation")
+	}
+
+	// 重复使用已轮换的刷新令牌 撤销整个令牌族
+	if _, _, err = s.RotateRefreshToken(first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reuse should return ErrRefreshTokenReused, got %v", err)
+	}
+	if _, ok := global.BlackCache.Get(second.Token); !ok {
+		t.Error("current access token should be blacklisted after reuse")
+	}
+	if _, _, err = s.RotateRefreshToken(second.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("revoked family should be invalid, got %v", err)
+	}
+}
+
+func TestJwtService_RotateRefreshToken_AccessToken(t *testing.T) {
+	user := setupUserTest(t)
+	pair, err := JwtServiceApp.IssueTokenPair(&user)
+	if err != nil {
+		t.Fatalf("IssueTokenPair: %v", err)
+	}
+	if _, _, err = JwtServiceApp.RotateRefreshToken(pair.Token); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("access token must not be accepted as refresh token, got %v", err)
+	}
+}
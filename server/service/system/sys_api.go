@@ -9,6 +9,7 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"gorm.io/gorm"
 )
 
@@ -143,7 +144,7 @@ func (apiService *ApiService) EnterSyncApi(syncApis systemRes.SysSyncApis) (err
 			}
 		}
 		for i := range syncApis.DeleteApis {
-			CasbinServiceApp.ClearCasbin(1, syncApis.DeleteApis[i].Path, syncApis.DeleteApis[i].Method)
+			CasbinServiceApp.ClearCasbin(utils.CasbinPathField(), syncApis.DeleteApis[i].Path, syncApis.DeleteApis[i].Method)
 			txErr = tx.Delete(&system.SysApi{}, "path = ? AND method = ?", syncApis.DeleteApis[i].Path, syncApis.DeleteApis[i].Method).Error
 			if txErr != nil {
 				return txErr
@@ -169,7 +170,7 @@ func (apiService *ApiService) DeleteApi(api system.SysApi) (err error) {
 	if err != nil {
 		return err
 	}
-	CasbinServiceApp.ClearCasbin(1, entity.Path, entity.Method)
+	CasbinServiceApp.ClearCasbin(utils.CasbinPathField(), entity.Path, entity.Method)
 	return nil
 }
 
@@ -243,7 +244,7 @@ func (apiService *ApiService) GetAllApis(authorityID uint) (apis []system.SysApi
 	if parentAuthorityID == 0 || !global.GVA_CONFIG.System.UseStrictAuth {
 		return
 	}
-	paths := CasbinServiceApp.GetPolicyPathByAuthorityId(authorityID)
+	paths := CasbinServiceApp.GetPolicyPathByAuthorityId(authorityID, "")
 	// 挑选 apis里面的path和method也在paths里面的api
 	var authApis []system.SysApi
 	for i := range apis {
@@ -319,7 +320,7 @@ func (apiService *ApiService) DeleteApisByIds(ids request.IdsReq) (err error) {
 			return err
 		}
 		for _, sysApi := range apis {
-			CasbinServiceApp.ClearCasbin(1, sysApi.Path, sysApi.Method)
+			CasbinServiceApp.ClearCasbin(utils.CasbinPathField(), sysApi.Path, sysApi.Method)
 		}
 		return err
 	})
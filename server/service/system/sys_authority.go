@@ -10,6 +10,7 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
 	"gorm.io/gorm"
 )
 
@@ -45,7 +46,7 @@ func (authorityService *AuthorityService) CreateAuthority(auth system.SysAuthori
 		authorityId := strconv.Itoa(int(auth.AuthorityId))
 		rules := [][]string{}
 		for _, v := range casbinInfos {
-			rules = append(rules, []string{authorityId, v.Path, v.Method})
+			rules = append(rules, utils.CasbinPolicy(authorityId, "", v.Path, v.Method))
 		}
 		return CasbinServiceApp.AddPolicies(tx, rules)
 	})
@@ -97,8 +98,8 @@ func (authorityService *AuthorityService) CopyAuthority(adminAuthorityID uint, c
 			return
 		}
 	}
-	paths := CasbinServiceApp.GetPolicyPathByAuthorityId(copyInfo.OldAuthorityId)
-	err = CasbinServiceApp.UpdateCasbin(adminAuthorityID, copyInfo.Authority.AuthorityId, paths)
+	paths := CasbinServiceApp.GetPolicyPathByAuthorityId(copyInfo.OldAuthorityId, "")
+	err = CasbinServiceApp.UpdateCasbin(adminAuthorityID, copyInfo.Authority.AuthorityId, "", paths)
 	if err != nil {
 		_ = authorityService.DeleteAuthority(&copyInfo.Authority)
 	}
@@ -118,7 +119,13 @@ func (authorityService *AuthorityService) UpdateAuthority(auth system.SysAuthori
 		global.GVA_LOG.Debug(err.Error())
 		return system.SysAuthority{}, errors.New("查询角色数据失败")
 	}
-	err = global.GVA_DB.Model(&oldAuthority).Updates(&auth).Error
+	// 角色所属的租户不随修改变化
+	err = global.GVA_DB.Model(&oldAuthority).Omit("tenant_id").Updates(&auth).Error
+	if err != nil {
+		return auth, err
+	}
+	// Updates 不会更新零值 单独更新两步验证策略以支持关闭
+	err = global.GVA_DB.Model(&oldAuthority).Update("require_totp", auth.RequireTotp).Error
 	return auth, err
 }
 
@@ -179,11 +186,11 @@ func (authorityService *AuthorityService) DeleteAuthority(auth *system.SysAuthor
 
 //@author: [piexlmax](https://github.com/piexlmax)
 //@function: GetAuthorityInfoList
-//@description: 分页获取数据
-//@param: info request.PageInfo
-//@return: list interface{}, total int64, err error
+//@description: 获取角色树 租户管理员只能获取本租户的角色
+//@param: authorityID uint, tenantID uint
+//@return: list []system.SysAuthority, err error
 
-func (authorityService *AuthorityService) GetAuthorityInfoList(authorityID uint) (list []system.SysAuthority, err error) {
+func (authorityService *AuthorityService) GetAuthorityInfoList(authorityID, tenantID uint) (list []system.SysAuthority, err error) {
 	var authority system.SysAuthority
 	err = global.GVA_DB.Where("authority_id = ?", authorityID).First(&authority).Error
 	if err != nil {
@@ -201,6 +208,9 @@ func (authorityService *AuthorityService) GetAuthorityInfoList(authorityID uint)
 			err = db.Debug().Preload("DataAuthorityId").Where("parent_id = ?", authorityID).Find(&authorities).Error
 		}
 	} else {
+		if tenantID != 0 {
+			db = db.Where("tenant_id = ?", tenantID)
+		}
 		err = db.Preload("DataAuthorityId").Where("parent_id = ?", "0").Find(&authorities).Error
 	}
 
@@ -0,0 +1,418 @@
+package system
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+)
+
+var (
+	ErrGrantNotFound = errors.New("临时授权不存在")
+	ErrGrantStatus   = errors.New("临时授权当前状态不允许该操作")
+	ErrGrantApprover = errors.New("审批人需拥有比授予角色更高级别的角色 且不能审批自己的申请")
+)
+
+// 临时授权审计记录的操作
+const (
+	grantActionCreate   = "create"
+	grantActionApprove  = "approve"
+	grantActionReject   = "reject"
+	grantActionActivate = "activate"
+	grantActionRevoke   = "revoke"
+	grantActionExpire   = "expire"
+)
+
+type AuthorityGrantService struct{}
+
+var AuthorityGrantServiceApp = new(AuthorityGrantService)
+
+//@function: CreateGrant
+//@description: 创建临时授权 无需审批时到开始时间即生效 为自己申请时必须由更高级别角色的用户审批
+//@param: operatorID uint, operatorAuthorityID uint, req systemReq.CreateAuthorityGrant
+//@return: grant system.SysAuthorityGrant, err error
+
+func (grantService *AuthorityGrantService) CreateGrant(operatorID, operatorAuthorityID uint, req systemReq.CreateAuthorityGrant) (grant system.SysAuthorityGrant, err error) {
+	now := time.Now()
+	startAt := now
+	if req.StartAt != nil {
+		startAt = *req.StartAt
+	}
+	if !req.EndAt.After(startAt) || !req.EndAt.After(now) {
+		return grant, errors.New("结束时间需晚于开始时间和当前时间")
+	}
+	if err = global.GVA_DB.Select("id").First(&system.SysUser{}, req.UserID).Error; err != nil {
+		return grant, errors.New("用户不存在")
+	}
+	if err = global.GVA_DB.Select("authority_id").Where("authority_id = ?", req.AuthorityId).First(&system.SysAuthority{}).Error; err != nil {
+		return grant, errors.New("角色不存在")
+	}
+	requireApproval := req.RequireApproval || operatorID == req.UserID
+	if !requireApproval {
+		if err = AuthorityServiceApp.CheckAuthorityIDAuth(operatorAuthorityID, req.AuthorityId); err != nil {
+			return grant, err
+		}
+	}
+	grant = system.SysAuthorityGrant{
+		UserID:          req.UserID,
+		AuthorityId:     req.AuthorityId,
+		StartAt:         startAt,
+		EndAt:           req.EndAt,
+		Reason:          req.Reason,
+		Status:          system.GrantStatusApproved,
+		RequireApproval: requireApproval,
+		GrantedBy:       operatorID,
+	}
+	if requireApproval {
+		grant.Status = system.GrantStatusPending
+	}
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&grant).Error; err != nil {
+			return err
+		}
+		return grantService.log(tx, grant, grantActionCreate, operatorID, req.Reason)
+	})
+	if err != nil {
+		return grant, err
+	}
+	if grant.Status == system.GrantStatusApproved && !grant.StartAt.After(now) {
+		// 定时任务已先行生效时返回最新的授权
+		if err = grantService.activate(&grant, operatorID); errors.Is(err, ErrGrantStatus) {
+			return grantService.getGrant(grant.ID)
+		}
+	}
+	return grant, err
+}
+
+//@function: ReviewGrant
+//@description: 审批临时授权 审批人当前角色需为授予角色的上级角色
+//@param: operatorID uint, operatorAuthorityID uint, req systemReq.ReviewAuthorityGrant
+//@return: err error
+
+func (grantService *AuthorityGrantService) ReviewGrant(operatorID, operatorAuthorityID uint, req systemReq.ReviewAuthorityGrant) error {
+	grant, err := grantService.getGrant(req.ID)
+	if err != nil {
+		return err
+	}
+	if grant.Status != system.GrantStatusPending {
+		return ErrGrantStatus
+	}
+	if operatorID == grant.UserID || operatorID == grant.GrantedBy {
+		return ErrGrantApprover
+	}
+	higher, err := grantService.isHigherAuthority(operatorAuthorityID, grant.AuthorityId)
+	if err != nil {
+		return err
+	}
+	if !higher {
+		return ErrGrantApprover
+	}
+	now := time.Now()
+	action := grantActionReject
+	updates := map[string]interface{}{"status": system.GrantStatusRejected, "approved_by": operatorID, "approved_at": now}
+	if req.Approve {
+		action = grantActionApprove
+		updates["status"] = system.GrantStatusApproved
+	}
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		// 按状态更新 避免重复审批
+		result := tx.Model(&grant).Where("status = ?", system.GrantStatusPending).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrGrantStatus
+		}
+		return grantService.log(tx, grant, action, operatorID, req.Remark)
+	})
+	if err != nil || !req.Approve {
+		return err
+	}
+	if !grant.StartAt.After(now) && grant.EndAt.After(now) {
+		// 定时任务已先行生效时无需处理
+		if err = grantService.activate(&grant, operatorID); !errors.Is(err, ErrGrantStatus) {
+			return err
+		}
+	}
+	return nil
+}
+
+//@function: RevokeGrant
+//@description: 提前撤销临时授权 生效中的授权收回角色并使用户的令牌失效
+//@param: operatorID uint, req systemReq.RevokeAuthorityGrant
+//@return: err error
+
+func (grantService *AuthorityGrantService) RevokeGrant(operatorID uint, req systemReq.RevokeAuthorityGrant) error {
+	grant, err := grantService.getGrant(req.ID)
+	if err != nil {
+		return err
+	}
+	switch grant.Status {
+	case system.GrantStatusActive:
+		return grantService.deactivate(&grant, system.GrantStatusRevoked, grantActionRevoke, operatorID, req.Remark)
+	case system.GrantStatusPending, system.GrantStatusApproved:
+		return grantService.close(&grant, system.GrantStatusRevoked, grantActionRevoke, operatorID, req.Remark)
+	default:
+		return ErrGrantStatus
+	}
+}
+
+//@function: GetGrantList
+//@description: 分页获取临时授权及审计记录
+//@param: info systemReq.GetAuthorityGrantList
+//@return: list []system.SysAuthorityGrant, total int64, err error
+
+func (grantService *AuthorityGrantService) GetGrantList(info systemReq.GetAuthorityGrantList) (list []system.SysAuthorityGrant, total int64, err error) {
+	db := global.GVA_DB.Model(&system.SysAuthorityGrant{})
+	if info.UserID != 0 {
+		db = db.Where("user_id = ?", info.UserID)
+	}
+	if info.AuthorityId != 0 {
+		db = db.Where("authority_id = ?", info.AuthorityId)
+	}
+	if info.Status != "" {
+		db = db.Where("status = ?", info.Status)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Scopes(info.Paginate()).Order("id desc").
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username", "nick_name") }).
+		Preload("Authority", func(db *gorm.DB) *gorm.DB { return db.Select("authority_id", "authority_name") }).
+		Preload("Logs", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Find(&list).Error
+	return
+}
+
+//@function: ProcessGrants
+//@description: 定时任务调用 到开始时间的授权生效 到结束时间的授权收回 未审批即到期的授权关闭
+//@param: now time.Time
+//@return: activated int, expired int, err error
+
+func (grantService *AuthorityGrantService) ProcessGrants(now time.Time) (activated, expired int, err error) {
+	var due []system.SysAuthorityGrant
+	err = global.GVA_DB.Where("status = ? AND start_at <= ? AND end_at > ?", system.GrantStatusApproved, now, now).Find(&due).Error
+	if err != nil {
+		return
+	}
+	for i := range due {
+		// 状态已被审批、撤销等并发操作修改的授权跳过
+		if err = grantService.activate(&due[i], 0); errors.Is(err, ErrGrantStatus) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		activated++
+	}
+	var ended []system.SysAuthorityGrant
+	err = global.GVA_DB.Where("status IN ? AND end_at <= ?", []string{system.GrantStatusPending, system.GrantStatusApproved, system.GrantStatusActive}, now).Find(&ended).Error
+	if err != nil {
+		return
+	}
+	for i := range ended {
+		if ended[i].Status == system.GrantStatusActive {
+			err = grantService.deactivate(&ended[i], system.GrantStatusExpired, grantActionExpire, 0, "")
+		} else {
+			err = grantService.close(&ended[i], system.GrantStatusExpired, grantActionExpire, 0, "")
+		}
+		if errors.Is(err, ErrGrantStatus) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		expired++
+	}
+	return
+}
+
+// retainActiveGrants 重新分配用户角色后保留生效中的临时授权 新分配的角色中已包含授予角色时 收回时不再删除
+func (grantService *AuthorityGrantService) retainActiveGrants(tx *gorm.DB, userID uint, authorityIds []uint) error {
+	var grants []system.SysAuthorityGrant
+	if err := tx.Where("user_id = ? AND status = ?", userID, system.GrantStatusActive).Find(&grants).Error; err != nil {
+		return err
+	}
+	for _, grant := range grants {
+		assigned := true
+		for _, id := range authorityIds {
+			if id == grant.AuthorityId {
+				assigned = false
+				break
+			}
+		}
+		if assigned {
+			if err := tx.Create(&system.SysUserAuthority{SysUserId: userID, SysAuthorityAuthorityId: grant.AuthorityId}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&grant).Update("assigned", assigned).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activate 授权生效 用户尚未拥有该角色时新增用户角色 授权已不是已审批状态时返回 ErrGrantStatus
+func (grantService *AuthorityGrantService) activate(grant *system.SysAuthorityGrant, operatorID uint) error {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		// 先按状态更新 并发生效时只有一方成功 另一方不再改动用户角色
+		err := grantService.transition(tx, grant, system.GrantStatusApproved, map[string]interface{}{"status": system.GrantStatusActive})
+		if err != nil {
+			return err
+		}
+		var count int64
+		err = tx.Model(&system.SysUserAuthority{}).Where("sys_user_id = ? AND sys_authority_authority_id = ?", grant.UserID, grant.AuthorityId).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			if err = tx.Create(&system.SysUserAuthority{SysUserId: grant.UserID, SysAuthorityAuthorityId: grant.AuthorityId}).Error; err != nil {
+				return err
+			}
+		}
+		if err = tx.Model(&system.SysAuthorityGrant{}).Where("id = ?", grant.ID).Update("assigned", count == 0).Error; err != nil {
+			return err
+		}
+		grant.Status = system.GrantStatusActive
+		grant.Assigned = count == 0
+		global.GVA_LOG.Info("临时授权生效", zap.Uint("grantId", grant.ID), zap.Uint("userId", grant.UserID), zap.Uint("authorityId", grant.AuthorityId))
+		return grantService.log(tx, *grant, grantActionActivate, operatorID, "")
+	})
+}
+
+// deactivate 收回生效中的授权 删除授权新增的用户角色 并使用户的令牌失效
+func (grantService *AuthorityGrantService) deactivate(grant *system.SysAuthorityGrant, status, action string, operatorID uint, remark string) error {
+	removed := false
+	err := global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		// 先按状态更新 撤销与到期并发时只有一方收回角色
+		if err := grantService.finish(tx, grant, system.GrantStatusActive, status, action, operatorID, remark); err != nil {
+			return err
+		}
+		// 生效期间重新分配角色可能修改了是否由授权新增 以状态更新后的值为准
+		var current system.SysAuthorityGrant
+		if err := tx.Select("assigned").First(&current, grant.ID).Error; err != nil {
+			return err
+		}
+		grant.Assigned = current.Assigned
+		if !grant.Assigned {
+			return nil
+		}
+		// 同一角色还有其他生效中的授权时 由其接管新增的用户角色
+		var other system.SysAuthorityGrant
+		err := tx.Select("id").Where("user_id = ? AND authority_id = ? AND status = ? AND id <> ?", grant.UserID, grant.AuthorityId, system.GrantStatusActive, grant.ID).
+			First(&other).Error
+		if err == nil {
+			return tx.Model(&other).Update("assigned", true).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		removed = true
+		err = tx.Where("sys_user_id = ? AND sys_authority_authority_id = ?", grant.UserID, grant.AuthorityId).Delete(&system.SysUserAuthority{}).Error
+		if err != nil {
+			return err
+		}
+		// 当前使用的角色被收回时切换到剩余的第一个角色
+		var remaining system.SysUserAuthority
+		err = tx.Where("sys_user_id = ?", grant.UserID).Take(&remaining).Error
+		if err == nil {
+			err = tx.Model(&system.SysUser{}).Where("id = ? AND authority_id = ?", grant.UserID, grant.AuthorityId).
+				Update("authority_id", remaining.SysAuthorityAuthorityId).Error
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return nil
+	})
+	if err != nil || !removed {
+		return err
+	}
+	// 令牌中携带签发时的角色 需重新登录
+	if err = UserSessionServiceApp.RevokeUserSessions(grant.UserID); err != nil {
+		global.GVA_LOG.Error("临时授权收回后撤销用户会话失败!", zap.Uint("userId", grant.UserID), zap.Error(err))
+	}
+	return nil
+}
+
+// close 关闭尚未生效的授权
+func (grantService *AuthorityGrantService) close(grant *system.SysAuthorityGrant, status, action string, operatorID uint, remark string) error {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		return grantService.finish(tx, grant, grant.Status, status, action, operatorID, remark)
+	})
+}
+
+// finish 将状态为from的授权结束为status 并写入审计记录
+func (grantService *AuthorityGrantService) finish(tx *gorm.DB, grant *system.SysAuthorityGrant, from, status, action string, operatorID uint, remark string) error {
+	now := time.Now()
+	err := grantService.transition(tx, grant, from, map[string]interface{}{"status": status, "revoked_by": operatorID, "revoked_at": now})
+	if err != nil {
+		return err
+	}
+	grant.Status = status
+	grant.RevokedBy = operatorID
+	grant.RevokedAt = &now
+	global.GVA_LOG.Info("临时授权收回", zap.Uint("grantId", grant.ID), zap.Uint("userId", grant.UserID), zap.Uint("authorityId", grant.AuthorityId), zap.String("action", action), zap.Uint("operatorId", operatorID))
+	return grantService.log(tx, *grant, action, operatorID, remark)
+}
+
+// transition 仅在授权仍为from状态时更新 已被并发的操作修改时返回 ErrGrantStatus 由调用方回滚事务
+func (grantService *AuthorityGrantService) transition(tx *gorm.DB, grant *system.SysAuthorityGrant, from string, updates map[string]interface{}) error {
+	result := tx.Model(&system.SysAuthorityGrant{}).Where("id = ? AND status = ?", grant.ID, from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrGrantStatus
+	}
+	return nil
+}
+
+// log 写入审计记录
+func (grantService *AuthorityGrantService) log(tx *gorm.DB, grant system.SysAuthorityGrant, action string, operatorID uint, remark string) error {
+	return tx.Create(&system.SysAuthorityGrantLog{
+		GrantID:     grant.ID,
+		UserID:      grant.UserID,
+		AuthorityId: grant.AuthorityId,
+		Action:      action,
+		OperatorID:  operatorID,
+		Remark:      remark,
+	}).Error
+}
+
+func (grantService *AuthorityGrantService) getGrant(id uint) (grant system.SysAuthorityGrant, err error) {
+	err = global.GVA_DB.First(&grant, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrGrantNotFound
+	}
+	return
+}
+
+// isHigherAuthority authorityID是否为targetID的上级角色
+func (grantService *AuthorityGrantService) isHigherAuthority(authorityID, targetID uint) (bool, error) {
+	current := targetID
+	// 角色树深度有限 防止数据异常时出现环
+	for i := 0; i < 32; i++ {
+		var authority system.SysAuthority
+		err := global.GVA_DB.Select("authority_id", "parent_id").Where("authority_id = ?", current).First(&authority).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if authority.ParentId == nil || *authority.ParentId == 0 {
+			return false, nil
+		}
+		if *authority.ParentId == authorityID {
+			return true, nil
+		}
+		current = *authority.ParentId
+	}
+	return false, nil
+}
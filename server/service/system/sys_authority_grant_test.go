@@ -0,0 +1,142 @@
+package system
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+func TestAuthorityGrantService(t *testing.T) {
+	user := setupUserTest(t)
+	db := global.GVA_DB
+	if err := db.AutoMigrate(&system.SysAuthorityGrant{}, &system.SysAuthorityGrantLog{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	// 888 > 8881 9528 独立
+	db.Create(&[]system.SysAuthority{
+		{AuthorityId: 888, AuthorityName: "admin", ParentId: utils.Pointer[uint](0)},
+		{AuthorityId: 8881, AuthorityName: "child", ParentId: utils.Pointer[uint](888)},
+		{AuthorityId: 9528, AuthorityName: "test", ParentId: utils.Pointer[uint](0)},
+	})
+	admin := system.SysUser{Username: "admin", AuthorityId: 888, Enable: 1}
+	db.Create(&admin)
+	db.Create(&system.SysUserAuthority{SysUserId: user.ID, SysAuthorityAuthorityId: 888})
+	db.Create(&system.SysUserSession{SessionID: "s1", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	service := AuthorityGrantServiceApp
+	userAuthorities := func() (ids []uint) {
+		db.Model(&system.SysUserAuthority{}).Where("sys_user_id = ?", user.ID).Order("sys_authority_authority_id").Pluck("sys_authority_authority_id", &ids)
+		return
+	}
+
+	// 无需审批的授权立即生效
+	direct, err := service.CreateGrant(admin.ID, 888, systemReq.CreateAuthorityGrant{UserID: user.ID, AuthorityId: 8881, EndAt: time.Now().Add(time.Hour)})
+	if err != nil || direct.Status != system.GrantStatusActive || !direct.Assigned {
+		t.Fatalf("CreateGrant = %+v, %v", direct, err)
+	}
+	// 重新分配角色时保留生效中的临时授权
+	if err = UserServiceApp.SetUserAuthorities(888, user.ID, []uint{888}); err != nil {
+		t.Fatalf("SetUserAuthorities: %v", err)
+	}
+	if ids := userAuthorities(); len(ids) != 2 || ids[1] != 8881 {
+		t.Fatalf("authorities after SetUserAuthorities = %v", ids)
+	}
+
+	// 为自己申请必须审批 审批人需为上级角色
+	pending, err := service.CreateGrant(user.ID, 888, systemReq.CreateAuthorityGrant{UserID: user.ID, AuthorityId: 9528, EndAt: time.Now().Add(time.Hour)})
+	if err != nil || pending.Status != system.GrantStatusPending {
+		t.Fatalf("self request = %+v, %v", pending, err)
+	}
+	if err = service.ReviewGrant(admin.ID, 888, systemReq.ReviewAuthorityGrant{ID: pending.ID, Approve: true}); !errors.Is(err, ErrGrantApprover) {
+		t.Errorf("approve without higher authority = %v, want ErrGrantApprover", err)
+	}
+	if err = service.ReviewGrant(admin.ID, 888, systemReq.ReviewAuthorityGrant{ID: pending.ID}); !errors.Is(err, ErrGrantApprover) {
+		t.Errorf("reject without higher authority = %v, want ErrGrantApprover", err)
+	}
+	request, _ := service.CreateGrant(user.ID, 888, systemReq.CreateAuthorityGrant{UserID: user.ID, AuthorityId: 8881, EndAt: time.Now().Add(time.Hour)})
+	if err = service.ReviewGrant(user.ID, 888, systemReq.ReviewAuthorityGrant{ID: request.ID, Approve: true}); !errors.Is(err, ErrGrantApprover) {
+		t.Errorf("approve own request = %v, want ErrGrantApprover", err)
+	}
+	if err = service.ReviewGrant(admin.ID, 888, systemReq.ReviewAuthorityGrant{ID: request.ID, Approve: true}); err != nil {
+		t.Fatalf("ReviewGrant: %v", err)
+	}
+	if err = service.RevokeGrant(admin.ID, systemReq.RevokeAuthorityGrant{ID: pending.ID}); err != nil {
+		t.Errorf("revoke pending grant: %v", err)
+	}
+
+	// 同一角色的授权先撤销时 由仍在生效的授权接管角色
+	if err = service.RevokeGrant(admin.ID, systemReq.RevokeAuthorityGrant{ID: direct.ID}); err != nil {
+		t.Fatalf("RevokeGrant: %v", err)
+	}
+	if ids := userAuthorities(); len(ids) != 2 {
+		t.Fatalf("authorities after revoking one of two grants = %v", ids)
+	}
+
+	// 到期收回授权新增的角色 切换当前角色并撤销会话
+	db.Model(&system.SysUser{}).Where("id = ?", user.ID).Update("authority_id", 8881)
+	activated, expired, err := service.ProcessGrants(time.Now().Add(2 * time.Hour))
+	if err != nil || activated != 0 || expired != 1 {
+		t.Fatalf("ProcessGrants = %d, %d, %v", activated, expired, err)
+	}
+	if ids := userAuthorities(); len(ids) != 1 || ids[0] != 888 {
+		t.Errorf("authorities after expiry = %v", ids)
+	}
+	var current system.SysUser
+	db.First(&current, user.ID)
+	if current.AuthorityId != 888 {
+		t.Errorf("current authority = %d, want 888", current.AuthorityId)
+	}
+	var session system.SysUserSession
+	db.Where("session_id = ?", "s1").First(&session)
+	if session.RevokedAt == nil {
+		t.Error("session should be revoked after the grant expired")
+	}
+
+	list, total, err := service.GetGrantList(systemReq.GetAuthorityGrantList{UserID: user.ID})
+	if err != nil || total != 3 {
+		t.Fatalf("GetGrantList = %d, %v", total, err)
+	}
+	for _, grant := range list {
+		if grant.ID != direct.ID {
+			continue
+		}
+		var actions []string
+		for _, log := range grant.Logs {
+			actions = append(actions, log.Action)
+		}
+		if len(actions) != 3 || actions[0] != grantActionCreate || actions[1] != grantActionActivate || actions[2] != grantActionRevoke {
+			t.Errorf("audit log = %v", actions)
+		}
+	}
+
+	// 审批与定时任务并发生效 以及撤销与到期并发收回时 只有一方修改用户角色
+	raced, _ := service.CreateGrant(user.ID, 888, systemReq.CreateAuthorityGrant{UserID: user.ID, AuthorityId: 9528, EndAt: time.Now().Add(time.Hour)})
+	db.Model(&raced).Update("status", system.GrantStatusApproved)
+	first, second := raced, raced
+	first.Status, second.Status = system.GrantStatusApproved, system.GrantStatusApproved
+	if err = service.activate(&first, admin.ID); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	if err = service.activate(&second, 0); !errors.Is(err, ErrGrantStatus) {
+		t.Errorf("concurrent activate = %v, want ErrGrantStatus", err)
+	}
+	db.First(&raced, raced.ID)
+	if raced.Status != system.GrantStatusActive || !raced.Assigned {
+		t.Errorf("grant after concurrent activate = %+v", raced)
+	}
+	first, second = raced, raced
+	if err = service.deactivate(&first, system.GrantStatusRevoked, grantActionRevoke, admin.ID, ""); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	db.Create(&system.SysUserAuthority{SysUserId: user.ID, SysAuthorityAuthorityId: 9528})
+	if err = service.deactivate(&second, system.GrantStatusExpired, grantActionExpire, 0, ""); !errors.Is(err, ErrGrantStatus) {
+		t.Errorf("concurrent deactivate = %v, want ErrGrantStatus", err)
+	}
+	if ids := userAuthorities(); len(ids) != 2 || ids[1] != 9528 {
+		t.Errorf("losing deactivate should not touch user authorities, got %v", ids)
+	}
+}
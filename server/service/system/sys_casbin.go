@@ -2,6 +2,7 @@ package system
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 
 	"gorm.io/gorm"
@@ -15,15 +16,15 @@ import (
 
 //@author: [piexlmax](https://github.com/piexlmax)
 //@function: UpdateCasbin
-//@description: 更新casbin权限
-//@param: authorityId string, casbinInfos []request.CasbinInfo
+//@description: 更新casbin权限 开启域时只替换该域的策略 domain为空时为对所有域生效的策略
+//@param: adminAuthorityID uint, AuthorityID uint, domain string, casbinInfos []request.CasbinInfo
 //@return: error
 
 type CasbinService struct{}
 
 var CasbinServiceApp = new(CasbinService)
 
-func (casbinService *CasbinService) UpdateCasbin(adminAuthorityID, AuthorityID uint, casbinInfos []request.CasbinInfo) error {
+func (casbinService *CasbinService) UpdateCasbin(adminAuthorityID, AuthorityID uint, domain string, casbinInfos []request.CasbinInfo) error {
 
 	err := AuthorityServiceApp.CheckAuthorityIDAuth(adminAuthorityID, AuthorityID)
 	if err != nil {
@@ -51,7 +52,7 @@ func (casbinService *CasbinService) UpdateCasbin(adminAuthorityID, AuthorityID u
 	}
 
 	authorityId := strconv.Itoa(int(AuthorityID))
-	casbinService.ClearCasbin(0, authorityId)
+	casbinService.ClearCasbin(0, utils.CasbinPolicyFilter(authorityId, domain)...)
 	rules := [][]string{}
 	//做权限去重处理
 	deduplicateMap := make(map[string]bool)
@@ -59,7 +60,7 @@ func (casbinService *CasbinService) UpdateCasbin(adminAuthorityID, AuthorityID u
 		key := authorityId + v.Path + v.Method
 		if _, ok := deduplicateMap[key]; !ok {
 			deduplicateMap[key] = true
-			rules = append(rules, []string{authorityId, v.Path, v.Method})
+			rules = append(rules, utils.CasbinPolicy(authorityId, domain, v.Path, v.Method))
 		}
 	}
 	if len(rules) == 0 {
@@ -80,32 +81,39 @@ func (casbinService *CasbinService) UpdateCasbin(adminAuthorityID, AuthorityID u
 //@return: error
 
 func (casbinService *CasbinService) UpdateCasbinApi(oldPath string, newPath string, oldMethod string, newMethod string) error {
-	err := global.GVA_DB.Model(&gormadapter.CasbinRule{}).Where("v1 = ? AND v2 = ?", oldPath, oldMethod).Updates(map[string]interface{}{
-		"v1": newPath,
-		"v2": newMethod,
+	pathColumn := fmt.Sprintf("v%d", utils.CasbinPathField())
+	methodColumn := fmt.Sprintf("v%d", utils.CasbinPathField()+1)
+	err := global.GVA_DB.Model(&gormadapter.CasbinRule{}).Where("ptype = 'p' AND "+pathColumn+" = ? AND "+methodColumn+" = ?", oldPath, oldMethod).Updates(map[string]interface{}{
+		pathColumn:   newPath,
+		methodColumn: newMethod,
 	}).Error
 	if err != nil {
 		return err
 	}
 
 	e := utils.GetCasbin()
-	return e.LoadPolicy()
+	if err = e.LoadPolicy(); err != nil {
+		return err
+	}
+	utils.NotifyCasbinReload()
+	return nil
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
 //@function: GetPolicyPathByAuthorityId
-//@description: 获取权限列表
-//@param: authorityId string
+//@description: 获取角色自身的权限列表 不含继承的权限 开启域时domain为空获取对所有域生效的权限
+//@param: AuthorityID uint, domain string
 //@return: pathMaps []request.CasbinInfo
 
-func (casbinService *CasbinService) GetPolicyPathByAuthorityId(AuthorityID uint) (pathMaps []request.CasbinInfo) {
+func (casbinService *CasbinService) GetPolicyPathByAuthorityId(AuthorityID uint, domain string) (pathMaps []request.CasbinInfo) {
 	e := utils.GetCasbin()
 	authorityId := strconv.Itoa(int(AuthorityID))
-	list, _ := e.GetFilteredPolicy(0, authorityId)
+	field := utils.CasbinPathField()
+	list, _ := e.GetFilteredPolicy(0, utils.CasbinPolicyFilter(authorityId, domain)...)
 	for _, v := range list {
 		pathMaps = append(pathMaps, request.CasbinInfo{
-			Path:   v[1],
-			Method: v[2],
+			Path:   v[field],
+			Method: v[field+1],
 		})
 	}
 	return pathMaps
@@ -156,18 +164,29 @@ func (casbinService *CasbinService) SyncPolicy(db *gorm.DB, authorityId string,
 func (casbinService *CasbinService) AddPolicies(db *gorm.DB, rules [][]string) error {
 	var casbinRules []gormadapter.CasbinRule
 	for i := range rules {
-		casbinRules = append(casbinRules, gormadapter.CasbinRule{
-			Ptype: "p",
-			V0:    rules[i][0],
-			V1:    rules[i][1],
-			V2:    rules[i][2],
-		})
+		rule := gormadapter.CasbinRule{Ptype: "p"}
+		for j, v := range []*string{&rule.V0, &rule.V1, &rule.V2, &rule.V3} {
+			if j < len(rules[i]) {
+				*v = rules[i][j]
+			}
+		}
+		casbinRules = append(casbinRules, rule)
 	}
 	return db.Create(&casbinRules).Error
 }
 
+//@function: FreshCasbin
+//@description: 迁移策略格式并按角色树同步继承关系后 重新加载全部策略 并通知其他节点重新加载
+//@return: err error
+
 func (casbinService *CasbinService) FreshCasbin() (err error) {
 	e := utils.GetCasbin()
-	err = e.LoadPolicy()
-	return err
+	if err = utils.SyncCasbinRules(global.GVA_DB); err != nil {
+		return err
+	}
+	if err = e.LoadPolicy(); err != nil {
+		return err
+	}
+	utils.NotifyCasbinReload()
+	return nil
 }
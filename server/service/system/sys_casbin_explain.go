@@ -0,0 +1,152 @@
+package system
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/casbin/casbin/v2/util"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+// DataPermissionExplainer 返回用户以该角色访问时生效的行、字段权限 由数据权限插件注册
+type DataPermissionExplainer func(userID, authorityID uint, authorityIds []uint) (interface{}, error)
+
+var (
+	dataPermissionExplainerMu sync.RWMutex
+	dataPermissionExplainer   DataPermissionExplainer
+)
+
+// RegisterDataPermissionExplainer 注册数据权限说明 权限模拟结果中附带生效的数据权限
+func RegisterDataPermissionExplainer(explainer DataPermissionExplainer) {
+	dataPermissionExplainerMu.Lock()
+	defer dataPermissionExplainerMu.Unlock()
+	dataPermissionExplainer = explainer
+}
+
+//@function: ExplainPermission
+//@description: 模拟用户或角色访问接口 说明放行或拒绝的原因 并返回角色可见的菜单、按钮及生效的数据权限
+//@param: info request.PermissionExplain
+//@return: res systemRes.PermissionExplain, err error
+
+func (casbinService *CasbinService) ExplainPermission(info request.PermissionExplain) (res systemRes.PermissionExplain, err error) {
+	res.UserID = info.UserID
+	res.AuthorityId = info.AuthorityId
+	res.Path = strings.TrimPrefix(info.Path, global.GVA_CONFIG.System.RouterPrefix)
+	res.Method = strings.ToUpper(info.Method)
+
+	var tenantID uint
+	authorityIds := []uint{info.AuthorityId}
+	if info.UserID != 0 {
+		var user system.SysUser
+		if err = global.GVA_DB.Preload("Authorities").First(&user, info.UserID).Error; err != nil {
+			return res, errors.New("用户不存在")
+		}
+		if res.AuthorityId == 0 {
+			res.AuthorityId = user.AuthorityId
+		}
+		authorityIds = authorityIds[:0]
+		owned := false
+		for _, authority := range user.Authorities {
+			authorityIds = append(authorityIds, authority.AuthorityId)
+			owned = owned || authority.AuthorityId == res.AuthorityId
+		}
+		if !owned {
+			return res, errors.New("用户未拥有该角色")
+		}
+		tenantID = user.TenantID
+	}
+	var authority system.SysAuthority
+	if err = global.GVA_DB.Where("authority_id = ?", res.AuthorityId).First(&authority).Error; err != nil {
+		return res, errors.New("角色不存在")
+	}
+	res.AuthorityName = authority.AuthorityName
+	if info.UserID == 0 {
+		tenantID = authority.TenantID
+	}
+	res.Domain = utils.TenantDomain(tenantID)
+
+	sub := strconv.Itoa(int(res.AuthorityId))
+	if res.Path != "" && res.Method != "" {
+		res.Allowed, res.Policy, err = utils.CasbinExplain(sub, res.Domain, res.Path, res.Method)
+		if err != nil {
+			return res, err
+		}
+		if res.ApiRegistered, err = casbinService.apiRegistered(res.Path, res.Method); err != nil {
+			return res, err
+		}
+		switch {
+		case res.Allowed && len(res.Policy) > 0 && res.Policy[0] != sub:
+			res.Reason = "继承角色 " + res.Policy[0] + " 的接口权限"
+		case res.Allowed:
+			res.Reason = "角色拥有该接口权限"
+		case !res.ApiRegistered:
+			res.Reason = "接口未在API管理中登记, 无法为角色授权"
+		default:
+			res.Reason = "角色未被授予该接口权限"
+		}
+	}
+
+	if res.Menus, err = casbinService.permissionMenus(res.AuthorityId); err != nil {
+		return res, err
+	}
+
+	dataPermissionExplainerMu.RLock()
+	explainer := dataPermissionExplainer
+	dataPermissionExplainerMu.RUnlock()
+	if explainer != nil {
+		if res.DataPermission, err = explainer(info.UserID, res.AuthorityId, authorityIds); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// apiRegistered 接口是否已登记 登记的路径可以带参数
+func (casbinService *CasbinService) apiRegistered(path, method string) (bool, error) {
+	var apis []system.SysApi
+	if err := global.GVA_DB.Select("path").Where("method = ?", method).Find(&apis).Error; err != nil {
+		return false, err
+	}
+	for _, api := range apis {
+		if util.KeyMatch2(path, api.Path) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// permissionMenus 按菜单树的顺序展开角色可见的菜单及按钮
+func (casbinService *CasbinService) permissionMenus(authorityId uint) ([]systemRes.PermissionMenu, error) {
+	tree, err := MenuServiceApp.GetMenuTree(authorityId)
+	if err != nil {
+		return nil, err
+	}
+	menus := []systemRes.PermissionMenu{}
+	var walk func([]system.SysMenu)
+	walk = func(list []system.SysMenu) {
+		for _, menu := range list {
+			btns := make([]string, 0, len(menu.Btns))
+			for name := range menu.Btns {
+				btns = append(btns, name)
+			}
+			sort.Strings(btns)
+			menus = append(menus, systemRes.PermissionMenu{
+				ID:       menu.MenuId,
+				ParentId: menu.ParentId,
+				Name:     menu.Name,
+				Title:    menu.Title,
+				Btns:     btns,
+			})
+			walk(menu.Children)
+		}
+	}
+	walk(tree)
+	return menus, nil
+}
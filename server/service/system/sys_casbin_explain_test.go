@@ -0,0 +1,67 @@
+package system
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+func TestCasbinService_ExplainPermission(t *testing.T) {
+	user := setupUserTest(t)
+	db := global.GVA_DB
+	if err := db.AutoMigrate(&system.SysApi{}, &system.SysBaseMenu{}, &system.SysBaseMenuParameter{}, &system.SysBaseMenuBtn{}, &system.SysAuthorityBtn{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	authority := system.SysAuthority{AuthorityId: 888, AuthorityName: "admin", ParentId: utils.Pointer[uint](0)}
+	db.Create(&authority)
+	_ = db.Model(&user).Association("Authorities").Append(&authority)
+	db.Create(&[]system.SysApi{
+		{Path: "/explain/:id", Method: "GET"},
+		{Path: "/explain/delete", Method: "DELETE"},
+	})
+	menu := system.SysBaseMenu{Name: "explain", Meta: system.Meta{Title: "权限模拟"}}
+	db.Create(&menu)
+	db.Create(&system.SysAuthorityMenu{MenuId: "1", AuthorityId: "888"})
+	btn := system.SysBaseMenuBtn{Name: "add", SysBaseMenuID: menu.ID}
+	db.Create(&btn)
+	db.Create(&system.SysAuthorityBtn{AuthorityId: 888, SysMenuID: menu.ID, SysBaseMenuBtnID: btn.ID})
+	if _, err := utils.GetCasbin().AddPolicies([][]string{{"888", "/explain/:id", "GET"}}); err != nil {
+		t.Fatalf("add policies: %v", err)
+	}
+	RegisterDataPermissionExplainer(func(userID, authorityID uint, authorityIds []uint) (interface{}, error) {
+		return map[string]interface{}{"userId": userID, "authorityIds": authorityIds}, nil
+	})
+	t.Cleanup(func() { RegisterDataPermissionExplainer(nil) })
+
+	service := CasbinServiceApp
+	res, err := service.ExplainPermission(request.PermissionExplain{UserID: user.ID, Path: "/explain/1", Method: "get"})
+	if err != nil {
+		t.Fatalf("ExplainPermission: %v", err)
+	}
+	if !res.Allowed || !reflect.DeepEqual(res.Policy, []string{"888", "/explain/:id", "GET"}) || res.AuthorityName != "admin" {
+		t.Errorf("allowed result = %+v", res)
+	}
+	if len(res.Menus) != 1 || res.Menus[0].Title != "权限模拟" || !reflect.DeepEqual(res.Menus[0].Btns, []string{"add"}) {
+		t.Errorf("menus = %+v", res.Menus)
+	}
+	if data, ok := res.DataPermission.(map[string]interface{}); !ok || !reflect.DeepEqual(data["authorityIds"], []uint{888}) {
+		t.Errorf("data permission = %+v", res.DataPermission)
+	}
+
+	// 拒绝时区分接口未登记和角色未授权
+	res, _ = service.ExplainPermission(request.PermissionExplain{AuthorityId: 888, Path: "/explain/delete", Method: "DELETE"})
+	if res.Allowed || !res.ApiRegistered || res.Reason != "角色未被授予该接口权限" {
+		t.Errorf("unauthorized result = %+v", res)
+	}
+	res, _ = service.ExplainPermission(request.PermissionExplain{AuthorityId: 888, Path: "/explain/unknown", Method: "POST"})
+	if res.Allowed || res.ApiRegistered {
+		t.Errorf("unregistered result = %+v", res)
+	}
+	if _, err = service.ExplainPermission(request.PermissionExplain{UserID: user.ID, AuthorityId: 9528}); err == nil {
+		t.Error("user without the authority should be rejected")
+	}
+}
@@ -0,0 +1,169 @@
+package system
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+type DeptService struct{}
+
+var DeptServiceApp = new(DeptService)
+
+//@function: CreateDept
+//@description: 创建部门
+//@param: dept *system.SysDept
+//@return: err error
+
+func (deptService *DeptService) CreateDept(dept *system.SysDept) (err error) {
+	if dept.ParentId != 0 {
+		if err = global.GVA_DB.First(&system.SysDept{}, dept.ParentId).Error; err != nil {
+			return errors.New("父部门不存在")
+		}
+	}
+	return global.GVA_DB.Create(dept).Error
+}
+
+//@function: DeleteDept
+//@description: 删除部门 存在子部门或部门下仍有用户时不允许删除
+//@param: id uint
+//@return: err error
+
+func (deptService *DeptService) DeleteDept(id uint) (err error) {
+	var count int64
+	if err = global.GVA_DB.Model(&system.SysDept{}).Where("parent_id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("存在子部门, 不允许删除")
+	}
+	if err = global.GVA_DB.Model(&system.SysUser{}).Where("dept_id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("部门下存在用户, 不允许删除")
+	}
+	return global.GVA_DB.Delete(&system.SysDept{}, id).Error
+}
+
+//@function: UpdateDept
+//@description: 更新部门 父部门不能为自身或其下级部门
+//@param: dept system.SysDept
+//@return: err error
+
+func (deptService *DeptService) UpdateDept(dept system.SysDept) (err error) {
+	var old system.SysDept
+	if err = global.GVA_DB.First(&old, dept.ID).Error; err != nil {
+		return errors.New("部门不存在")
+	}
+	if dept.ParentId != 0 {
+		childIds, err := deptService.GetDeptAndChildIds(dept.ID)
+		if err != nil {
+			return err
+		}
+		for _, id := range childIds {
+			if id == dept.ParentId {
+				return errors.New("父部门不能为自身或下级部门")
+			}
+		}
+		if err = global.GVA_DB.First(&system.SysDept{}, dept.ParentId).Error; err != nil {
+			return errors.New("父部门不存在")
+		}
+	}
+	return global.GVA_DB.Model(&old).Select("parent_id", "dept_name", "leader", "phone", "email", "sort", "enable").Updates(&dept).Error
+}
+
+//@function: GetDeptById
+//@description: 根据ID获取部门
+//@param: id uint
+//@return: dept system.SysDept, err error
+
+func (deptService *DeptService) GetDeptById(id uint) (dept system.SysDept, err error) {
+	err = global.GVA_DB.First(&dept, id).Error
+	return
+}
+
+//@function: GetDeptTree
+//@description: 获取部门树
+//@return: tree []system.SysDept, err error
+
+func (deptService *DeptService) GetDeptTree() (tree []system.SysDept, err error) {
+	var all []system.SysDept
+	if err = global.GVA_DB.Order("sort").Order("id").Find(&all).Error; err != nil {
+		return nil, err
+	}
+	treeMap := make(map[uint][]system.SysDept)
+	exist := make(map[uint]bool, len(all))
+	for _, d := range all {
+		exist[d.ID] = true
+	}
+	for _, d := range all {
+		parent := d.ParentId
+		// 父部门已被删除的部门挂到根节点 避免数据丢失
+		if !exist[parent] {
+			parent = 0
+		}
+		treeMap[parent] = append(treeMap[parent], d)
+	}
+	tree = treeMap[0]
+	for i := range tree {
+		deptService.getChildrenList(&tree[i], treeMap)
+	}
+	return tree, nil
+}
+
+func (deptService *DeptService) getChildrenList(dept *system.SysDept, treeMap map[uint][]system.SysDept) {
+	dept.Children = treeMap[dept.ID]
+	for i := range dept.Children {
+		deptService.getChildrenList(&dept.Children[i], treeMap)
+	}
+}
+
+//@function: GetDeptAndChildIds
+//@description: 获取部门及全部下级部门ID 在应用层遍历 不依赖数据库递归语法
+//@param: id uint
+//@return: ids []uint, err error
+
+func (deptService *DeptService) GetDeptAndChildIds(id uint) (ids []uint, err error) {
+	return DeptAndChildIds(global.GVA_DB, id)
+}
+
+//@function: SetUserDept
+//@description: 设置用户所属部门 deptId为0时清除
+//@param: userId uint, deptId uint
+//@return: err error
+
+func (deptService *DeptService) SetUserDept(userId uint, deptId uint) (err error) {
+	if deptId != 0 {
+		if err = global.GVA_DB.First(&system.SysDept{}, deptId).Error; err != nil {
+			return errors.New("部门不存在")
+		}
+	}
+	return global.GVA_DB.Model(&system.SysUser{}).Where("id = ?", userId).Update("dept_id", deptId).Error
+}
+
+// DeptAndChildIds 使用指定db获取部门及全部下级部门ID
+func DeptAndChildIds(db *gorm.DB, id uint) (ids []uint, err error) {
+	var all []system.SysDept
+	if err = db.Model(&system.SysDept{}).Select("id", "parent_id").Find(&all).Error; err != nil {
+		return nil, err
+	}
+	children := make(map[uint][]uint)
+	for _, d := range all {
+		children[d.ParentId] = append(children[d.ParentId], d.ID)
+	}
+	visited := map[uint]bool{id: true}
+	ids = []uint{id}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !visited[child] {
+				visited[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,55 @@
+package system
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+func TestDeptService_Tree(t *testing.T) {
+	setupUserTest(t)
+	if err := global.GVA_DB.AutoMigrate(&system.SysDept{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	s := DeptServiceApp
+	root := system.SysDept{DeptName: "总公司"}
+	if err := s.CreateDept(&root); err != nil {
+		t.Fatalf("CreateDept: %v", err)
+	}
+	dev := system.SysDept{ParentId: root.ID, DeptName: "研发部"}
+	if err := s.CreateDept(&dev); err != nil {
+		t.Fatalf("CreateDept: %v", err)
+	}
+	team := system.SysDept{ParentId: dev.ID, DeptName: "后端组"}
+	if err := s.CreateDept(&team); err != nil {
+		t.Fatalf("CreateDept: %v", err)
+	}
+
+	ids, err := s.GetDeptAndChildIds(dev.ID)
+	if err != nil {
+		t.Fatalf("GetDeptAndChildIds: %v", err)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) != 2 || ids[0] != dev.ID || ids[1] != team.ID {
+		t.Errorf("GetDeptAndChildIds() = %v, want [%d %d]", ids, dev.ID, team.ID)
+	}
+
+	tree, err := s.GetDeptTree()
+	if err != nil {
+		t.Fatalf("GetDeptTree: %v", err)
+	}
+	if len(tree) != 1 || len(tree[0].Children) != 1 || len(tree[0].Children[0].Children) != 1 {
+		t.Errorf("GetDeptTree() returned unexpected shape: %+v", tree)
+	}
+
+	// 不允许把部门挂到自己的下级部门下
+	dev.ParentId = team.ID
+	if err = s.UpdateDept(dev); err == nil {
+		t.Error("UpdateDept should reject cycles")
+	}
+	if err = s.DeleteDept(dev.ID); err == nil {
+		t.Error("DeleteDept should reject departments with children")
+	}
+}
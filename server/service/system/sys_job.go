@@ -0,0 +1,398 @@
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+var (
+	ErrJobNotFound = errors.New("定时任务不存在")
+	ErrJobHandler  = errors.New("定时任务的处理函数未注册")
+	ErrJobRunning  = errors.New("定时任务正在执行")
+)
+
+// jobCronName 数据库中的定时任务统一加入该cron 重新加载时整体清空
+const jobCronName = "SysJob"
+
+// jobTaskPrefix 定时任务在集群中的标识前缀
+const jobTaskPrefix = "job:"
+
+// JobHandler 定时任务的处理函数 args为任务配置的JSON参数 未配置时为空
+type JobHandler func(args json.RawMessage) error
+
+var (
+	jobHandlersMu sync.RWMutex
+	jobHandlers   = make(map[string]JobHandler)
+)
+
+// RegisterJobHandler 按名称注册定时任务的处理函数 定时任务通过名称引用
+func RegisterJobHandler(name string, handler JobHandler) {
+	jobHandlersMu.Lock()
+	defer jobHandlersMu.Unlock()
+	jobHandlers[name] = handler
+}
+
+func getJobHandler(name string) (JobHandler, bool) {
+	jobHandlersMu.RLock()
+	defer jobHandlersMu.RUnlock()
+	handler, ok := jobHandlers[name]
+	return handler, ok
+}
+
+var (
+	jobScheduleMu sync.Mutex
+	jobScheduled  = make(map[uint]string) // 本节点已调度的任务ID及cron表达式
+	jobRunning    sync.Map                // 本节点正在执行的任务ID 同一任务不重叠执行
+)
+
+type JobService struct{}
+
+var JobServiceApp = new(JobService)
+
+//@function: GetJobHandlers
+//@description: 获取已注册的处理函数名称
+//@return: []string
+
+func (jobService *JobService) GetJobHandlers() []string {
+	jobHandlersMu.RLock()
+	defer jobHandlersMu.RUnlock()
+	names := make([]string, 0, len(jobHandlers))
+	for name := range jobHandlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//@function: CreateJob
+//@description: 创建定时任务 启用时立即加入调度
+//@param: job *system.SysJob
+//@return: err error
+
+func (jobService *JobService) CreateJob(job *system.SysJob) (err error) {
+	if err = jobService.validate(*job); err != nil {
+		return err
+	}
+	if err = global.GVA_DB.Create(job).Error; err != nil {
+		return err
+	}
+	return jobService.schedule(*job)
+}
+
+//@function: UpdateJob
+//@description: 更新定时任务 并按新的配置重新调度
+//@param: job system.SysJob
+//@return: err error
+
+func (jobService *JobService) UpdateJob(job system.SysJob) (err error) {
+	if _, err = jobService.GetJob(job.ID); err != nil {
+		return err
+	}
+	if err = jobService.validate(job); err != nil {
+		return err
+	}
+	err = global.GVA_DB.Model(&system.SysJob{}).Where("id = ?", job.ID).
+		Select("name", "spec", "handler", "args", "enabled", "description").Updates(&job).Error
+	if err != nil {
+		return err
+	}
+	return jobService.schedule(job)
+}
+
+//@function: DeleteJobs
+//@description: 删除定时任务 并停止调度 执行记录保留
+//@param: ids []uint
+//@return: err error
+
+func (jobService *JobService) DeleteJobs(ids []uint) (err error) {
+	if err = global.GVA_DB.Delete(&[]system.SysJob{}, "id in ?", ids).Error; err != nil {
+		return err
+	}
+	jobScheduleMu.Lock()
+	defer jobScheduleMu.Unlock()
+	for _, id := range ids {
+		jobService.remove(id)
+	}
+	return nil
+}
+
+//@function: GetJob
+//@description: 根据ID获取定时任务
+//@param: id uint
+//@return: job system.SysJob, err error
+
+func (jobService *JobService) GetJob(id uint) (job system.SysJob, err error) {
+	if err = global.GVA_DB.First(&job, id).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return job, ErrJobNotFound
+	}
+	return job, err
+}
+
+//@function: GetJobList
+//@description: 分页获取定时任务
+//@param: info systemReq.SysJobSearch
+//@return: list []system.SysJob, total int64, err error
+
+func (jobService *JobService) GetJobList(info systemReq.SysJobSearch) (list []system.SysJob, total int64, err error) {
+	db := global.GVA_DB.Model(&system.SysJob{})
+	if info.Name != "" {
+		db = db.Where("name LIKE ?", "%"+info.Name+"%")
+	}
+	if info.Handler != "" {
+		db = db.Where("handler = ?", info.Handler)
+	}
+	if info.Enabled != nil {
+		db = db.Where("enabled = ?", *info.Enabled)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Scopes(info.Paginate()).Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+//@function: SetJobEnabled
+//@description: 暂停或恢复定时任务
+//@param: id uint, enabled bool
+//@return: err error
+
+func (jobService *JobService) SetJobEnabled(id uint, enabled bool) (err error) {
+	job, err := jobService.GetJob(id)
+	if err != nil {
+		return err
+	}
+	if err = global.GVA_DB.Model(&job).Update("enabled", enabled).Error; err != nil {
+		return err
+	}
+	job.Enabled = enabled
+	return jobService.schedule(job)
+}
+
+//@function: RunJob
+//@description: 立即在后台执行一次定时任务 暂停的任务也可以执行
+//@param: id uint
+//@return: err error
+
+func (jobService *JobService) RunJob(id uint) (err error) {
+	job, err := jobService.GetJob(id)
+	if err != nil {
+		return err
+	}
+	if _, ok := getJobHandler(job.Handler); !ok {
+		return ErrJobHandler
+	}
+	if _, loaded := jobRunning.LoadOrStore(job.ID, struct{}{}); loaded {
+		return ErrJobRunning
+	}
+	go func() {
+		defer jobRunning.Delete(job.ID)
+		jobService.execute(job, system.JobTriggerManual)
+	}()
+	return nil
+}
+
+//@function: GetJobRunList
+//@description: 分页获取定时任务执行记录
+//@param: info systemReq.SysJobRunSearch
+//@return: list []system.SysJobRun, total int64, err error
+
+func (jobService *JobService) GetJobRunList(info systemReq.SysJobRunSearch) (list []system.SysJobRun, total int64, err error) {
+	db := global.GVA_DB.Model(&system.SysJobRun{})
+	if info.JobID != 0 {
+		db = db.Where("job_id = ?", info.JobID)
+	}
+	if info.Status != "" {
+		db = db.Where("status = ?", info.Status)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Scopes(info.Paginate()).Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+//@function: LoadJobs
+//@description: 清空已调度的任务 从数据库重新加载启用的定时任务
+//@return: loaded int, err error
+
+func (jobService *JobService) LoadJobs() (loaded int, err error) {
+	var jobs []system.SysJob
+	if err = global.GVA_DB.Where("enabled = ?", true).Find(&jobs).Error; err != nil {
+		return 0, err
+	}
+	jobScheduleMu.Lock()
+	defer jobScheduleMu.Unlock()
+	global.GVA_Timer.Clear(jobCronName)
+	utils.RemoveClusterJobs(jobTaskPrefix)
+	jobScheduled = make(map[uint]string)
+	for _, job := range jobs {
+		if err := jobService.add(job); err != nil {
+			global.GVA_LOG.Error("加载定时任务失败!", zap.String("job", job.Name), zap.Error(err))
+			continue
+		}
+		loaded++
+	}
+	return loaded, nil
+}
+
+//@function: SyncJobs
+//@description: 集群模式下按数据库调整本节点的调度 其他节点新增、修改、暂停或删除的任务在本节点生效
+//@return: err error
+
+func (jobService *JobService) SyncJobs() (err error) {
+	var jobs []system.SysJob
+	if err = global.GVA_DB.Where("enabled = ?", true).Find(&jobs).Error; err != nil {
+		return err
+	}
+	jobScheduleMu.Lock()
+	defer jobScheduleMu.Unlock()
+	enabled := make(map[uint]bool, len(jobs))
+	for _, job := range jobs {
+		enabled[job.ID] = true
+		if spec, ok := jobScheduled[job.ID]; ok && spec == job.Spec {
+			continue
+		}
+		jobService.remove(job.ID)
+		if err := jobService.add(job); err != nil {
+			global.GVA_LOG.Error("加载定时任务失败!", zap.String("job", job.Name), zap.Error(err))
+		}
+	}
+	for id := range jobScheduled {
+		if !enabled[id] {
+			jobService.remove(id)
+		}
+	}
+	return nil
+}
+
+// validate 校验cron表达式、处理函数和参数 任务名称不能重复
+func (jobService *JobService) validate(job system.SysJob) error {
+	if _, err := utils.CronParser.Parse(job.Spec); err != nil {
+		return fmt.Errorf("cron表达式不正确: %w", err)
+	}
+	if _, ok := getJobHandler(job.Handler); !ok {
+		return ErrJobHandler
+	}
+	if job.Args != "" && !json.Valid([]byte(job.Args)) {
+		return errors.New("参数不是合法的JSON")
+	}
+	err := global.GVA_DB.Where("name = ? AND id <> ?", job.Name, job.ID).First(&system.SysJob{}).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		if err != nil {
+			return err
+		}
+		return errors.New("存在相同名称的定时任务")
+	}
+	return nil
+}
+
+// schedule 按任务当前的配置重新调度 暂停的任务只移出调度
+func (jobService *JobService) schedule(job system.SysJob) error {
+	jobScheduleMu.Lock()
+	defer jobScheduleMu.Unlock()
+	jobService.remove(job.ID)
+	if !job.Enabled {
+		return nil
+	}
+	return jobService.add(job)
+}
+
+// add 加入调度 开启集群模式时每次触发只在一个节点执行
+func (jobService *JobService) add(job system.SysJob) error {
+	id, name := job.ID, jobTaskName(job.ID)
+	_, err := global.GVA_Timer.AddTaskByFunc(jobCronName, job.Spec, utils.ClusterJob(name, job.Spec, func() {
+		jobService.trigger(id)
+	}), name, cron.WithParser(utils.CronParser))
+	if err != nil {
+		utils.RemoveClusterJob(name)
+		return err
+	}
+	jobScheduled[id] = job.Spec
+	return nil
+}
+
+func (jobService *JobService) remove(id uint) {
+	name := jobTaskName(id)
+	global.GVA_Timer.RemoveTaskByName(jobCronName, name)
+	utils.RemoveClusterJob(name)
+	delete(jobScheduled, id)
+}
+
+// trigger 按cron触发 执行前重新读取任务 已删除或暂停的任务不再执行
+func (jobService *JobService) trigger(id uint) {
+	if _, loaded := jobRunning.LoadOrStore(id, struct{}{}); loaded {
+		global.GVA_LOG.Warn("定时任务上次执行尚未结束 跳过本次执行", zap.Uint("job", id))
+		return
+	}
+	defer jobRunning.Delete(id)
+	var job system.SysJob
+	if err := global.GVA_DB.First(&job, id).Error; err != nil || !job.Enabled {
+		return
+	}
+	jobService.execute(job, system.JobTriggerCron)
+}
+
+// execute 执行任务并写入执行记录
+func (jobService *JobService) execute(job system.SysJob, trigger string) system.SysJobRun {
+	run := system.SysJobRun{
+		JobID:     job.ID,
+		JobName:   job.Name,
+		Handler:   job.Handler,
+		Trigger:   trigger,
+		Status:    system.JobRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := global.GVA_DB.Create(&run).Error; err != nil {
+		global.GVA_LOG.Error("写入定时任务执行记录失败!", zap.Error(err))
+	}
+	err := invokeJobHandler(job)
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Duration = finishedAt.Sub(run.StartedAt).Milliseconds()
+	run.Status = system.JobRunStatusSuccess
+	if err != nil {
+		run.Status = system.JobRunStatusFailed
+		run.Error = err.Error()
+		global.GVA_LOG.Error("执行定时任务失败!", zap.String("job", job.Name), zap.Error(err))
+	}
+	if err = global.GVA_DB.Save(&run).Error; err != nil {
+		global.GVA_LOG.Error("写入定时任务执行记录失败!", zap.Error(err))
+	}
+	return run
+}
+
+// invokeJobHandler 调用处理函数 处理函数panic时作为失败记录
+func invokeJobHandler(job system.SysJob) (err error) {
+	handler, ok := getJobHandler(job.Handler)
+	if !ok {
+		return ErrJobHandler
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	var args json.RawMessage
+	if job.Args != "" {
+		args = json.RawMessage(job.Args)
+	}
+	return handler(args)
+}
+
+func jobTaskName(id uint) string {
+	return jobTaskPrefix + strconv.FormatUint(uint64(id), 10)
+}
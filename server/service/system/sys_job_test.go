@@ -0,0 +1,142 @@
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+)
+
+func TestJobService(t *testing.T) {
+	setupUserTest(t)
+	if err := global.GVA_DB.AutoMigrate(&system.SysJob{}, &system.SysJobRun{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() { global.GVA_Timer.Clear(jobCronName) })
+
+	calls := make(chan string, 4)
+	RegisterJobHandler("test.echo", func(args json.RawMessage) error {
+		var params struct{ Fail string }
+		if len(args) > 0 {
+			if err := json.Unmarshal(args, &params); err != nil {
+				return err
+			}
+		}
+		calls <- string(args)
+		if params.Fail != "" {
+			return errors.New(params.Fail)
+		}
+		return nil
+	})
+	RegisterJobHandler("test.panic", func(json.RawMessage) error { panic("boom") })
+
+	service := JobServiceApp
+	for _, job := range []system.SysJob{
+		{Name: "bad spec", Spec: "every day", Handler: "test.echo"},
+		{Name: "bad handler", Spec: "@daily", Handler: "missing"},
+		{Name: "bad args", Spec: "@daily", Handler: "test.echo", Args: "{"},
+	} {
+		if err := service.CreateJob(&job); err == nil {
+			t.Errorf("CreateJob(%s) should fail", job.Name)
+		}
+	}
+
+	job := system.SysJob{Name: "echo", Spec: "0 0 * * *", Handler: "test.echo", Args: `{"Fail":"oops"}`, Enabled: true}
+	if err := service.CreateJob(&job); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if _, ok := global.GVA_Timer.FindTask(jobCronName, jobTaskName(job.ID)); !ok {
+		t.Error("enabled job should be scheduled")
+	}
+	if err := service.CreateJob(&system.SysJob{Name: "echo", Spec: "@daily", Handler: "test.echo"}); err == nil {
+		t.Error("duplicate name should be rejected")
+	}
+
+	// 失败和panic都记录到执行记录
+	run := service.execute(job, system.JobTriggerManual)
+	if run.Status != system.JobRunStatusFailed || run.Error != "oops" || run.FinishedAt == nil || <-calls != job.Args {
+		t.Errorf("failed run = %+v", run)
+	}
+	panicJob := system.SysJob{Name: "panic", Spec: "@daily", Handler: "test.panic"}
+	if err := service.CreateJob(&panicJob); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if run = service.execute(panicJob, system.JobTriggerCron); run.Status != system.JobRunStatusFailed || run.Error != "panic: boom" {
+		t.Errorf("panic run = %+v", run)
+	}
+
+	// 暂停后移出调度 手动执行仍然可以
+	if err := service.SetJobEnabled(job.ID, false); err != nil {
+		t.Fatalf("SetJobEnabled: %v", err)
+	}
+	if _, ok := global.GVA_Timer.FindTask(jobCronName, jobTaskName(job.ID)); ok {
+		t.Error("paused job should not be scheduled")
+	}
+	job.Args, job.Enabled = "", false
+	if err := service.UpdateJob(job); err != nil {
+		t.Fatalf("UpdateJob: %v", err)
+	}
+	if err := service.RunJob(job.ID); err != nil {
+		t.Fatalf("RunJob: %v", err)
+	}
+	select {
+	case args := <-calls:
+		if args != "" {
+			t.Errorf("args = %q", args)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunJob did not run the handler")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		list, total, err := service.GetJobRunList(systemReq.SysJobRunSearch{JobID: job.ID, Status: system.JobRunStatusSuccess})
+		if err != nil {
+			t.Fatalf("GetJobRunList: %v", err)
+		}
+		if total == 1 {
+			if list[0].Trigger != system.JobTriggerManual {
+				t.Errorf("run = %+v", list[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("manual run was not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 重新加载时只调度启用的任务
+	if err := service.SetJobEnabled(panicJob.ID, true); err != nil {
+		t.Fatalf("SetJobEnabled: %v", err)
+	}
+	if loaded, err := service.LoadJobs(); err != nil || loaded != 1 {
+		t.Errorf("LoadJobs = %d, %v", loaded, err)
+	}
+	if err := service.DeleteJobs([]uint{panicJob.ID}); err != nil {
+		t.Fatalf("DeleteJobs: %v", err)
+	}
+	if _, ok := global.GVA_Timer.FindTask(jobCronName, jobTaskName(panicJob.ID)); ok {
+		t.Error("deleted job should not be scheduled")
+	}
+
+	// 集群中其他节点新增的任务同步后在本节点调度
+	remote := system.SysJob{Name: "remote", Spec: "@hourly", Handler: "test.echo", Enabled: true}
+	global.GVA_DB.Create(&remote)
+	if err := service.SyncJobs(); err != nil {
+		t.Fatalf("SyncJobs: %v", err)
+	}
+	if _, ok := global.GVA_Timer.FindTask(jobCronName, jobTaskName(remote.ID)); !ok {
+		t.Error("job created on another node should be scheduled after sync")
+	}
+	global.GVA_DB.Delete(&remote)
+	if err := service.SyncJobs(); err != nil {
+		t.Fatalf("SyncJobs: %v", err)
+	}
+	if _, ok := global.GVA_Timer.FindTask(jobCronName, jobTaskName(remote.ID)); ok {
+		t.Error("job deleted on another node should be removed after sync")
+	}
+}
@@ -1,10 +1,16 @@
 package system
 
 import (
+	"errors"
+	"math"
+	"time"
+
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/common/request"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"gorm.io/gorm"
 )
 
 //@author: [granty1](https://github.com/granty1)
@@ -74,6 +80,17 @@ func (operationRecordService *OperationRecordService) GetSysOperationRecordInfoL
 	if info.Status != 0 {
 		db = db.Where("status = ?", info.Status)
 	}
+	if info.UserID != 0 {
+		db = db.Where("user_id = ?", info.UserID)
+	}
+	if len(info.CreatedAtRange) == 2 {
+		db = db.Where("created_at BETWEEN ? AND ?", info.CreatedAtRange[0], info.CreatedAtRange[1])
+	}
+	// 关键字同时搜索路径、请求Body和错误信息
+	if info.Keyword != "" {
+		keyword := "%" + info.Keyword + "%"
+		db = db.Where("(path LIKE ? OR body LIKE ? OR error_message LIKE ?)", keyword, keyword, keyword)
+	}
 	err = db.Count(&total).Error
 	if err != nil {
 		return
@@ -81,3 +98,136 @@ func (operationRecordService *OperationRecordService) GetSysOperationRecordInfoL
 	err = db.Order("id desc").Limit(limit).Offset(offset).Preload("User").Find(&sysOperationRecords).Error
 	return sysOperationRecords, total, err
 }
+
+//@function: GetOperationRecordWriterStats
+//@description: 获取操作记录写入器指标
+//@return: systemRes.OperationRecordWriterStats
+
+func (operationRecordService *OperationRecordService) GetOperationRecordWriterStats() systemRes.OperationRecordWriterStats {
+	return OperationRecordWriterApp.Stats()
+}
+
+//@function: GetSysOperationRecordStatistics
+//@description: 按时间范围统计操作记录 包括按用户、路径、状态码的分布和耗时分位数
+//@param: info systemReq.SysOperationRecordStatistics
+//@return: stats systemRes.OperationRecordStatistics, err error
+
+func (operationRecordService *OperationRecordService) GetSysOperationRecordStatistics(info systemReq.SysOperationRecordStatistics) (stats systemRes.OperationRecordStatistics, err error) {
+	stats.EndTime = time.Now()
+	if info.EndTime != nil {
+		stats.EndTime = *info.EndTime
+	}
+	stats.StartTime = stats.EndTime.Add(-24 * time.Hour)
+	if info.StartTime != nil {
+		stats.StartTime = *info.StartTime
+	}
+	if stats.StartTime.After(stats.EndTime) {
+		return stats, errors.New("开始时间不能晚于结束时间")
+	}
+	top := info.Top
+	if top <= 0 || top > 100 {
+		top = 10
+	}
+	scope := func() *gorm.DB {
+		return global.GVA_DB.Model(&system.SysOperationRecord{}).Where("created_at BETWEEN ? AND ?", stats.StartTime, stats.EndTime)
+	}
+	const errorCount = "SUM(CASE WHEN status >= 400 THEN 1 ELSE 0 END) AS error_count"
+
+	var summary struct {
+		Total      int64
+		ErrorCount int64
+		AvgLatency float64
+		MaxLatency float64
+	}
+	if err = scope().Select("COUNT(*) AS total, " + errorCount + ", AVG(latency) AS avg_latency, MAX(latency) AS max_latency").Scan(&summary).Error; err != nil {
+		return
+	}
+	stats.Total = summary.Total
+	stats.ErrorCount = summary.ErrorCount
+	stats.AvgLatencyMs = latencyMs(summary.AvgLatency)
+	stats.Latency.Max = latencyMs(summary.MaxLatency)
+	stats.ByUser = []systemRes.OperationRecordUserStat{}
+	stats.ByPath = []systemRes.OperationRecordPathStat{}
+	stats.ByStatus = []systemRes.OperationRecordStatusStat{}
+	if stats.Total == 0 {
+		return
+	}
+
+	// 最近秩法计算分位数 每个分位数按耗时排序后取一条
+	for _, p := range []struct {
+		rank  float64
+		value *float64
+	}{{0.5, &stats.Latency.P50}, {0.9, &stats.Latency.P90}, {0.95, &stats.Latency.P95}, {0.99, &stats.Latency.P99}} {
+		var latency []int64
+		offset := int(math.Ceil(p.rank*float64(stats.Total))) - 1
+		if err = scope().Order("latency").Offset(offset).Limit(1).Pluck("latency", &latency).Error; err != nil {
+			return
+		}
+		if len(latency) > 0 {
+			*p.value = latencyMs(float64(latency[0]))
+		}
+	}
+
+	var users []struct {
+		UserID     int
+		Count      int64
+		ErrorCount int64
+		AvgLatency float64
+	}
+	if err = scope().Select("user_id, COUNT(*) AS count, " + errorCount + ", AVG(latency) AS avg_latency").
+		Group("user_id").Order("count desc").Limit(top).Scan(&users).Error; err != nil {
+		return
+	}
+	userIds := make([]int, 0, len(users))
+	for _, u := range users {
+		userIds = append(userIds, u.UserID)
+	}
+	var sysUsers []system.SysUser
+	if err = global.GVA_DB.Select("id", "username").Where("id in ?", userIds).Find(&sysUsers).Error; err != nil {
+		return
+	}
+	usernames := make(map[int]string, len(sysUsers))
+	for _, u := range sysUsers {
+		usernames[int(u.ID)] = u.Username
+	}
+	for _, u := range users {
+		stats.ByUser = append(stats.ByUser, systemRes.OperationRecordUserStat{
+			UserID:       u.UserID,
+			Username:     usernames[u.UserID],
+			Count:        u.Count,
+			ErrorCount:   u.ErrorCount,
+			AvgLatencyMs: latencyMs(u.AvgLatency),
+		})
+	}
+
+	var paths []struct {
+		Method     string
+		Path       string
+		Count      int64
+		ErrorCount int64
+		AvgLatency float64
+		MaxLatency float64
+	}
+	if err = scope().Select("method, path, COUNT(*) AS count, " + errorCount + ", AVG(latency) AS avg_latency, MAX(latency) AS max_latency").
+		Group("method, path").Order("count desc").Limit(top).Scan(&paths).Error; err != nil {
+		return
+	}
+	for _, p := range paths {
+		stats.ByPath = append(stats.ByPath, systemRes.OperationRecordPathStat{
+			Method:       p.Method,
+			Path:         p.Path,
+			Count:        p.Count,
+			ErrorCount:   p.ErrorCount,
+			AvgLatencyMs: latencyMs(p.AvgLatency),
+			MaxLatencyMs: latencyMs(p.MaxLatency),
+		})
+	}
+
+	err = scope().Select("status, COUNT(*) AS count").Group("status").Order("status").Scan(&stats.ByStatus).Error
+	return
+}
+
+// latencyMs 将以纳秒保存的耗时转换为毫秒
+func latencyMs(latency float64) float64 {
+	return math.Round(latency/float64(time.Millisecond)*100) / 100
+}
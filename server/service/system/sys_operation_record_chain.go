@@ -0,0 +1,200 @@
+package system
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 哈希链断裂原因
+const (
+	chainBreakContent  = "记录内容与哈希不匹配"
+	chainBreakPrevHash = "上一条记录哈希不匹配 中间记录可能被删除"
+	chainBreakUnsigned = "哈希链中出现未计算哈希的记录"
+)
+
+const maxChainBreaks = 100
+
+// operationRecordChainHeadID 哈希链头所在行
+const operationRecordChainHeadID = 1
+
+// operationRecordHashContent 参与哈希计算的记录内容
+type operationRecordHashContent struct {
+	Ip           string `json:"ip"`
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	Status       int    `json:"status"`
+	Latency      int64  `json:"latency"`
+	Agent        string `json:"agent"`
+	ErrorMessage string `json:"errorMessage"`
+	Body         string `json:"body"`
+	Resp         string `json:"resp"`
+	UserID       int    `json:"userId"`
+	CreatedAt    int64  `json:"createdAt"`
+}
+
+// operationRecordHash 计算记录哈希 sha256(上一条记录哈希 + 记录内容)
+func operationRecordHash(prevHash string, record *system.SysOperationRecord) string {
+	content, _ := json.Marshal(operationRecordHashContent{
+		Ip:           record.Ip,
+		Method:       record.Method,
+		Path:         record.Path,
+		Status:       record.Status,
+		Latency:      int64(record.Latency),
+		Agent:        record.Agent,
+		ErrorMessage: record.ErrorMessage,
+		Body:         record.Body,
+		Resp:         record.Resp,
+		UserID:       record.UserID,
+		CreatedAt:    record.CreatedAt.UnixMilli(),
+	})
+	sum := sha256.Sum256(append([]byte(prevHash+"\n"), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// lastOperationRecordHash 获取哈希链末尾的哈希 含已删除的记录 记录都被清理时取最新检查点
+func lastOperationRecordHash(tx *gorm.DB) (string, error) {
+	var last system.SysOperationRecord
+	err := tx.Unscoped().Select("id", "hash").Where("hash <> ''").Order("id desc").Take(&last).Error
+	if err == nil {
+		return last.Hash, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	checkpoint, err := latestOperationRecordCheckpoint(tx)
+	return checkpoint.Hash, err
+}
+
+// latestOperationRecordCheckpoint 获取最新的清理检查点 没有时返回零值
+func latestOperationRecordCheckpoint(tx *gorm.DB) (checkpoint system.SysOperationRecordCheckpoint, err error) {
+	err = tx.Order("record_id desc").Take(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return checkpoint, nil
+	}
+	return
+}
+
+// lockOperationRecordChain 在事务内锁定哈希链头 直到事务结束 各节点的写入和清理由数据库串行执行
+func lockOperationRecordChain(tx *gorm.DB) error {
+	head := system.SysOperationRecordChainHead{ID: operationRecordChainHeadID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&head).Error; err != nil {
+		return err
+	}
+	// SQL Server 不支持 FOR UPDATE 通过更新该行加锁 SQLite 写事务本身即串行
+	if global.GVA_CONFIG.System.DbType == "mssql" {
+		return tx.Model(&head).Update("updated_at", time.Now()).Error
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&head, operationRecordChainHeadID).Error
+}
+
+// createChainedOperationRecords 接在哈希链末尾写入记录
+func createChainedOperationRecords(records []system.SysOperationRecord) error {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockOperationRecordChain(tx); err != nil {
+			return err
+		}
+		prevHash, err := lastOperationRecordHash(tx)
+		if err != nil {
+			return err
+		}
+		for i := range records {
+			// 按数据库可保存的精度截断 保证读出后哈希一致
+			records[i].CreatedAt = records[i].CreatedAt.Truncate(time.Millisecond)
+			records[i].PrevHash = prevHash
+			records[i].Hash = operationRecordHash(prevHash, &records[i])
+			prevHash = records[i].Hash
+		}
+		return tx.CreateInBatches(&records, len(records)).Error
+	})
+}
+
+//@function: VerifySysOperationRecordChain
+//@description: 按ID顺序校验操作记录哈希链 检查记录是否被篡改或在系统之外被删除
+//@return: result systemRes.OperationRecordChainVerification, err error
+
+func (operationRecordService *OperationRecordService) VerifySysOperationRecordChain() (result systemRes.OperationRecordChainVerification, err error) {
+	checkpoint, err := latestOperationRecordCheckpoint(global.GVA_DB)
+	if err != nil {
+		return
+	}
+	result.CheckpointID = checkpoint.RecordID
+	result.Breaks = []systemRes.OperationRecordChainBreak{}
+	addBreak := func(id uint, reason string) {
+		if len(result.Breaks) >= maxChainBreaks {
+			result.Truncated = true
+			return
+		}
+		result.Breaks = append(result.Breaks, systemRes.OperationRecordChainBreak{ID: id, Reason: reason})
+	}
+
+	// 软删除的记录仍在链中 一并校验
+	prevHash := checkpoint.Hash
+	started := checkpoint.Hash != ""
+	var records []system.SysOperationRecord
+	err = global.GVA_DB.Unscoped().Where("id > ?", checkpoint.RecordID).FindInBatches(&records, 500, func(tx *gorm.DB, batch int) error {
+		for i := range records {
+			record := &records[i]
+			result.LastID = record.ID
+			if record.Hash == "" {
+				if started {
+					result.Checked++
+					addBreak(record.ID, chainBreakUnsigned)
+				} else {
+					result.Legacy++
+				}
+				continue
+			}
+			started = true
+			result.Checked++
+			if record.PrevHash != prevHash {
+				addBreak(record.ID, chainBreakPrevHash)
+			}
+			if operationRecordHash(record.PrevHash, record) != record.Hash {
+				addBreak(record.ID, chainBreakContent)
+			}
+			prevHash = record.Hash
+		}
+		return nil
+	}).Error
+	result.Valid = err == nil && len(result.Breaks) == 0
+	return
+}
+
+//@function: PruneSysOperationRecords
+//@description: 清理指定时间之前的操作记录 写入检查点作为哈希链新的起点
+//@param: before time.Time
+//@return: pruned int64, err error
+
+func (operationRecordService *OperationRecordService) PruneSysOperationRecords(before time.Time) (pruned int64, err error) {
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		// 与写入使用同一把锁 避免清理时新记录接在被清理的记录之后
+		if err := lockOperationRecordChain(tx); err != nil {
+			return err
+		}
+		// 只清理ID连续的最旧部分 保证剩余记录仍能接上检查点
+		var last system.SysOperationRecord
+		err := tx.Unscoped().Select("id", "hash").Where("created_at < ?", before).Order("id desc").Take(&last).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id <= ?", last.ID).Delete(&system.SysOperationRecord{})
+		if result.Error != nil {
+			return result.Error
+		}
+		pruned = result.RowsAffected
+		return tx.Create(&system.SysOperationRecordCheckpoint{RecordID: last.ID, Hash: last.Hash, Pruned: pruned}).Error
+	})
+	return
+}
@@ -0,0 +1,99 @@
+package system
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+)
+
+func TestOperationRecordChain(t *testing.T) {
+	user := setupUserTest(t)
+	if err := global.GVA_DB.AutoMigrate(&system.SysOperationRecord{}, &system.SysOperationRecordCheckpoint{}, &system.SysOperationRecordChainHead{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	// 启用哈希链之前写入的记录
+	old := time.Now().Add(-48 * time.Hour)
+	legacy := system.SysOperationRecord{Path: "/legacy"}
+	legacy.CreatedAt = old
+	if err := global.GVA_DB.Create(&legacy).Error; err != nil {
+		t.Fatalf("create legacy: %v", err)
+	}
+	var records []system.SysOperationRecord
+	for i := 0; i < 6; i++ {
+		records = append(records, system.SysOperationRecord{
+			Method:  "POST",
+			Path:    "/api/test",
+			Status:  200 + 300*(i%2),
+			Latency: time.Duration(i+1) * time.Millisecond,
+			UserID:  int(user.ID),
+		})
+		records[i].CreatedAt = time.Now()
+	}
+	records[0].CreatedAt = old
+	if err := createChainedOperationRecords(records[:2]); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := createChainedOperationRecords(records[2:]); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// 各次写入锁定同一行链头
+	var heads int64
+	global.GVA_DB.Model(&system.SysOperationRecordChainHead{}).Count(&heads)
+	if heads != 1 {
+		t.Errorf("chain heads = %d, want 1", heads)
+	}
+
+	service := OperationRecordServiceApp
+	verify := func() []string {
+		t.Helper()
+		result, err := service.VerifySysOperationRecordChain()
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		var reasons []string
+		for _, b := range result.Breaks {
+			reasons = append(reasons, b.Reason)
+		}
+		if result.Valid != (len(reasons) == 0) {
+			t.Fatalf("valid = %v with breaks %v", result.Valid, reasons)
+		}
+		return reasons
+	}
+	if breaks := verify(); len(breaks) != 0 {
+		t.Fatalf("unexpected breaks: %v", breaks)
+	}
+
+	stats, err := service.GetSysOperationRecordStatistics(systemReq.SysOperationRecordStatistics{})
+	if err != nil {
+		t.Fatalf("statistics: %v", err)
+	}
+	if stats.Total != 5 || stats.ErrorCount != 3 || stats.Latency.P50 != 4 || stats.Latency.Max != 6 {
+		t.Errorf("unexpected statistics: %+v", stats)
+	}
+	if len(stats.ByUser) != 1 || stats.ByUser[0].Username != user.Username || stats.ByUser[0].Count != 5 {
+		t.Errorf("unexpected user statistics: %+v", stats.ByUser)
+	}
+
+	// 软删除和清理不会破坏哈希链
+	if err = service.DeleteSysOperationRecord(records[2]); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	pruned, err := service.PruneSysOperationRecords(time.Now().Add(-24 * time.Hour))
+	if err != nil || pruned != 2 {
+		t.Fatalf("prune = %d, %v", pruned, err)
+	}
+	if breaks := verify(); len(breaks) != 0 {
+		t.Fatalf("unexpected breaks after prune: %v", breaks)
+	}
+
+	// 篡改内容和在系统之外删除记录都会被发现
+	global.GVA_DB.Model(&system.SysOperationRecord{}).Where("id = ?", records[3].ID).Update("body", "tampered")
+	global.GVA_DB.Unscoped().Delete(&system.SysOperationRecord{}, records[4].ID)
+	breaks := verify()
+	if len(breaks) != 2 || breaks[0] != chainBreakContent || breaks[1] != chainBreakPrevHash {
+		t.Errorf("unexpected breaks: %v", breaks)
+	}
+}
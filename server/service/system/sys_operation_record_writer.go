@@ -0,0 +1,229 @@
+package system
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"go.uber.org/zap"
+)
+
+// 队列满时的处理策略
+const (
+	OperationRecordDropPolicyDrop  = "drop"  // 丢弃新记录
+	OperationRecordDropPolicyBlock = "block" // 等待队列空出 超时后丢弃
+	OperationRecordDropPolicySync  = "sync"  // 在请求中同步写入
+)
+
+// OperationRecordWriter 操作记录异步批量写入器
+// 请求只把记录放入有界队列 由后台协程按批次写入数据库 未启动时退化为同步写入
+type OperationRecordWriter struct {
+	mu      sync.RWMutex
+	running bool
+	queue   chan system.SysOperationRecord
+	done    chan struct{}
+	config  config.OperationRecord
+
+	enqueued   atomic.Uint64
+	written    atomic.Uint64
+	dropped    atomic.Uint64
+	failed     atomic.Uint64
+	syncWrites atomic.Uint64
+	batches    atomic.Uint64
+}
+
+var OperationRecordWriterApp = new(OperationRecordWriter)
+
+//@function: Start
+//@description: 按配置启动后台写入协程 未开启异步写入时不启动
+//@param: cfg config.OperationRecord
+
+func (w *OperationRecordWriter) Start(cfg config.OperationRecord) {
+	if !cfg.Async {
+		return
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 1000
+	}
+	if cfg.BlockTimeout <= 0 {
+		cfg.BlockTimeout = 100
+	}
+	switch cfg.DropPolicy {
+	case OperationRecordDropPolicyBlock, OperationRecordDropPolicySync:
+	default:
+		cfg.DropPolicy = OperationRecordDropPolicyDrop
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running {
+		return
+	}
+	w.config = cfg
+	w.queue = make(chan system.SysOperationRecord, cfg.QueueSize)
+	w.done = make(chan struct{})
+	w.running = true
+	go w.run(w.queue, w.done, cfg.BatchSize, time.Duration(cfg.FlushInterval)*time.Millisecond)
+}
+
+//@function: Write
+//@description: 写入一条操作记录 队列满时按策略丢弃、等待或同步写入
+//@param: record system.SysOperationRecord
+
+func (w *OperationRecordWriter) Write(record system.SysOperationRecord) {
+	// 异步写入时保留请求发生的时间
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	w.mu.RLock()
+	if !w.running {
+		w.mu.RUnlock()
+		w.writeSync(record)
+		return
+	}
+	select {
+	case w.queue <- record:
+		w.enqueued.Add(1)
+		w.mu.RUnlock()
+		return
+	default:
+	}
+
+	switch w.config.DropPolicy {
+	case OperationRecordDropPolicyBlock:
+		timer := time.NewTimer(time.Duration(w.config.BlockTimeout) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case w.queue <- record:
+			w.enqueued.Add(1)
+		case <-timer.C:
+			w.dropped.Add(1)
+		}
+		w.mu.RUnlock()
+	case OperationRecordDropPolicySync:
+		w.mu.RUnlock()
+		w.writeSync(record)
+	default:
+		w.mu.RUnlock()
+		w.dropped.Add(1)
+	}
+}
+
+//@function: Close
+//@description: 停止接收新记录并写入队列中剩余的记录 之后的记录同步写入
+//@param: ctx context.Context
+//@return: error
+
+func (w *OperationRecordWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return nil
+	}
+	w.running = false
+	close(w.queue)
+	done := w.done
+	w.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//@function: Stats
+//@description: 获取写入器运行指标
+//@return: systemRes.OperationRecordWriterStats
+
+func (w *OperationRecordWriter) Stats() systemRes.OperationRecordWriterStats {
+	w.mu.RLock()
+	stats := systemRes.OperationRecordWriterStats{
+		Async:      w.running,
+		DropPolicy: w.config.DropPolicy,
+	}
+	if w.running {
+		stats.QueueDepth = len(w.queue)
+		stats.QueueCapacity = cap(w.queue)
+	}
+	w.mu.RUnlock()
+	stats.Enqueued = w.enqueued.Load()
+	stats.Written = w.written.Load()
+	stats.Dropped = w.dropped.Load()
+	stats.Failed = w.failed.Load()
+	stats.SyncWrites = w.syncWrites.Load()
+	stats.Batches = w.batches.Load()
+	return stats
+}
+
+// run 后台写入协程 批次写满或到达刷新间隔时写入 队列关闭后写入剩余记录并退出
+func (w *OperationRecordWriter) run(queue <-chan system.SysOperationRecord, done chan<- struct{}, batchSize int, interval time.Duration) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	batch := make([]system.SysOperationRecord, 0, batchSize)
+	for {
+		select {
+		case record, ok := <-queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= batchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush 批量写入一批记录 写入时接在哈希链末尾
+func (w *OperationRecordWriter) flush(batch []system.SysOperationRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	w.batches.Add(1)
+	if global.GVA_DB == nil {
+		w.failed.Add(uint64(len(batch)))
+		return
+	}
+	if err := createChainedOperationRecords(batch); err != nil {
+		w.failed.Add(uint64(len(batch)))
+		global.GVA_LOG.Error("批量写入操作记录失败!", zap.Int("count", len(batch)), zap.Error(err))
+		return
+	}
+	w.written.Add(uint64(len(batch)))
+}
+
+// writeSync 同步写入单条记录
+func (w *OperationRecordWriter) writeSync(record system.SysOperationRecord) {
+	w.syncWrites.Add(1)
+	if global.GVA_DB == nil {
+		w.failed.Add(1)
+		return
+	}
+	if err := createChainedOperationRecords([]system.SysOperationRecord{record}); err != nil {
+		w.failed.Add(1)
+		global.GVA_LOG.Error("create operation record error:", zap.Error(err))
+		return
+	}
+	w.written.Add(1)
+}
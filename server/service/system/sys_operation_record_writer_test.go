@@ -0,0 +1,82 @@
+package system
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+func countOperationRecords(t *testing.T) int64 {
+	t.Helper()
+	var count int64
+	if err := global.GVA_DB.Model(&system.SysOperationRecord{}).Count(&count).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return count
+}
+
+func TestOperationRecordWriter_FlushOnClose(t *testing.T) {
+	setupUserTest(t)
+	if err := global.GVA_DB.AutoMigrate(&system.SysOperationRecord{}, &system.SysOperationRecordCheckpoint{}, &system.SysOperationRecordChainHead{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	w := new(OperationRecordWriter)
+	// 刷新间隔足够长 记录只会在批次写满或关闭时写入
+	w.Start(config.OperationRecord{Async: true, QueueSize: 100, BatchSize: 10, FlushInterval: 60000})
+	for i := 0; i < 25; i++ {
+		w.Write(system.SysOperationRecord{Method: "POST", Path: "/test"})
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := countOperationRecords(t); got != 25 {
+		t.Errorf("records = %d, want 25", got)
+	}
+	stats := w.Stats()
+	if stats.Enqueued != 25 || stats.Written != 25 || stats.Batches != 3 || stats.Async {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// 关闭后退化为同步写入
+	w.Write(system.SysOperationRecord{Method: "POST", Path: "/test"})
+	if got := countOperationRecords(t); got != 26 {
+		t.Errorf("records after close = %d, want 26", got)
+	}
+}
+
+func TestOperationRecordWriter_DropPolicy(t *testing.T) {
+	setupUserTest(t)
+	if err := global.GVA_DB.AutoMigrate(&system.SysOperationRecord{}, &system.SysOperationRecordCheckpoint{}, &system.SysOperationRecordChainHead{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	// 不启动写入协程 队列写满后按策略处理
+	newWriter := func(policy string) *OperationRecordWriter {
+		return &OperationRecordWriter{
+			running: true,
+			queue:   make(chan system.SysOperationRecord, 1),
+			config:  config.OperationRecord{DropPolicy: policy, BlockTimeout: 10},
+		}
+	}
+	for _, policy := range []string{OperationRecordDropPolicyDrop, OperationRecordDropPolicyBlock} {
+		w := newWriter(policy)
+		w.Write(system.SysOperationRecord{Path: "/a"})
+		w.Write(system.SysOperationRecord{Path: "/b"})
+		stats := w.Stats()
+		if stats.QueueDepth != 1 || stats.Dropped != 1 {
+			t.Errorf("%s: unexpected stats: %+v", policy, stats)
+		}
+	}
+
+	w := newWriter(OperationRecordDropPolicySync)
+	w.Write(system.SysOperationRecord{Path: "/a"})
+	w.Write(system.SysOperationRecord{Path: "/b"})
+	if stats := w.Stats(); stats.SyncWrites != 1 || stats.Dropped != 0 {
+		t.Errorf("sync: unexpected stats: %+v", stats)
+	}
+	if got := countOperationRecords(t); got != 1 {
+		t.Errorf("records = %d, want 1", got)
+	}
+}
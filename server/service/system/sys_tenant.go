@@ -0,0 +1,205 @@
+package system
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+var (
+	ErrTenantDisabled  = errors.New("租户已停用或已到期")
+	ErrTenantForbidden = errors.New("无权操作其他租户的数据")
+	ErrTenantPlatform  = errors.New("仅平台管理员可以管理租户")
+	ErrTenantExistence = errors.New("租户编码已存在")
+)
+
+type TenantService struct{}
+
+var TenantServiceApp = new(TenantService)
+
+//@function: CreateTenant
+//@description: 创建租户 复制模板角色的权限作为租户管理员角色 并创建租户管理员
+//@param: adminAuthorityID uint, req systemReq.CreateTenant
+//@return: tenant system.SysTenant, err error
+
+func (tenantService *TenantService) CreateTenant(adminAuthorityID uint, req systemReq.CreateTenant) (tenant system.SysTenant, err error) {
+	if !errors.Is(global.GVA_DB.Where("code = ?", req.Code).First(&system.SysTenant{}).Error, gorm.ErrRecordNotFound) {
+		return tenant, ErrTenantExistence
+	}
+	tenant = system.SysTenant{
+		Name:        req.Name,
+		Code:        req.Code,
+		AuthorityId: req.AuthorityId,
+		Enable:      1,
+		ExpiresAt:   req.ExpiresAt,
+		Remark:      req.Remark,
+	}
+	if err = global.GVA_DB.Create(&tenant).Error; err != nil {
+		return
+	}
+	authority := system.SysAuthority{
+		AuthorityId:   req.AuthorityId,
+		AuthorityName: req.Name + "管理员",
+		ParentId:      utils.Pointer[uint](0),
+		GVA_TENANT:    global.GVA_TENANT{TenantID: tenant.ID},
+	}
+	if _, err = AuthorityServiceApp.CopyAuthority(adminAuthorityID, response.SysAuthorityCopyResponse{Authority: authority, OldAuthorityId: req.TemplateAuthorityId}); err != nil {
+		global.GVA_DB.Unscoped().Delete(&tenant)
+		return
+	}
+	nickName := req.NickName
+	if nickName == "" {
+		nickName = req.Username
+	}
+	_, err = UserServiceApp.Register(system.SysUser{
+		GVA_TENANT:  global.GVA_TENANT{TenantID: tenant.ID},
+		Username:    req.Username,
+		Password:    req.Password,
+		NickName:    nickName,
+		AuthorityId: req.AuthorityId,
+		Authorities: []system.SysAuthority{{AuthorityId: req.AuthorityId}},
+		Enable:      1,
+	})
+	if err != nil {
+		_ = AuthorityServiceApp.DeleteAuthority(&authority)
+		global.GVA_DB.Unscoped().Delete(&tenant)
+	}
+	return
+}
+
+//@function: UpdateTenant
+//@description: 修改租户名称、状态和到期时间
+//@param: req systemReq.UpdateTenant
+//@return: err error
+
+func (tenantService *TenantService) UpdateTenant(req systemReq.UpdateTenant) error {
+	result := global.GVA_DB.Model(&system.SysTenant{}).Where("id = ?", req.ID).
+		Select("name", "enable", "expires_at", "remark").
+		Updates(&system.SysTenant{Name: req.Name, Enable: req.Enable, ExpiresAt: req.ExpiresAt, Remark: req.Remark})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("租户不存在")
+	}
+	return nil
+}
+
+//@function: DeleteTenant
+//@description: 删除租户 租户下仍有用户时不允许删除
+//@param: id uint
+//@return: err error
+
+func (tenantService *TenantService) DeleteTenant(id uint) error {
+	var count int64
+	if err := global.GVA_DB.Model(&system.SysUser{}).Where("tenant_id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("租户下存在用户, 不允许删除")
+	}
+	return global.GVA_DB.Delete(&system.SysTenant{}, id).Error
+}
+
+//@function: GetTenant
+//@description: 根据ID获取租户
+//@param: id uint
+//@return: tenant system.SysTenant, err error
+
+func (tenantService *TenantService) GetTenant(id uint) (tenant system.SysTenant, err error) {
+	err = global.GVA_DB.First(&tenant, id).Error
+	return
+}
+
+//@function: GetTenantList
+//@description: 分页获取租户
+//@param: info systemReq.GetTenantList
+//@return: list []system.SysTenant, total int64, err error
+
+func (tenantService *TenantService) GetTenantList(info systemReq.GetTenantList) (list []system.SysTenant, total int64, err error) {
+	db := global.GVA_DB.Model(&system.SysTenant{})
+	if info.Name != "" {
+		db = db.Where("name LIKE ?", "%"+info.Name+"%")
+	}
+	if info.Code != "" {
+		db = db.Where("code = ?", info.Code)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Scopes(info.Paginate()).Order("id desc").Find(&list).Error
+	return
+}
+
+//@function: CheckTenant
+//@description: 校验租户是否可用 平台用户不校验
+//@param: tenantID uint
+//@return: err error
+
+func (tenantService *TenantService) CheckTenant(tenantID uint) error {
+	if tenantID == 0 {
+		return nil
+	}
+	var tenant system.SysTenant
+	err := global.GVA_DB.Select("id", "enable", "expires_at").First(&tenant, tenantID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTenantDisabled
+	}
+	if err != nil {
+		return err
+	}
+	if tenant.Enable != 1 || (tenant.ExpiresAt != nil && !time.Now().Before(*tenant.ExpiresAt)) {
+		return ErrTenantDisabled
+	}
+	return nil
+}
+
+//@function: CheckUsers
+//@description: 校验用户均属于该租户 平台用户可以操作所有租户的用户
+//@param: tenantID uint, ids ...uint
+//@return: err error
+
+func (tenantService *TenantService) CheckUsers(tenantID uint, ids ...uint) error {
+	return tenantService.checkOwned(&system.SysUser{}, "id", tenantID, ids)
+}
+
+//@function: CheckAuthorities
+//@description: 校验角色均属于该租户 平台用户可以操作所有租户的角色
+//@param: tenantID uint, ids ...uint
+//@return: err error
+
+func (tenantService *TenantService) CheckAuthorities(tenantID uint, ids ...uint) error {
+	return tenantService.checkOwned(&system.SysAuthority{}, "authority_id", tenantID, ids)
+}
+
+// checkOwned 统计属于该租户的记录数 与去重后的ID数一致时均属于该租户
+func (tenantService *TenantService) checkOwned(model any, column string, tenantID uint, ids []uint) error {
+	if tenantID == 0 {
+		return nil
+	}
+	unique := make(map[uint]struct{}, len(ids))
+	for _, id := range ids {
+		if id != 0 {
+			unique[id] = struct{}{}
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	var count int64
+	err := global.GVA_DB.Model(model).Where(column+" IN ? AND tenant_id = ?", ids, tenantID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if int(count) != len(unique) {
+		return ErrTenantForbidden
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package system
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils/autocode"
+)
+
+func TestTenantService(t *testing.T) {
+	user := setupUserTest(t)
+	if err := global.GVA_DB.AutoMigrate(&system.SysTenant{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	service := TenantServiceApp
+	past := time.Now().Add(-time.Hour)
+	tenants := []system.SysTenant{
+		{Name: "a", Code: "a", Enable: 1},
+		{Name: "b", Code: "b", Enable: 2},
+		{Name: "c", Code: "c", Enable: 1, ExpiresAt: &past},
+	}
+	global.GVA_DB.Create(&tenants)
+
+	if err := service.CheckTenant(0); err != nil {
+		t.Errorf("platform user = %v", err)
+	}
+	if err := service.CheckTenant(tenants[0].ID); err != nil {
+		t.Errorf("enabled tenant = %v", err)
+	}
+	for _, id := range []uint{tenants[1].ID, tenants[2].ID, 99} {
+		if err := service.CheckTenant(id); !errors.Is(err, ErrTenantDisabled) {
+			t.Errorf("tenant %d = %v, want ErrTenantDisabled", id, err)
+		}
+	}
+
+	tenantUser := system.SysUser{Username: "t", AuthorityId: 9001, Enable: 1, GVA_TENANT: global.GVA_TENANT{TenantID: tenants[0].ID}}
+	global.GVA_DB.Create(&tenantUser)
+	global.GVA_DB.Create(&system.SysAuthority{AuthorityId: 9001, AuthorityName: "t", ParentId: utils.Pointer[uint](0), GVA_TENANT: global.GVA_TENANT{TenantID: tenants[0].ID}})
+	global.GVA_DB.Create(&system.SysAuthority{AuthorityId: 888, AuthorityName: "admin", ParentId: utils.Pointer[uint](0)})
+
+	// 平台用户可以操作所有租户 租户管理员只能操作本租户
+	if err := service.CheckUsers(0, user.ID, tenantUser.ID); err != nil {
+		t.Errorf("platform CheckUsers = %v", err)
+	}
+	if err := service.CheckUsers(tenants[0].ID, tenantUser.ID, tenantUser.ID); err != nil {
+		t.Errorf("own user = %v", err)
+	}
+	if err := service.CheckUsers(tenants[0].ID, tenantUser.ID, user.ID); !errors.Is(err, ErrTenantForbidden) {
+		t.Errorf("platform user from tenant = %v, want ErrTenantForbidden", err)
+	}
+	if err := service.CheckAuthorities(tenants[0].ID, 9001, 0); err != nil {
+		t.Errorf("own authority = %v", err)
+	}
+	if err := service.CheckAuthorities(tenants[0].ID, 888); !errors.Is(err, ErrTenantForbidden) {
+		t.Errorf("platform authority from tenant = %v, want ErrTenantForbidden", err)
+	}
+
+	if err := service.DeleteTenant(tenants[0].ID); err == nil {
+		t.Error("tenant with users should not be deleted")
+	}
+	list, total, err := service.GetTenantList(request.GetTenantList{Name: "a"})
+	if err != nil || total != 1 || len(list) != 1 || list[0].Code != "a" {
+		t.Errorf("GetTenantList = %+v, %d, %v", list, total, err)
+	}
+}
+
+func TestTenantAutoCodeTemplate(t *testing.T) {
+	info := request.AutoCode{
+		Package:          "demo",
+		StructName:       "Order",
+		Abbreviation:     "order",
+		Module:           "github.com/flipped-aurora/gin-vue-admin/server",
+		GvaModel:         true,
+		AutoCreateTenant: true,
+		PrimaryField:     &request.AutoCodeField{FieldName: "ID", FieldJson: "ID", ColumnName: "id"},
+	}
+	render := func(path string) string {
+		t.Helper()
+		tmpl, err := template.New(filepath.Base(path)).Funcs(autocode.GetTemplateFuncMap()).ParseFiles(path)
+		if err != nil {
+			t.Fatalf("parse %s: %v", path, err)
+		}
+		var builder strings.Builder
+		if err = tmpl.Execute(&builder, info); err != nil {
+			t.Fatalf("execute %s: %v", path, err)
+		}
+		return builder.String()
+	}
+	for _, dir := range []string{"package", "plugin"} {
+		root := filepath.Join("..", "..", "resource", dir, "server")
+		if model := render(filepath.Join(root, "model", "model.go.tpl")); !strings.Contains(model, "global.GVA_TENANT") {
+			t.Errorf("%s model should embed the tenant column:\n%s", dir, model)
+		}
+		if service := render(filepath.Join(root, "service", "service.go.tpl")); !strings.Contains(service, "global.GVA_DB.WithContext(ctx).Create(") {
+			t.Errorf("%s service should pass the request context:\n%s", dir, service)
+		}
+	}
+}
@@ -30,10 +30,21 @@ func (userService *UserService) Register(u system.SysUser) (userInter system.Sys
 	if !errors.Is(global.GVA_DB.Where("username = ?", u.Username).First(&user).Error, gorm.ErrRecordNotFound) { // 判断用户名是否注册
 		return userInter, errors.New("用户名已注册")
 	}
+	if err = userService.checkNewPassword(global.GVA_DB, u, u.Password); err != nil {
+		return userInter, err
+	}
 	// 否则 附加uuid 密码hash加密 注册
+	now := time.Now()
 	u.Password = utils.BcryptHash(u.Password)
 	u.UUID = uuid.New()
-	err = global.GVA_DB.Create(&u).Error
+	u.PasswordChangedAt = &now
+	u.MustChangePassword = global.GVA_CONFIG.PasswordPolicy.ForceChangeFirstLogin
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&u).Error; err != nil {
+			return err
+		}
+		return userService.addPasswordHistory(tx, u.ID, u.Password)
+	})
 	return u, err
 }
 
@@ -49,15 +60,13 @@ func (userService *UserService) Login(u *system.SysUser) (userInter *system.SysU
 		return nil, fmt.Errorf("db not init")
 	}
 
-	var user system.SysUser
-	err = global.GVA_DB.Where("username = ?", u.Username).Preload("Authorities").Preload("Authority").First(&user).Error
-	if err == nil {
-		if ok := utils.BcryptCheck(u.Password, user.Password); !ok {
-			return nil, errors.New("密码错误")
-		}
-		MenuServiceApp.UserAuthorityDefaultRouter(&user)
+	// 按配置顺序依次尝试认证方式
+	user, err := authenticate(u.Username, u.Password)
+	if err != nil {
+		return nil, err
 	}
-	return &user, err
+	MenuServiceApp.UserAuthorityDefaultRouter(user)
+	return user, nil
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -68,16 +77,19 @@ func (userService *UserService) Login(u *system.SysUser) (userInter *system.SysU
 
 func (userService *UserService) ChangePassword(u *system.SysUser, newPassword string) (err error) {
 	var user system.SysUser
-	err = global.GVA_DB.Select("id, password").Where("id = ?", u.ID).First(&user).Error
+	err = global.GVA_DB.Select("id, username, password").Where("id = ?", u.ID).First(&user).Error
 	if err != nil {
 		return err
 	}
 	if ok := utils.BcryptCheck(u.Password, user.Password); !ok {
-		return errors.New("原密码错误")
+		return ErrOldPasswordMismatch
 	}
-	pwd := utils.BcryptHash(newPassword)
-	err = global.GVA_DB.Model(&user).Update("password", pwd).Error
-	return err
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := userService.checkNewPassword(tx, user, newPassword); err != nil {
+			return err
+		}
+		return userService.savePassword(tx, user.ID, newPassword, false)
+	})
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -104,6 +116,12 @@ func (userService *UserService) GetUserInfoList(info systemReq.GetUserList) (lis
 	if info.Email != "" {
 		db = db.Where("email LIKE ?", "%"+info.Email+"%")
 	}
+	if info.DeptId != 0 {
+		db = db.Where("dept_id = ?", info.DeptId)
+	}
+	if info.TenantID != nil {
+		db = db.Where("tenant_id = ?", *info.TenantID)
+	}
 
 	err = db.Count(&total).Error
 	if err != nil {
@@ -194,6 +212,11 @@ func (userService *UserService) SetUserAuthorities(adminAuthorityID, id uint, au
 		if TxErr != nil {
 			return TxErr
 		}
+		// 临时授权的角色保留至到期
+		TxErr = AuthorityGrantServiceApp.retainActiveGrants(tx, id, authorityIds)
+		if TxErr != nil {
+			return TxErr
+		}
 		TxErr = tx.Model(&user).Update("authority_id", authorityIds[0]).Error
 		if TxErr != nil {
 			return TxErr
@@ -210,15 +233,32 @@ func (userService *UserService) SetUserAuthorities(adminAuthorityID, id uint, au
 //@return: err error
 
 func (userService *UserService) DeleteUser(id int) (err error) {
-	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("id = ?", id).Delete(&system.SysUser{}).Error; err != nil {
 			return err
 		}
 		if err := tx.Delete(&[]system.SysUserAuthority{}, "sys_user_id = ?", id).Error; err != nil {
 			return err
 		}
+		if err := tx.Unscoped().Delete(&[]system.SysUserTotp{}, "user_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&[]system.SysUserRecoveryCode{}, "user_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&[]system.SysUserIdentity{}, "user_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&[]system.SysUserToken{}, "user_id = ?", id).Error; err != nil {
+			return err
+		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	// 被删除用户已登录的会话立即失效
+	return UserSessionServiceApp.RevokeUserSessions(uint(id))
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -228,8 +268,8 @@ func (userService *UserService) DeleteUser(id int) (err error) {
 //@return: err error, user model.SysUser
 
 func (userService *UserService) SetUserInfo(req system.SysUser) error {
-	return global.GVA_DB.Model(&system.SysUser{}).
-		Select("updated_at", "nick_name", "header_img", "phone", "email", "enable").
+	err := global.GVA_DB.Model(&system.SysUser{}).
+		Select("updated_at", "nick_name", "header_img", "phone", "email", "enable", "dept_id").
 		Where("id=?", req.ID).
 		Updates(map[string]interface{}{
 			"updated_at": time.Now(),
@@ -238,7 +278,13 @@ func (userService *UserService) SetUserInfo(req system.SysUser) error {
 			"phone":      req.Phone,
 			"email":      req.Email,
 			"enable":     req.Enable,
+			"dept_id":    req.DeptId,
 		}).Error
+	if err != nil || req.Enable != 2 {
+		return err
+	}
+	// 用户被禁用后 已登录的会话立即失效
+	return UserSessionServiceApp.RevokeUserSessions(req.ID)
 }
 
 //@author: [piexlmax](https://github.com/piexlmax)
@@ -313,6 +359,14 @@ func (userService *UserService) FindUserByUuid(uuid string) (user *system.SysUse
 //@return: err error
 
 func (userService *UserService) ResetPassword(ID uint, password string) (err error) {
-	err = global.GVA_DB.Model(&system.SysUser{}).Where("id = ?", ID).Update("password", utils.BcryptHash(password)).Error
-	return err
+	var user system.SysUser
+	if err = global.GVA_DB.Select("id, username, password").Where("id = ?", ID).First(&user).Error; err != nil {
+		return err
+	}
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := userService.checkNewPassword(tx, user, password); err != nil {
+			return err
+		}
+		return userService.savePassword(tx, user.ID, password, global.GVA_CONFIG.PasswordPolicy.ForceChangeAfterReset)
+	})
 }
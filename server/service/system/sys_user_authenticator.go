@@ -0,0 +1,129 @@
+package system
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+// 内置的认证方式名称
+const (
+	DBAuthenticatorName   = "db"
+	LdapAuthenticatorName = "ldap"
+)
+
+var (
+	// ErrAuthUserNotFound 用户不属于该认证方式 继续尝试下一个认证方式
+	ErrAuthUserNotFound = errors.New("用户不存在")
+	// ErrAuthPasswordMismatch 用户存在但密码错误 不再尝试其他认证方式
+	ErrAuthPasswordMismatch = errors.New("密码错误")
+	// ErrAuthUnavailable 认证方式暂不可用 继续尝试下一个认证方式
+	ErrAuthUnavailable = errors.New("认证服务不可用")
+)
+
+// Authenticator 用户名密码登录的认证方式
+type Authenticator interface {
+	// Authenticate 校验用户名密码 返回对应的本地用户
+	// 用户不属于该认证方式时返回 ErrAuthUserNotFound 服务不可用时返回包装 ErrAuthUnavailable 的错误
+	Authenticate(username, password string) (*system.SysUser, error)
+	// ManagesPassword 是否使用本地密码 为false时不检查本地密码的有效期
+	ManagesPassword() bool
+}
+
+var (
+	authenticatorsMu sync.RWMutex
+	authenticators   = map[string]Authenticator{
+		DBAuthenticatorName:   dbAuthenticator{},
+		LdapAuthenticatorName: ldapAuthenticator{},
+	}
+)
+
+// RegisterAuthenticator 注册认证方式 插件可以注册自定义的认证方式 并在配置 authenticators 中启用
+func RegisterAuthenticator(name string, authenticator Authenticator) {
+	authenticatorsMu.Lock()
+	defer authenticatorsMu.Unlock()
+	authenticators[name] = authenticator
+}
+
+// loginAuthenticators 按配置顺序返回启用的认证方式
+func loginAuthenticators() []Authenticator {
+	names := global.GVA_CONFIG.Authenticators
+	if len(names) == 0 {
+		names = []string{LdapAuthenticatorName, DBAuthenticatorName}
+	}
+	authenticatorsMu.RLock()
+	defer authenticatorsMu.RUnlock()
+	list := make([]Authenticator, 0, len(names))
+	for _, name := range names {
+		authenticator, ok := authenticators[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			global.GVA_LOG.Warn("未知的认证方式!", zap.String("name", name))
+			continue
+		}
+		list = append(list, authenticator)
+	}
+	return list
+}
+
+// authenticate 依次尝试认证方式 用户不存在或服务不可用时尝试下一个 其他错误直接返回
+func authenticate(username, password string) (*system.SysUser, error) {
+	err := ErrAuthUserNotFound
+	for _, authenticator := range loginAuthenticators() {
+		user, authErr := authenticator.Authenticate(username, password)
+		if authErr == nil {
+			if !authenticator.ManagesPassword() {
+				// 密码由外部认证方式管理 不检查本地密码状态
+				user.PasswordChangedAt = nil
+				user.MustChangePassword = false
+			}
+			return user, nil
+		}
+		if errors.Is(authErr, ErrAuthUnavailable) {
+			global.GVA_LOG.Warn("认证服务不可用, 尝试下一个认证方式!", zap.Error(authErr))
+			err = authErr
+			continue
+		}
+		if !errors.Is(authErr, ErrAuthUserNotFound) {
+			return nil, authErr
+		}
+	}
+	return nil, err
+}
+
+// dbAuthenticator 校验数据库中的bcrypt密码
+type dbAuthenticator struct{}
+
+func (dbAuthenticator) Authenticate(username, password string) (*system.SysUser, error) {
+	var user system.SysUser
+	err := global.GVA_DB.Where("username = ?", username).Preload("Authorities").Preload("Authority").First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAuthUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ok := utils.BcryptCheck(password, user.Password); !ok {
+		return nil, ErrAuthPasswordMismatch
+	}
+	// 启用密码有效期之前创建的用户 从首次登录开始计算有效期
+	if user.PasswordChangedAt == nil {
+		now := time.Now()
+		if err = global.GVA_DB.Model(&user).UpdateColumn("password_changed_at", now).Error; err != nil {
+			return nil, err
+		}
+		user.PasswordChangedAt = &now
+	}
+	return &user, nil
+}
+
+func (dbAuthenticator) ManagesPassword() bool {
+	return true
+}
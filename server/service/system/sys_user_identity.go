@@ -0,0 +1,238 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+var (
+	ErrNoMappedAuthority    = errors.New("未分配可用的角色, 请联系管理员")
+	ErrExternalUserNotFound = errors.New("用户不存在, 请联系管理员开通账号")
+	ErrUsernameTaken        = errors.New("用户名已被本地用户占用, 请联系管理员")
+)
+
+// externalUser 外部身份提供方认证通过的用户
+type externalUser struct {
+	Provider     string // 身份提供方 如oidc ldap
+	Subject      string // 身份提供方中的用户唯一标识
+	Username     string
+	NickName     string
+	Email        string
+	Phone        string
+	AuthorityIDs []uint // 按规则映射的角色
+}
+
+// externalUserPolicy 外部用户的关联与同步策略
+type externalUserPolicy struct {
+	AutoCreate      bool // 首次登录时自动创建用户
+	LinkExisting    bool // 首次登录时按用户名关联已有的本地用户
+	SyncAuthorities bool // 每次登录时更新角色
+	SyncProfile     bool // 每次登录时更新昵称、邮箱、电话
+}
+
+// loginExternalUser 按外部身份查找关联的用户 首次登录时按策略关联或创建 返回含角色信息的用户
+func loginExternalUser(ext externalUser, policy externalUserPolicy) (user system.SysUser, err error) {
+	var userID uint
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		var identity system.SysUserIdentity
+		err := tx.Where("provider = ? AND subject = ?", ext.Provider, ext.Subject).First(&identity).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			userID, err = provisionExternalUser(tx, ext, policy)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		userID = identity.UserID
+		return syncExternalUser(tx, userID, ext, policy)
+	})
+	if err != nil {
+		return
+	}
+	err = global.GVA_DB.Where("id = ?", userID).Preload("Authorities").Preload("Authority").First(&user).Error
+	return
+}
+
+// provisionExternalUser 首次登录 关联同名的本地用户或创建新用户
+func provisionExternalUser(tx *gorm.DB, ext externalUser, policy externalUserPolicy) (uint, error) {
+	username := ext.Username
+	if username == "" {
+		username = ext.Subject
+	}
+	var user system.SysUser
+	err := tx.Where("username = ?", username).First(&user).Error
+	switch {
+	case err == nil:
+		if !policy.LinkExisting {
+			return 0, ErrUsernameTaken
+		}
+		if err = syncExternalUser(tx, user.ID, ext, policy); err != nil {
+			return 0, err
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if !policy.AutoCreate {
+			return 0, ErrExternalUserNotFound
+		}
+		if len(ext.AuthorityIDs) == 0 {
+			return 0, ErrNoMappedAuthority
+		}
+		// 外部用户不使用本地密码 设置随机密码且不计算密码有效期
+		password, err := utils.OidcRandom(24)
+		if err != nil {
+			return 0, err
+		}
+		user = system.SysUser{
+			UUID:        uuid.New(),
+			Username:    username,
+			NickName:    ext.NickName,
+			Email:       ext.Email,
+			Phone:       ext.Phone,
+			Password:    utils.BcryptHash(password),
+			AuthorityId: ext.AuthorityIDs[0],
+			Enable:      1,
+		}
+		if user.NickName == "" {
+			user.NickName = username
+		}
+		for _, id := range ext.AuthorityIDs {
+			user.Authorities = append(user.Authorities, system.SysAuthority{AuthorityId: id})
+		}
+		if err = tx.Create(&user).Error; err != nil {
+			return 0, err
+		}
+	default:
+		return 0, err
+	}
+	return user.ID, tx.Create(&system.SysUserIdentity{UserID: user.ID, Provider: ext.Provider, Subject: ext.Subject}).Error
+}
+
+// syncExternalUser 按策略将外部身份的角色和资料同步到本地用户
+func syncExternalUser(tx *gorm.DB, userID uint, ext externalUser, policy externalUserPolicy) error {
+	if policy.SyncAuthorities {
+		if len(ext.AuthorityIDs) == 0 {
+			return ErrNoMappedAuthority
+		}
+		if err := syncUserAuthorities(tx, userID, ext.AuthorityIDs); err != nil {
+			return err
+		}
+	}
+	if !policy.SyncProfile {
+		return nil
+	}
+	profile := map[string]interface{}{"email": ext.Email, "phone": ext.Phone}
+	if ext.NickName != "" {
+		profile["nick_name"] = ext.NickName
+	}
+	return tx.Model(&system.SysUser{}).Where("id = ?", userID).Updates(profile).Error
+}
+
+// syncUserAuthorities 将用户角色更新为映射结果 当前角色仍在其中时保留
+func syncUserAuthorities(tx *gorm.DB, userID uint, authorityIDs []uint) error {
+	var current []uint
+	if err := tx.Model(&system.SysUserAuthority{}).Where("sys_user_id = ?", userID).Pluck("sys_authority_authority_id", &current).Error; err != nil {
+		return err
+	}
+	sorted := slices.Clone(authorityIDs)
+	slices.Sort(sorted)
+	slices.Sort(current)
+	if slices.Equal(sorted, current) {
+		return nil
+	}
+	if err := tx.Delete(&[]system.SysUserAuthority{}, "sys_user_id = ?", userID).Error; err != nil {
+		return err
+	}
+	rows := make([]system.SysUserAuthority, 0, len(authorityIDs))
+	for _, id := range authorityIDs {
+		rows = append(rows, system.SysUserAuthority{SysUserId: userID, SysAuthorityAuthorityId: id})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return err
+	}
+	return tx.Model(&system.SysUser{}).
+		Where("id = ? AND authority_id NOT IN ?", userID, authorityIDs).
+		Update("authority_id", authorityIDs[0]).Error
+}
+
+// mapAuthorityIDs 按规则将claim映射为角色ID 未命中时使用默认角色 只保留存在的角色
+func mapAuthorityIDs(rules []config.AuthorityRule, defaultID uint, claims map[string]interface{}) ([]uint, error) {
+	var ids []uint
+	for _, rule := range rules {
+		claim := rule.Claim
+		if claim == "" {
+			claim = "groups"
+		}
+		values := claimValues(claims, claim)
+		matched := slices.ContainsFunc(rule.Values, func(want string) bool {
+			return (want == "*" && len(values) > 0) || slices.Contains(values, want)
+		})
+		if !matched {
+			continue
+		}
+		for _, id := range rule.AuthorityIDs {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 && defaultID != 0 {
+		ids = []uint{defaultID}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var exist []uint
+	if err := global.GVA_DB.Model(&system.SysAuthority{}).Where("authority_id IN ?", ids).Pluck("authority_id", &exist).Error; err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(ids, func(id uint) bool { return !slices.Contains(exist, id) }), nil
+}
+
+// claimValues 读取claim的值 支持以.分隔的嵌套路径 如 realm_access.roles
+func claimValues(claims map[string]interface{}, path string) []string {
+	var value interface{} = claims
+	for _, segment := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if value, ok = m[segment]; !ok {
+			return nil
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	case nil:
+		return nil
+	}
+	return []string{fmt.Sprint(value)}
+}
+
+// claimString 读取claim的第一个值 claim为空时使用默认名称
+func claimString(claims map[string]interface{}, claim, fallback string) string {
+	if claim == "" {
+		claim = fallback
+	}
+	if values := claimValues(claims, claim); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
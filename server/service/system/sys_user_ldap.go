@@ -0,0 +1,234 @@
+package system
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"go.uber.org/zap"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+// ldapConn LDAP连接中使用到的操作 便于测试替换
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// dialLdap 按配置建立LDAP连接
+var dialLdap = func(cfg config.LDAP) (ldapConn, error) {
+	timeout, _ := utils.ParseDuration(cfg.Timeout)
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	conn, err := ldap.DialURL(cfg.URL, ldap.DialWithDialer(&net.Dialer{Timeout: timeout}), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(timeout)
+	if cfg.StartTLS {
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// ldapAuthenticator 通过LDAP绑定校验密码 首次登录时按配置关联或创建本地用户
+type ldapAuthenticator struct{}
+
+func (ldapAuthenticator) Authenticate(username, password string) (*system.SysUser, error) {
+	cfg := global.GVA_CONFIG.LDAP
+	if !cfg.Enable {
+		return nil, ErrAuthUserNotFound
+	}
+	conn, err := openLdap(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	entry, err := searchLdapUser(conn, cfg, username)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrAuthUserNotFound
+	}
+	// 空密码会被服务端视为匿名绑定而成功
+	if password == "" {
+		return nil, ErrAuthPasswordMismatch
+	}
+	if err = conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrAuthPasswordMismatch
+		}
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+	ext, err := ldapExternalUser(cfg, entry)
+	if err != nil {
+		return nil, err
+	}
+	user, err := loginExternalUser(ext, externalUserPolicy{
+		AutoCreate:      cfg.AutoCreate,
+		LinkExisting:    cfg.LinkExisting,
+		SyncAuthorities: cfg.SyncAuthorities,
+		SyncProfile:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (ldapAuthenticator) ManagesPassword() bool {
+	return false
+}
+
+//@function: SyncLdapUsers
+//@description: 将已关联LDAP的用户的昵称、邮箱、电话同步到本地 开启角色同步时同时更新角色
+//@return: synced int, err error
+
+func (userService *UserService) SyncLdapUsers() (synced int, err error) {
+	cfg := global.GVA_CONFIG.LDAP
+	if !cfg.Enable {
+		return 0, nil
+	}
+	var identities []system.SysUserIdentity
+	if err = global.GVA_DB.Where("provider = ?", LdapAuthenticatorName).Find(&identities).Error; err != nil || len(identities) == 0 {
+		return
+	}
+	conn, err := openLdap(cfg)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	policy := externalUserPolicy{SyncAuthorities: cfg.SyncAuthorities, SyncProfile: true}
+	for _, identity := range identities {
+		entry, err := searchLdapUser(conn, cfg, identity.Subject)
+		if err != nil {
+			return synced, err
+		}
+		if entry == nil {
+			global.GVA_LOG.Warn("LDAP中未找到用户, 跳过同步!", zap.String("subject", identity.Subject))
+			continue
+		}
+		ext, err := ldapExternalUser(cfg, entry)
+		if err != nil {
+			return synced, err
+		}
+		if err = syncExternalUser(global.GVA_DB, identity.UserID, ext, policy); err != nil {
+			global.GVA_LOG.Error("同步LDAP用户失败!", zap.String("subject", identity.Subject), zap.Error(err))
+			continue
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+// openLdap 建立连接并使用服务账号绑定 连接或绑定失败视为服务不可用
+func openLdap(cfg config.LDAP) (ldapConn, error) {
+	conn, err := dialLdap(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+	if cfg.BindDN != "" {
+		if err = conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+		}
+	}
+	return conn, nil
+}
+
+// searchLdapUser 按用户名查找用户 未找到时返回nil 匹配到多个用户时视为错误
+func searchLdapUser(conn ldapConn, cfg config.LDAP, username string) (*ldap.Entry, error) {
+	filter := cfg.UserFilter
+	if filter == "" {
+		filter = "(&(objectClass=person)(uid=%s))"
+	}
+	result, err := conn.Search(ldap.NewSearchRequest(
+		cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(filter, ldap.EscapeFilter(username)),
+		[]string{"dn", ldapAttribute(cfg.UsernameAttribute, "uid"), ldapAttribute(cfg.NickNameAttribute, "displayName"),
+			ldapAttribute(cfg.EmailAttribute, "mail"), ldapAttribute(cfg.PhoneAttribute, "telephoneNumber"),
+			ldapAttribute(cfg.GroupAttribute, "memberOf")},
+		nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+	switch len(result.Entries) {
+	case 0:
+		return nil, nil
+	case 1:
+		return result.Entries[0], nil
+	}
+	return nil, errors.New("LDAP中存在多个同名用户, 请检查 user-filter 配置")
+}
+
+// ldapExternalUser 读取用户属性 并将所属组的CN作为groups按规则映射角色
+func ldapExternalUser(cfg config.LDAP, entry *ldap.Entry) (externalUser, error) {
+	username := strings.TrimSpace(entry.GetAttributeValue(ldapAttribute(cfg.UsernameAttribute, "uid")))
+	if username == "" {
+		return externalUser{}, errors.New("LDAP用户缺少用户名属性")
+	}
+	groupAttribute := ldapAttribute(cfg.GroupAttribute, "memberOf")
+	var groups []string
+	for _, group := range entry.GetAttributeValues(groupAttribute) {
+		groups = append(groups, ldapGroupName(group))
+	}
+	claims := map[string]interface{}{"dn": entry.DN, "groups": groups, groupAttribute: entry.GetAttributeValues(groupAttribute)}
+	for _, attribute := range entry.Attributes {
+		if _, exist := claims[attribute.Name]; !exist {
+			claims[attribute.Name] = attribute.Values
+		}
+	}
+	authorityIDs, err := mapAuthorityIDs(cfg.Rules, cfg.DefaultAuthorityID, claims)
+	if err != nil {
+		return externalUser{}, err
+	}
+	return externalUser{
+		Provider:     LdapAuthenticatorName,
+		Subject:      strings.ToLower(username),
+		Username:     username,
+		NickName:     strings.TrimSpace(entry.GetAttributeValue(ldapAttribute(cfg.NickNameAttribute, "displayName"))),
+		Email:        strings.TrimSpace(entry.GetAttributeValue(ldapAttribute(cfg.EmailAttribute, "mail"))),
+		Phone:        strings.TrimSpace(entry.GetAttributeValue(ldapAttribute(cfg.PhoneAttribute, "telephoneNumber"))),
+		AuthorityIDs: authorityIDs,
+	}, nil
+}
+
+// ldapGroupName 组DN取第一个CN 不是DN时原样返回
+func ldapGroupName(group string) string {
+	dn, err := ldap.ParseDN(group)
+	if err != nil || len(dn.RDNs) == 0 {
+		return group
+	}
+	for _, attr := range dn.RDNs[0].Attributes {
+		if strings.EqualFold(attr.Type, "cn") {
+			return attr.Value
+		}
+	}
+	return group
+}
+
+func ldapAttribute(attribute, fallback string) string {
+	if attribute == "" {
+		return fallback
+	}
+	return attribute
+}
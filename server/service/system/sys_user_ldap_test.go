@@ -0,0 +1,151 @@
+package system
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+// fakeLdap 内存中的目录 密码按DN保存
+type fakeLdap struct {
+	entries   []*ldap.Entry
+	passwords map[string]string
+	down      bool
+}
+
+func (f *fakeLdap) Bind(username, password string) error {
+	if f.passwords[username] != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	return nil
+}
+
+func (f *fakeLdap) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	result := &ldap.SearchResult{}
+	for _, entry := range f.entries {
+		if strings.Contains(req.Filter, "(uid="+entry.GetAttributeValue("uid")+")") {
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeLdap) Close() error { return nil }
+
+func (f *fakeLdap) add(uid, password string, attributes map[string][]string) {
+	dn := "uid=" + uid + ",ou=people,dc=example,dc=com"
+	attributes["uid"] = []string{uid}
+	f.entries = append(f.entries, ldap.NewEntry(dn, attributes))
+	f.passwords[dn] = password
+}
+
+func setupLdapTest(t *testing.T) *fakeLdap {
+	t.Helper()
+	user := setupUserTest(t)
+	if err := global.GVA_DB.AutoMigrate(&system.SysUserIdentity{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	global.GVA_DB.Model(&user).Update("password", utils.BcryptHash("123456"))
+	for _, id := range []uint{888, 9528} {
+		global.GVA_DB.Create(&system.SysAuthority{AuthorityId: id, AuthorityName: "role"})
+	}
+	directory := &fakeLdap{passwords: map[string]string{"cn=svc,dc=example,dc=com": "svc"}}
+	directory.add("alice", "ldap-pass", map[string][]string{
+		"displayName": {"Alice"},
+		"mail":        {"alice@example.com"},
+		"memberOf":    {"cn=gva-admins,ou=groups,dc=example,dc=com"},
+	})
+	dial := dialLdap
+	dialLdap = func(config.LDAP) (ldapConn, error) {
+		if directory.down {
+			return nil, errors.New("connection refused")
+		}
+		return directory, nil
+	}
+	global.GVA_CONFIG.Authenticators = []string{LdapAuthenticatorName, DBAuthenticatorName}
+	global.GVA_CONFIG.LDAP = config.LDAP{
+		Enable:          true,
+		BindDN:          "cn=svc,dc=example,dc=com",
+		BindPassword:    "svc",
+		AutoCreate:      true,
+		SyncAuthorities: true,
+		Rules:           []config.AuthorityRule{{Values: []string{"gva-admins"}, AuthorityIDs: []uint{888}}},
+	}
+	t.Cleanup(func() {
+		dialLdap = dial
+		global.GVA_CONFIG.Authenticators = nil
+		global.GVA_CONFIG.LDAP = config.LDAP{}
+	})
+	return directory
+}
+
+func TestUserService_LoginLdap(t *testing.T) {
+	directory := setupLdapTest(t)
+	service := UserServiceApp
+
+	user, err := service.Login(&system.SysUser{Username: "alice", Password: "ldap-pass"})
+	if err != nil {
+		t.Fatalf("ldap login: %v", err)
+	}
+	if user.NickName != "Alice" || user.Email != "alice@example.com" || user.AuthorityId != 888 || user.PasswordChangedAt != nil {
+		t.Fatalf("unexpected provisioned user: %+v", user)
+	}
+	// 密码错误时不回退到数据库认证
+	if _, err = service.Login(&system.SysUser{Username: "alice", Password: "wrong"}); !errors.Is(err, ErrAuthPasswordMismatch) {
+		t.Errorf("wrong ldap password = %v, want ErrAuthPasswordMismatch", err)
+	}
+	if _, err = service.Login(&system.SysUser{Username: "alice", Password: ""}); !errors.Is(err, ErrAuthPasswordMismatch) {
+		t.Errorf("empty password = %v, want ErrAuthPasswordMismatch", err)
+	}
+
+	// 目录中不存在的用户回退到数据库认证
+	if _, err = service.Login(&system.SysUser{Username: "test", Password: "123456"}); err != nil {
+		t.Errorf("db fallback: %v", err)
+	}
+	if _, err = service.Login(&system.SysUser{Username: "nobody", Password: "123456"}); !errors.Is(err, ErrAuthUserNotFound) {
+		t.Errorf("unknown user = %v, want ErrAuthUserNotFound", err)
+	}
+
+	// LDAP不可用时同样回退
+	directory.down = true
+	if _, err = service.Login(&system.SysUser{Username: "test", Password: "123456"}); err != nil {
+		t.Errorf("db fallback while ldap down: %v", err)
+	}
+	if _, err = service.Login(&system.SysUser{Username: "alice", Password: "ldap-pass"}); err == nil {
+		t.Error("ldap user should not log in with the local random password while ldap is down")
+	}
+}
+
+func TestUserService_SyncLdapUsers(t *testing.T) {
+	directory := setupLdapTest(t)
+	service := UserServiceApp
+	user, err := service.Login(&system.SysUser{Username: "alice", Password: "ldap-pass"})
+	if err != nil {
+		t.Fatalf("ldap login: %v", err)
+	}
+
+	entry := directory.entries[0]
+	entry.Attributes = ldap.NewEntry(entry.DN, map[string][]string{
+		"uid":             {"alice"},
+		"displayName":     {"Alice Smith"},
+		"mail":            {"alice.smith@example.com"},
+		"telephoneNumber": {"13800000000"},
+		"memberOf":        {"cn=gva-admins,ou=groups,dc=example,dc=com"},
+	}).Attributes
+	synced, err := service.SyncLdapUsers()
+	if err != nil || synced != 1 {
+		t.Fatalf("SyncLdapUsers = %d, %v", synced, err)
+	}
+	var got system.SysUser
+	global.GVA_DB.First(&got, user.ID)
+	if got.NickName != "Alice Smith" || got.Email != "alice.smith@example.com" || got.Phone != "13800000000" {
+		t.Errorf("attributes not synced: %+v", got)
+	}
+}
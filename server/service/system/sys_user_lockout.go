@@ -0,0 +1,233 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+const (
+	loginFailuresKeyPrefix = "login_failures:"
+	loginLockKeyPrefix     = "login_lock:"
+)
+
+// ErrUserLocked 账号因连续输错密码被锁定
+var ErrUserLocked = errors.New("账号已被锁定")
+
+// loginLockoutMu 串行化本地缓存中的计数更新 Redis模式下由脚本保证原子性
+var loginLockoutMu sync.Mutex
+
+// loginLock 账号锁定状态
+type loginLock struct {
+	Locks int       // 保留期内的锁定次数
+	Until time.Time // 锁定截止时间
+}
+
+// loginLockoutPolicy 解析后的锁定策略
+type loginLockoutPolicy struct {
+	maxAttempts int
+	window      time.Duration
+	duration    time.Duration
+	maxDuration time.Duration
+	resetAfter  time.Duration
+}
+
+// LoginFailure 记录一次登录失败的结果
+type LoginFailure struct {
+	Failures    int       // 当前窗口内的连续失败次数 锁定后清零
+	Locked      bool      // 本次失败是否触发锁定
+	LockedUntil time.Time // 锁定截止时间
+}
+
+// recordLoginFailureScript 原子地累加失败次数 达到上限时按锁定次数翻倍计算锁定时长
+// KEYS: 失败计数key 锁定key ARGV: 当前毫秒 最大次数 窗口 首次锁定时长 最长锁定时长 锁定次数保留时长
+var recordLoginFailureScript = redis.NewScript(`
+local failures = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+if failures < tonumber(ARGV[2]) then
+	return {failures, 0}
+end
+redis.call('DEL', KEYS[1])
+local locks = redis.call('HINCRBY', KEYS[2], 'locks', 1)
+local d = math.min(tonumber(ARGV[4]) * math.pow(2, locks - 1), tonumber(ARGV[5]))
+local untilMs = tonumber(ARGV[1]) + d
+redis.call('HSET', KEYS[2], 'until', untilMs)
+redis.call('PEXPIRE', KEYS[2], math.max(d, tonumber(ARGV[6])))
+return {failures, untilMs}
+`)
+
+//@function: CheckLoginLock
+//@description: 检查用户名是否处于锁定状态 锁定时返回 ErrUserLocked 和锁定截止时间
+//@param: username string
+//@return: lockedUntil time.Time, err error
+
+func (userService *UserService) CheckLoginLock(username string) (lockedUntil time.Time, err error) {
+	if _, ok := loginLockoutConfig(); !ok {
+		return
+	}
+	key := loginLockKeyPrefix + loginLockoutName(username)
+	if useRedisLoginLockout() {
+		var ms int64
+		ms, err = global.GVA_REDIS.HGet(context.Background(), key, "until").Int64()
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		if err != nil {
+			return
+		}
+		lockedUntil = time.UnixMilli(ms)
+	} else {
+		v, ok := global.BlackCache.Get(key)
+		if !ok {
+			return
+		}
+		lockedUntil = v.(loginLock).Until
+	}
+	if time.Now().Before(lockedUntil) {
+		return lockedUntil, ErrUserLocked
+	}
+	return time.Time{}, nil
+}
+
+//@function: RecordLoginFailure
+//@description: 记录一次密码错误 连续失败达到上限时锁定账号 每次锁定时长翻倍
+//@param: username string
+//@return: failure LoginFailure, err error
+
+func (userService *UserService) RecordLoginFailure(username string) (failure LoginFailure, err error) {
+	policy, ok := loginLockoutConfig()
+	if !ok {
+		return
+	}
+	name := loginLockoutName(username)
+	failuresKey, lockKey := loginFailuresKeyPrefix+name, loginLockKeyPrefix+name
+	now := time.Now()
+	if useRedisLoginLockout() {
+		var res []int64
+		res, err = recordLoginFailureScript.Run(context.Background(), global.GVA_REDIS, []string{failuresKey, lockKey},
+			now.UnixMilli(), policy.maxAttempts, policy.window.Milliseconds(), policy.duration.Milliseconds(),
+			policy.maxDuration.Milliseconds(), policy.resetAfter.Milliseconds()).Int64Slice()
+		if err != nil {
+			return
+		}
+		failure.Failures = int(res[0])
+		if res[1] > 0 {
+			failure.Failures = 0
+			failure.Locked = true
+			failure.LockedUntil = time.UnixMilli(res[1])
+		}
+		return
+	}
+
+	loginLockoutMu.Lock()
+	defer loginLockoutMu.Unlock()
+	failures := 1
+	if v, ok := global.BlackCache.Get(failuresKey); ok {
+		failures += v.(int)
+	}
+	if failures < policy.maxAttempts {
+		global.BlackCache.Set(failuresKey, failures, policy.window)
+		failure.Failures = failures
+		return
+	}
+	global.BlackCache.Delete(failuresKey)
+	lock := loginLock{Locks: 1}
+	if v, ok := global.BlackCache.Get(lockKey); ok {
+		lock.Locks += v.(loginLock).Locks
+	}
+	d := policy.lockDuration(lock.Locks)
+	lock.Until = now.Add(d)
+	global.BlackCache.Set(lockKey, lock, max(d, policy.resetAfter))
+	failure.Locked = true
+	failure.LockedUntil = lock.Until
+	return
+}
+
+//@function: ResetLoginFailures
+//@description: 登录成功后清除失败次数 锁定次数保留至保留期结束
+//@param: username string
+//@return: err error
+
+func (userService *UserService) ResetLoginFailures(username string) error {
+	if _, ok := loginLockoutConfig(); !ok {
+		return nil
+	}
+	key := loginFailuresKeyPrefix + loginLockoutName(username)
+	if useRedisLoginLockout() {
+		return global.GVA_REDIS.Del(context.Background(), key).Err()
+	}
+	global.BlackCache.Delete(key)
+	return nil
+}
+
+//@function: UnlockUser
+//@description: 管理员解除用户锁定 同时清除失败次数和锁定次数
+//@param: id uint
+//@return: err error
+
+func (userService *UserService) UnlockUser(id uint) error {
+	user, err := userService.FindUserById(int(id))
+	if err != nil {
+		return err
+	}
+	name := loginLockoutName(user.Username)
+	keys := []string{loginFailuresKeyPrefix + name, loginLockKeyPrefix + name}
+	if useRedisLoginLockout() {
+		return global.GVA_REDIS.Del(context.Background(), keys...).Err()
+	}
+	for _, key := range keys {
+		global.BlackCache.Delete(key)
+	}
+	return nil
+}
+
+// useRedisLoginLockout 开启redis时计数存放于redis 锁定在多个节点间生效
+func useRedisLoginLockout() bool {
+	return global.GVA_CONFIG.System.UseRedis && global.GVA_REDIS != nil
+}
+
+// loginLockoutName 用户名不区分大小写 避免变换大小写绕过计数
+func loginLockoutName(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// loginLockoutConfig 读取锁定策略 未配置的项使用默认值 max-attempts小于0时不锁定
+func loginLockoutConfig() (policy loginLockoutPolicy, ok bool) {
+	cfg := global.GVA_CONFIG.Lockout
+	if cfg.MaxAttempts < 0 {
+		return policy, false
+	}
+	policy.maxAttempts = cfg.MaxAttempts
+	if policy.maxAttempts == 0 {
+		policy.maxAttempts = 5
+	}
+	parse := func(s string, def time.Duration) time.Duration {
+		d, err := utils.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return def
+		}
+		return d
+	}
+	policy.window = parse(cfg.Window, 15*time.Minute)
+	policy.duration = parse(cfg.Duration, 5*time.Minute)
+	policy.maxDuration = max(parse(cfg.MaxDuration, 24*time.Hour), policy.duration)
+	policy.resetAfter = parse(cfg.ResetAfter, 24*time.Hour)
+	return policy, true
+}
+
+// lockDuration 第n次锁定的时长
+func (p loginLockoutPolicy) lockDuration(locks int) time.Duration {
+	d := float64(p.duration) * math.Pow(2, float64(locks-1))
+	if d > float64(p.maxDuration) {
+		return p.maxDuration
+	}
+	return time.Duration(d)
+}
@@ -0,0 +1,64 @@
+package system
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+func TestLoginLockout(t *testing.T) {
+	user := setupUserTest(t)
+	global.GVA_CONFIG.System.UseRedis = false
+	global.GVA_CONFIG.Lockout = config.Lockout{MaxAttempts: 3, Window: "1m", Duration: "1m", MaxDuration: "3m", ResetAfter: "1h"}
+	t.Cleanup(func() { global.GVA_CONFIG.Lockout = config.Lockout{} })
+	service := UserServiceApp
+
+	lockOut := func(username string) time.Duration {
+		t.Helper()
+		var failure LoginFailure
+		for i := 0; i < 3; i++ {
+			var err error
+			if failure, err = service.RecordLoginFailure(username); err != nil {
+				t.Fatalf("RecordLoginFailure: %v", err)
+			}
+		}
+		if !failure.Locked {
+			t.Fatalf("not locked after 3 failures: %+v", failure)
+		}
+		return time.Until(failure.LockedUntil).Round(time.Minute)
+	}
+
+	// 成功登录清除失败次数
+	service.RecordLoginFailure(user.Username)
+	service.RecordLoginFailure(user.Username)
+	if err := service.ResetLoginFailures(user.Username); err != nil {
+		t.Fatalf("ResetLoginFailures: %v", err)
+	}
+	if failure, _ := service.RecordLoginFailure(user.Username); failure.Failures != 1 || failure.Locked {
+		t.Fatalf("failures not reset: %+v", failure)
+	}
+	service.ResetLoginFailures(user.Username)
+
+	// 锁定时长逐次翻倍 不超过上限 用户名不区分大小写
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		if got := lockOut("TEST"); got != want {
+			t.Errorf("lock %d duration = %v, want %v", i+1, got, want)
+		}
+	}
+	if _, err := service.CheckLoginLock(user.Username); !errors.Is(err, ErrUserLocked) {
+		t.Fatalf("CheckLoginLock err = %v, want ErrUserLocked", err)
+	}
+
+	if err := service.UnlockUser(user.ID); err != nil {
+		t.Fatalf("UnlockUser: %v", err)
+	}
+	if _, err := service.CheckLoginLock(user.Username); err != nil {
+		t.Fatalf("still locked after unlock: %v", err)
+	}
+	if got := lockOut(user.Username); got != time.Minute {
+		t.Errorf("duration after unlock = %v, want 1m", got)
+	}
+}
@@ -0,0 +1,197 @@
+package system
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+const (
+	// OidcProviderName 外部身份关联中OIDC身份提供方的名称
+	OidcProviderName = "oidc"
+
+	oidcStateKeyPrefix = "oidc_state:"
+	oidcStateTTL       = 10 * time.Minute
+)
+
+var (
+	ErrOidcDisabled     = errors.New("未开启单点登录")
+	ErrOidcStateInvalid = errors.New("登录请求已失效, 请重新登录")
+)
+
+// oidcState 发起授权时保存的校验信息 回调时一次性取出
+type oidcState struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Binding  string `json:"binding"` // 发起授权的浏览器持有的绑定值的sha256
+}
+
+var (
+	oidcProviderMu  sync.Mutex
+	oidcProvider    *utils.OidcProvider
+	oidcProviderCfg config.OIDC
+	// oidcStateMu 串行化本地缓存中state的读取和删除 保证只能使用一次
+	oidcStateMu sync.Mutex
+)
+
+type OidcService struct{}
+
+var OidcServiceApp = new(OidcService)
+
+//@function: AuthorizeURL
+//@description: 生成state、nonce和PKCE校验码 返回跳转到身份提供方的授权地址 以及需写入发起授权的浏览器的绑定值
+//@return: authURL string, binding string, err error
+
+func (oidcService *OidcService) AuthorizeURL() (authURL string, binding string, err error) {
+	provider, err := currentOidcProvider()
+	if err != nil {
+		return
+	}
+	var state oidcState
+	var key string
+	for _, v := range []*string{&key, &state.Nonce, &state.Verifier, &binding} {
+		if *v, err = utils.OidcRandom(32); err != nil {
+			return
+		}
+	}
+	state.Binding = hashOidcBinding(binding)
+	if authURL, err = provider.AuthCodeURL(key, state.Nonce, state.Verifier); err != nil {
+		return
+	}
+	return authURL, binding, saveOidcState(key, state)
+}
+
+//@function: Login
+//@description: 使用授权码完成登录 首次登录时按配置关联或创建用户 并按规则映射角色 binding需与发起授权时返回的一致
+//@param: code string, state string, binding string
+//@return: user system.SysUser, err error
+
+func (oidcService *OidcService) Login(code, state, binding string) (user system.SysUser, err error) {
+	provider, err := currentOidcProvider()
+	if err != nil {
+		return
+	}
+	saved, ok := takeOidcState(state)
+	// 回调需来自发起授权的浏览器 防止他人的授权码被用于登录(登录CSRF)
+	if !ok || subtle.ConstantTimeCompare([]byte(hashOidcBinding(binding)), []byte(saved.Binding)) != 1 {
+		return user, ErrOidcStateInvalid
+	}
+	token, err := provider.Exchange(code, saved.Verifier)
+	if err != nil {
+		return
+	}
+	claims, err := provider.VerifyIDToken(token.IDToken, saved.Nonce)
+	if err != nil {
+		return
+	}
+	sub := claims["sub"].(string)
+	// 组等信息可能只在userinfo中返回 以id_token为准补充缺少的claim
+	if info, err := provider.UserInfo(token.AccessToken); err != nil {
+		global.GVA_LOG.Warn("获取OIDC用户信息失败!", zap.Error(err))
+	} else if infoSub, _ := info["sub"].(string); infoSub == sub {
+		for k, v := range info {
+			if _, exist := claims[k]; !exist {
+				claims[k] = v
+			}
+		}
+	}
+
+	cfg := global.GVA_CONFIG.OIDC
+	authorityIDs, err := mapAuthorityIDs(cfg.Rules, cfg.DefaultAuthorityID, claims)
+	if err != nil {
+		return
+	}
+	username := claimString(claims, cfg.UsernameClaim, "preferred_username")
+	if username == "" {
+		username = claimString(claims, cfg.EmailClaim, "email")
+	}
+	user, err = loginExternalUser(externalUser{
+		Provider:     OidcProviderName,
+		Subject:      sub,
+		Username:     username,
+		NickName:     claimString(claims, cfg.NickNameClaim, "name"),
+		Email:        claimString(claims, cfg.EmailClaim, "email"),
+		AuthorityIDs: authorityIDs,
+	}, externalUserPolicy{
+		AutoCreate:      cfg.AutoCreate,
+		LinkExisting:    cfg.LinkExisting,
+		SyncAuthorities: cfg.SyncAuthorities,
+	})
+	if err != nil {
+		return
+	}
+	MenuServiceApp.UserAuthorityDefaultRouter(&user)
+	return user, nil
+}
+
+// currentOidcProvider 获取OIDC客户端 配置变更后重新创建
+func currentOidcProvider() (*utils.OidcProvider, error) {
+	cfg := global.GVA_CONFIG.OIDC
+	if !cfg.Enable {
+		return nil, ErrOidcDisabled
+	}
+	oidcProviderMu.Lock()
+	defer oidcProviderMu.Unlock()
+	if oidcProvider == nil || !reflect.DeepEqual(cfg, oidcProviderCfg) {
+		oidcProvider, oidcProviderCfg = utils.NewOidcProvider(cfg), cfg
+	}
+	return oidcProvider, nil
+}
+
+// useRedisOidcState 开启redis时state存放于redis 回调可以落在任意节点
+func useRedisOidcState() bool {
+	return global.GVA_CONFIG.System.UseRedis && global.GVA_REDIS != nil
+}
+
+func saveOidcState(key string, state oidcState) error {
+	if !useRedisOidcState() {
+		global.BlackCache.Set(oidcStateKeyPrefix+key, state, oidcStateTTL)
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return global.GVA_REDIS.Set(context.Background(), oidcStateKeyPrefix+key, data, oidcStateTTL).Err()
+}
+
+func hashOidcBinding(binding string) string {
+	sum := sha256.Sum256([]byte(binding))
+	return hex.EncodeToString(sum[:])
+}
+
+// takeOidcState 取出并删除state 防止授权码回调被重放
+func takeOidcState(key string) (state oidcState, ok bool) {
+	if key == "" {
+		return state, false
+	}
+	if !useRedisOidcState() {
+		oidcStateMu.Lock()
+		defer oidcStateMu.Unlock()
+		v, exist := global.BlackCache.Get(oidcStateKeyPrefix + key)
+		if !exist {
+			return state, false
+		}
+		global.BlackCache.Delete(oidcStateKeyPrefix + key)
+		state, ok = v.(oidcState)
+		return state, ok
+	}
+	data, err := global.GVA_REDIS.GetDel(context.Background(), oidcStateKeyPrefix+key).Bytes()
+	if err != nil {
+		return state, false
+	}
+	return state, json.Unmarshal(data, &state) == nil
+}
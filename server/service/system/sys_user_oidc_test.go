@@ -0,0 +1,237 @@
+package system
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+// mockIdp 本地模拟的OIDC身份提供方 组信息只在userinfo中返回
+type mockIdp struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu       sync.Mutex
+	grants   map[string]mockIdpGrant
+	sub      string
+	username string
+	groups   []string
+}
+
+type mockIdpGrant struct {
+	nonce     string
+	challenge string
+}
+
+func newMockIdp(t *testing.T) *mockIdp {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	m := &mockIdp{key: key, grants: map[string]mockIdpGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"userinfo_endpoint":      m.server.URL + "/userinfo",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		encode := base64.RawURLEncoding.EncodeToString
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+			"n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		code := r.PostFormValue("code")
+		grant, ok := m.grants[code]
+		delete(m.grants, code)
+		id, secret, _ := r.BasicAuth()
+		if !ok || id != "gva" || secret != "secret" || utils.PkceChallenge(r.PostFormValue("code_verifier")) != grant.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":                m.server.URL,
+			"aud":                "gva",
+			"sub":                m.sub,
+			"nonce":              grant.nonce,
+			"exp":                time.Now().Add(time.Minute).Unix(),
+			"iat":                time.Now().Unix(),
+			"preferred_username": m.username,
+			"name":               "Alice",
+		})
+		token.Header["kid"] = "k1"
+		idToken, _ := token.SignedString(key)
+		writeJSON(w, map[string]string{"access_token": "at-" + m.sub, "id_token": idToken, "token_type": "Bearer"})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer at-"+m.sub {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"sub": m.sub, "groups": m.groups, "email": m.username + "@example.com"})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize 模拟用户在身份提供方完成登录 返回回调携带的code和state
+func (m *mockIdp) authorize(t *testing.T, authURL string) (code, state string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse auth url: %v", err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != "gva" || q.Get("code_challenge_method") != "S256" || q.Get("response_type") != "code" {
+		t.Fatalf("unexpected auth url: %s", authURL)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code = uuid.New().String()
+	m.grants[code] = mockIdpGrant{nonce: q.Get("nonce"), challenge: q.Get("code_challenge")}
+	return code, q.Get("state")
+}
+
+func (m *mockIdp) login(t *testing.T, sub, username string, groups ...string) (system.SysUser, error) {
+	t.Helper()
+	m.mu.Lock()
+	m.sub, m.username, m.groups = sub, username, groups
+	m.mu.Unlock()
+	authURL, binding, err := OidcServiceApp.AuthorizeURL()
+	if err != nil {
+		t.Fatalf("AuthorizeURL: %v", err)
+	}
+	code, state := m.authorize(t, authURL)
+	return OidcServiceApp.Login(code, state, binding)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func setupOidcTest(t *testing.T) *mockIdp {
+	t.Helper()
+	setupUserTest(t)
+	if err := global.GVA_DB.AutoMigrate(&system.SysUserIdentity{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for _, id := range []uint{888, 9528} {
+		global.GVA_DB.Create(&system.SysAuthority{AuthorityId: id, AuthorityName: "role"})
+	}
+	idp := newMockIdp(t)
+	global.GVA_CONFIG.OIDC = config.OIDC{
+		Enable:          true,
+		Issuer:          idp.server.URL,
+		ClientID:        "gva",
+		ClientSecret:    "secret",
+		RedirectURL:     "http://127.0.0.1:8080/#/oidc/callback",
+		AutoCreate:      true,
+		SyncAuthorities: true,
+		Rules: []config.AuthorityRule{
+			{Values: []string{"admins"}, AuthorityIDs: []uint{888}},
+			{Claim: "groups", Values: []string{"users"}, AuthorityIDs: []uint{9528}},
+		},
+	}
+	t.Cleanup(func() { global.GVA_CONFIG.OIDC = config.OIDC{} })
+	return idp
+}
+
+func TestOidcService_Login(t *testing.T) {
+	idp := setupOidcTest(t)
+
+	user, err := idp.login(t, "sub-alice", "alice", "admins")
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if user.Username != "alice" || user.NickName != "Alice" || user.Email != "alice@example.com" || user.AuthorityId != 888 || len(user.Authorities) != 1 {
+		t.Fatalf("unexpected provisioned user: %+v", user)
+	}
+
+	// 组变化后同步角色 同一subject关联同一用户
+	again, err := idp.login(t, "sub-alice", "alice-renamed", "users")
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again.ID != user.ID || again.AuthorityId != 9528 || len(again.Authorities) != 1 || again.Authorities[0].AuthorityId != 9528 {
+		t.Errorf("authorities not synced: %+v", again)
+	}
+
+	if _, err = idp.login(t, "sub-alice", "alice"); !errors.Is(err, ErrNoMappedAuthority) {
+		t.Errorf("login without matching group should fail, got %v", err)
+	}
+	// 与本地用户同名且未开启关联
+	if _, err = idp.login(t, "sub-test", "test", "users"); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("username collision should fail, got %v", err)
+	}
+	global.GVA_CONFIG.OIDC.LinkExisting = true
+	linked, err := idp.login(t, "sub-test", "test", "users")
+	if err != nil || linked.Username != "test" || linked.AuthorityId != 9528 {
+		t.Errorf("link existing user = %+v, %v", linked, err)
+	}
+}
+
+func TestOidcService_LoginRejectsReplay(t *testing.T) {
+	idp := setupOidcTest(t)
+	idp.sub, idp.username, idp.groups = "sub-alice", "alice", []string{"admins"}
+	authURL, binding, err := OidcServiceApp.AuthorizeURL()
+	if err != nil {
+		t.Fatalf("AuthorizeURL: %v", err)
+	}
+	code, state := idp.authorize(t, authURL)
+	if _, err = OidcServiceApp.Login(code, "forged", binding); !errors.Is(err, ErrOidcStateInvalid) {
+		t.Errorf("unknown state should fail, got %v", err)
+	}
+	if _, err = OidcServiceApp.Login(code, state, binding); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if _, err = OidcServiceApp.Login(code, state, binding); !errors.Is(err, ErrOidcStateInvalid) {
+		t.Errorf("replayed state should fail, got %v", err)
+	}
+
+	// 他人发起并完成的授权 回调链接在其他浏览器中无法使用
+	authURL, _, _ = OidcServiceApp.AuthorizeURL()
+	code, state = idp.authorize(t, authURL)
+	if _, err = OidcServiceApp.Login(code, state, ""); !errors.Is(err, ErrOidcStateInvalid) {
+		t.Errorf("callback without the browser binding should fail, got %v", err)
+	}
+
+	// 授权码被截获后 没有对应的PKCE校验码无法换取令牌
+	authURL, _, _ = OidcServiceApp.AuthorizeURL()
+	code, _ = idp.authorize(t, authURL)
+	otherURL, otherBinding, _ := OidcServiceApp.AuthorizeURL()
+	_, otherState := idp.authorize(t, otherURL)
+	if _, err = OidcServiceApp.Login(code, otherState, otherBinding); err == nil {
+		t.Error("code exchanged with another state's verifier should fail")
+	}
+}
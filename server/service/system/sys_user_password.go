@@ -0,0 +1,121 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+var (
+	ErrPasswordExpired     = errors.New("密码已过期, 请修改密码")
+	ErrOldPasswordMismatch = errors.New("原密码错误")
+)
+
+//@function: PasswordState
+//@description: 计算用户密码状态 密码过期且超过宽限期时返回 ErrPasswordExpired
+//@param: user system.SysUser
+//@return: state systemRes.PasswordState, err error
+
+func (userService *UserService) PasswordState(user system.SysUser) (state systemRes.PasswordState, err error) {
+	if user.MustChangePassword {
+		state.MustChange = true
+		state.Reason = systemRes.PasswordChangeRequired
+	}
+	maxAge, _ := utils.ParseDuration(global.GVA_CONFIG.PasswordPolicy.MaxAge)
+	if maxAge <= 0 || user.PasswordChangedAt == nil {
+		return
+	}
+	grace, _ := utils.ParseDuration(global.GVA_CONFIG.PasswordPolicy.GracePeriod)
+	expiresAt := user.PasswordChangedAt.Add(maxAge)
+	graceExpiresAt := expiresAt.Add(max(grace, 0))
+	state.ExpiresAt = expiresAt.UnixMilli()
+	state.GraceExpiresAt = graceExpiresAt.UnixMilli()
+	now := time.Now()
+	if now.Before(expiresAt) {
+		return
+	}
+	if !state.MustChange {
+		state.MustChange = true
+		state.Reason = systemRes.PasswordChangeExpired
+	}
+	if !now.Before(graceExpiresAt) {
+		err = ErrPasswordExpired
+	}
+	return
+}
+
+//@function: RestrictPassword
+//@description: 须修改密码(管理员要求或密码过期超过宽限期)时标记用户 签发的令牌仅能访问修改密码和注销接口
+//@param: user *system.SysUser
+//@return: restricted bool
+
+func (userService *UserService) RestrictPassword(user *system.SysUser) (restricted bool) {
+	_, err := userService.PasswordState(*user)
+	user.MustChangePassword = user.MustChangePassword || errors.Is(err, ErrPasswordExpired)
+	return user.MustChangePassword
+}
+
+// checkNewPassword 按密码策略校验新密码 并检查是否与当前密码或最近N次密码相同
+func (userService *UserService) checkNewPassword(tx *gorm.DB, user system.SysUser, password string) error {
+	policy := global.GVA_CONFIG.PasswordPolicy
+	if err := utils.ValidatePassword(policy, user.Username, password); err != nil {
+		return err
+	}
+	if policy.HistoryCount <= 0 || user.ID == 0 {
+		return nil
+	}
+	hashes := []string{user.Password}
+	var histories []system.SysUserPasswordHistory
+	if err := tx.Select("password").Where("user_id = ?", user.ID).Order("id desc").Limit(policy.HistoryCount).Find(&histories).Error; err != nil {
+		return err
+	}
+	for _, history := range histories {
+		hashes = append(hashes, history.Password)
+	}
+	for _, hash := range hashes {
+		if hash != "" && utils.BcryptCheck(password, hash) {
+			return utils.PasswordPolicyError(fmt.Sprintf("新密码不能与最近%d次使用的密码相同", policy.HistoryCount))
+		}
+	}
+	return nil
+}
+
+// savePassword 保存新密码 记录修改时间和历史密码 mustChange 为 true 时下次登录须修改密码
+func (userService *UserService) savePassword(tx *gorm.DB, userID uint, password string, mustChange bool) error {
+	hash := utils.BcryptHash(password)
+	err := tx.Model(&system.SysUser{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password":             hash,
+		"password_changed_at":  time.Now(),
+		"must_change_password": mustChange,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return userService.addPasswordHistory(tx, userID, hash)
+}
+
+// addPasswordHistory 写入历史密码 只保留策略需要的条数
+func (userService *UserService) addPasswordHistory(tx *gorm.DB, userID uint, hash string) error {
+	keep := global.GVA_CONFIG.PasswordPolicy.HistoryCount
+	if keep <= 0 {
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&system.SysUserPasswordHistory{}).Error
+	}
+	if err := tx.Create(&system.SysUserPasswordHistory{UserID: userID, Password: hash}).Error; err != nil {
+		return err
+	}
+	var expired []uint
+	if err := tx.Model(&system.SysUserPasswordHistory{}).Where("user_id = ?", userID).Order("id desc").Offset(keep).Pluck("id", &expired).Error; err != nil {
+		return err
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	return tx.Unscoped().Delete(&system.SysUserPasswordHistory{}, expired).Error
+}
@@ -0,0 +1,95 @@
+package system
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+func TestPasswordHistoryAndState(t *testing.T) {
+	user := setupUserTest(t)
+	if err := global.GVA_DB.AutoMigrate(&system.SysUserPasswordHistory{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	global.GVA_CONFIG.PasswordPolicy = config.PasswordPolicy{MinLength: 6, HistoryCount: 2, ForceChangeAfterReset: true, MaxAge: "30d", GracePeriod: "7d"}
+	t.Cleanup(func() { global.GVA_CONFIG.PasswordPolicy = config.PasswordPolicy{} })
+	service := UserServiceApp
+
+	if err := service.ResetPassword(user.ID, "short"); !errors.As(err, new(utils.PasswordPolicyError)) {
+		t.Fatalf("short password err = %v", err)
+	}
+	if err := service.ResetPassword(user.ID, "first-pass"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	change := func(old, new string) error {
+		return service.ChangePassword(&system.SysUser{GVA_MODEL: global.GVA_MODEL{ID: user.ID}, Password: old}, new)
+	}
+	if err := change("wrong", "second-pass"); !errors.Is(err, ErrOldPasswordMismatch) {
+		t.Fatalf("wrong old password err = %v", err)
+	}
+	if err := change("first-pass", "second-pass"); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	if err := change("second-pass", "third-pass"); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	// 最近两次密码不可重复使用 更早的可以
+	for _, reused := range []string{"third-pass", "second-pass"} {
+		if err := change("third-pass", reused); !errors.As(err, new(utils.PasswordPolicyError)) {
+			t.Errorf("reuse %s err = %v", reused, err)
+		}
+	}
+	if err := change("third-pass", "first-pass"); err != nil {
+		t.Errorf("reuse of expired history: %v", err)
+	}
+	var count int64
+	global.GVA_DB.Model(&system.SysUserPasswordHistory{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("history count = %d, want 2", count)
+	}
+
+	// 重置后须修改密码 修改后清除
+	var stored system.SysUser
+	global.GVA_DB.First(&stored, user.ID)
+	if stored.MustChangePassword || stored.PasswordChangedAt == nil {
+		t.Errorf("unexpected state after change: must=%v changedAt=%v", stored.MustChangePassword, stored.PasswordChangedAt)
+	}
+	service.ResetPassword(user.ID, "reset-pass")
+	global.GVA_DB.First(&stored, user.ID)
+	if state, err := service.PasswordState(stored); err != nil || !state.MustChange || state.Reason != systemRes.PasswordChangeRequired {
+		t.Errorf("after reset: %+v, %v", state, err)
+	}
+	// 须修改密码时签发的令牌受限
+	restricted := stored
+	if !service.RestrictPassword(&restricted) {
+		t.Error("reset password should restrict the token")
+	}
+	if _, claims, err := utils.LoginToken(&restricted); err != nil || !claims.MustChangePassword {
+		t.Errorf("token after reset: %+v, %v", claims.BaseClaims, err)
+	}
+
+	// 过期后宽限期内可正常登录 超过宽限期须先修改密码
+	stored.MustChangePassword = false
+	expired := time.Now().Add(-31 * 24 * time.Hour)
+	stored.PasswordChangedAt = &expired
+	if state, err := service.PasswordState(stored); err != nil || state.Reason != systemRes.PasswordChangeExpired {
+		t.Errorf("in grace period: %+v, %v", state, err)
+	}
+	if restricted = stored; service.RestrictPassword(&restricted) {
+		t.Error("token should not be restricted in grace period")
+	}
+	expired = time.Now().Add(-38 * 24 * time.Hour)
+	if _, err := service.PasswordState(stored); !errors.Is(err, ErrPasswordExpired) {
+		t.Errorf("after grace period err = %v", err)
+	}
+	// 超过宽限期仍可登录 但令牌仅能用于修改密码
+	if restricted = stored; !service.RestrictPassword(&restricted) {
+		t.Error("token should be restricted after grace period")
+	}
+}
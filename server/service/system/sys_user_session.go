@@ -0,0 +1,222 @@
+package system
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+// sessionCheckInterval 同一会话在本节点检查撤销状态并更新最近访问时间的最小间隔
+const sessionCheckInterval = time.Minute
+
+var ErrSessionNotFound = errors.New("会话不存在或已失效")
+
+var (
+	// sessionChecked 会话在本节点最近一次检查的时间 key: sessionID
+	sessionChecked sync.Map
+	// sessionPrunedAt 最近一次清理sessionChecked的时间 unix纳秒
+	sessionPrunedAt atomic.Int64
+)
+
+type UserSessionService struct{}
+
+var UserSessionServiceApp = new(UserSessionService)
+
+//@function: CreateSession
+//@description: 登录签发令牌后登记会话
+//@param: user system.SysUser, claims systemReq.CustomClaims, token string, expiresAt time.Time, ip string, userAgent string
+//@return: err error
+
+func (sessionService *UserSessionService) CreateSession(user system.SysUser, claims systemReq.CustomClaims, token string, expiresAt time.Time, ip, userAgent string) error {
+	if claims.FamilyID == "" {
+		return nil
+	}
+	now := time.Now()
+	return global.GVA_DB.Create(&system.SysUserSession{
+		SessionID:  claims.FamilyID,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Token:      token,
+		Ip:         ip,
+		UserAgent:  userAgent,
+		IssuedAt:   now,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}).Error
+}
+
+//@function: RotateSession
+//@description: 刷新令牌或续期后记录会话的当前令牌
+//@param: sessionID string, token string, expiresAt time.Time
+//@return: err error
+
+func (sessionService *UserSessionService) RotateSession(sessionID, token string, expiresAt time.Time) error {
+	if sessionID == "" {
+		return nil
+	}
+	return global.GVA_DB.Model(&system.SysUserSession{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]interface{}{"token": token, "expires_at": expiresAt, "last_seen_at": time.Now()}).Error
+}
+
+//@function: Revoked
+//@description: 判断令牌所属会话是否已被撤销 同时更新最近访问时间 每个会话每分钟最多查询一次
+//@param: claims *systemReq.CustomClaims
+//@return: bool
+
+func (sessionService *UserSessionService) Revoked(claims *systemReq.CustomClaims) bool {
+	if claims.FamilyID == "" {
+		return false
+	}
+	now := time.Now()
+	if last, ok := sessionChecked.Load(claims.FamilyID); ok && now.Sub(last.(time.Time)) < sessionCheckInterval {
+		return false
+	}
+	sessionChecked.Store(claims.FamilyID, now)
+	pruneSessionChecked(now)
+	var session system.SysUserSession
+	err := global.GVA_DB.Select("id", "revoked_at").Where("session_id = ?", claims.FamilyID).First(&session).Error
+	if err != nil {
+		// 启用会话登记之前签发的令牌没有对应会话
+		return false
+	}
+	if session.RevokedAt != nil {
+		return true
+	}
+	global.GVA_DB.Model(&session).UpdateColumn("last_seen_at", now)
+	return false
+}
+
+// pruneSessionChecked 每个检查间隔清理一次超过间隔的记录 避免已过期的会话一直占用内存
+func pruneSessionChecked(now time.Time) {
+	last := sessionPrunedAt.Load()
+	if now.UnixNano()-last < int64(sessionCheckInterval) || !sessionPrunedAt.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	sessionChecked.Range(func(key, value interface{}) bool {
+		if now.Sub(value.(time.Time)) >= sessionCheckInterval {
+			sessionChecked.Delete(key)
+		}
+		return true
+	})
+}
+
+//@function: GetUserSessions
+//@description: 获取用户未过期且未撤销的会话
+//@param: userID uint, currentSessionID string
+//@return: sessions []system.SysUserSession, err error
+
+func (sessionService *UserSessionService) GetUserSessions(userID uint, currentSessionID string) (sessions []system.SysUserSession, err error) {
+	err = sessionService.activeSessions().Where("user_id = ?", userID).Order("last_seen_at desc").Find(&sessions).Error
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == currentSessionID
+	}
+	return
+}
+
+//@function: GetSessionList
+//@description: 分页获取全部在线会话
+//@param: info systemReq.GetSessionList
+//@return: list interface{}, total int64, err error
+
+func (sessionService *UserSessionService) GetSessionList(info systemReq.GetSessionList) (list interface{}, total int64, err error) {
+	limit := info.PageSize
+	offset := info.PageSize * (info.Page - 1)
+	db := sessionService.activeSessions().Model(&system.SysUserSession{})
+	if info.Username != "" {
+		db = db.Where("username LIKE ?", "%"+info.Username+"%")
+	}
+	if info.TenantID != nil {
+		db = db.Where("user_id IN (?)", global.GVA_DB.Model(&system.SysUser{}).Select("id").Where("tenant_id = ?", *info.TenantID))
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	var sessions []system.SysUserSession
+	err = db.Order("last_seen_at desc").Limit(limit).Offset(offset).Find(&sessions).Error
+	return sessions, total, err
+}
+
+//@function: RevokeSession
+//@description: 撤销会话 userID不为0时只能撤销该用户自己的会话
+//@param: userID uint, sessionID string
+//@return: err error
+
+func (sessionService *UserSessionService) RevokeSession(userID uint, sessionID string) error {
+	db := sessionService.activeSessions().Where("session_id = ?", sessionID)
+	if userID != 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	var session system.SysUserSession
+	if err := db.First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return sessionService.revoke(session)
+}
+
+//@function: RevokeUserSessions
+//@description: 撤销用户的全部会话 用于强制下线、禁用或删除用户
+//@param: userID uint
+//@return: err error
+
+func (sessionService *UserSessionService) RevokeUserSessions(userID uint) error {
+	var sessions []system.SysUserSession
+	if err := global.GVA_DB.Where("user_id = ? AND revoked_at IS NULL", userID).Find(&sessions).Error; err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := sessionService.revoke(session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//@function: RevokeSessionByToken
+//@description: 根据访问令牌标记会话已撤销 用于注销及多点登录挤下线
+//@param: token string
+//@return: err error
+
+func (sessionService *UserSessionService) RevokeSessionByToken(token string) error {
+	claims, err := utils.NewJWT().ParseToken(token)
+	if err != nil || claims.FamilyID == "" {
+		return nil
+	}
+	var session system.SysUserSession
+	if err = global.GVA_DB.Where("session_id = ? AND revoked_at IS NULL", claims.FamilyID).First(&session).Error; err != nil {
+		return nil
+	}
+	return sessionService.revoke(session)
+}
+
+// revoke 当前访问令牌加入黑名单 撤销刷新令牌所属令牌族 并标记会话已撤销
+func (sessionService *UserSessionService) revoke(session system.SysUserSession) error {
+	if session.Token != "" {
+		if _, blacked := global.BlackCache.Get(session.Token); !blacked {
+			if err := JwtServiceApp.JsonInBlacklist(system.JwtBlacklist{Jwt: session.Token}); err != nil {
+				return err
+			}
+		}
+	}
+	if err := JwtServiceApp.RevokeTokenFamily(session.SessionID); err != nil {
+		global.GVA_LOG.Error("令牌族撤销失败!", zap.Error(err))
+	}
+	sessionChecked.Delete(session.SessionID)
+	return global.GVA_DB.Model(&session).Update("revoked_at", time.Now()).Error
+}
+
+func (sessionService *UserSessionService) activeSessions() *gorm.DB {
+	return global.GVA_DB.Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+}
@@ -0,0 +1,122 @@
+package system
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+func TestUserSessionService_Revoke(t *testing.T) {
+	user := setupUserTest(t)
+	global.GVA_CONFIG.JWT.UseRefreshToken = false
+	s := UserSessionServiceApp
+
+	var tokens []string
+	var claims []systemReq.CustomClaims
+	for i := 0; i < 2; i++ {
+		token, c, err := utils.LoginToken(&user)
+		if err != nil {
+			t.Fatalf("LoginToken: %v", err)
+		}
+		if err = s.CreateSession(user, c, token, c.ExpiresAt.Time, "127.0.0.1", "test"); err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		tokens, claims = append(tokens, token), append(claims, c)
+	}
+	sessions, err := s.GetUserSessions(user.ID, claims[0].FamilyID)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("GetUserSessions = %d, %v; want 2", len(sessions), err)
+	}
+	for _, session := range sessions {
+		if session.Current != (session.SessionID == claims[0].FamilyID) {
+			t.Errorf("session %s current = %v", session.SessionID, session.Current)
+		}
+	}
+
+	// 只能撤销自己的会话
+	if err = s.RevokeSession(user.ID+1, claims[0].FamilyID); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("revoke other user's session should fail, got %v", err)
+	}
+	if err = s.RevokeSession(user.ID, claims[0].FamilyID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, ok := global.BlackCache.Get(tokens[0]); !ok {
+		t.Error("revoked session token should be blacklisted")
+	}
+	if !s.Revoked(&claims[0]) {
+		t.Error("revoked session should be reported as revoked")
+	}
+	if s.Revoked(&claims[1]) {
+		t.Error("other session should stay active")
+	}
+	if sessions, _ = s.GetUserSessions(user.ID, ""); len(sessions) != 1 {
+		t.Errorf("active sessions = %d, want 1", len(sessions))
+	}
+
+	// 租户管理员只能查询本租户用户的会话
+	page := systemReq.GetSessionList{}
+	page.Page, page.PageSize = 1, 10
+	if _, total, err := s.GetSessionList(page); err != nil || total != 1 {
+		t.Errorf("platform session list = %d, %v; want 1", total, err)
+	}
+	tenantID := uint(1)
+	page.TenantID = &tenantID
+	if _, total, err := s.GetSessionList(page); err != nil || total != 0 {
+		t.Errorf("tenant session list = %d, %v; want 0", total, err)
+	}
+}
+
+func TestUserService_DisableRevokesSessions(t *testing.T) {
+	user := setupUserTest(t)
+	global.GVA_CONFIG.JWT.UseRefreshToken = false
+	token, claims, err := utils.LoginToken(&user)
+	if err != nil {
+		t.Fatalf("LoginToken: %v", err)
+	}
+	if err = UserSessionServiceApp.CreateSession(user, claims, token, time.Now().Add(time.Hour), "", ""); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	user.Enable = 2
+	if err = UserServiceApp.SetUserInfo(user); err != nil {
+		t.Fatalf("SetUserInfo: %v", err)
+	}
+	if _, ok := global.BlackCache.Get(token); !ok {
+		t.Error("disabled user's token should be blacklisted")
+	}
+	var session system.SysUserSession
+	if err = global.GVA_DB.Where("session_id = ?", claims.FamilyID).First(&session).Error; err != nil || session.RevokedAt == nil {
+		t.Errorf("session should be revoked, err = %v", err)
+	}
+}
+
+func TestPruneSessionChecked(t *testing.T) {
+	now := time.Now()
+	sessionChecked.Store("stale", now.Add(-2*sessionCheckInterval))
+	sessionChecked.Store("fresh", now)
+	t.Cleanup(func() {
+		sessionChecked.Delete("stale")
+		sessionChecked.Delete("fresh")
+	})
+	sessionPrunedAt.Store(0)
+
+	pruneSessionChecked(now)
+	if _, ok := sessionChecked.Load("stale"); ok {
+		t.Error("stale entry should be pruned")
+	}
+	if _, ok := sessionChecked.Load("fresh"); !ok {
+		t.Error("fresh entry should be kept")
+	}
+
+	// 检查间隔内不重复清理
+	sessionChecked.Store("stale", now.Add(-2*sessionCheckInterval))
+	pruneSessionChecked(now.Add(time.Second))
+	if _, ok := sessionChecked.Load("stale"); !ok {
+		t.Error("should not prune again within the check interval")
+	}
+}
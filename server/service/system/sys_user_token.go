@@ -0,0 +1,225 @@
+package system
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2/util"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+const (
+	// UserTokenPrefix 个人访问令牌的固定前缀 用于与jwt区分
+	UserTokenPrefix = "gva_pat_"
+	// userTokenTouchInterval 最近使用时间的最小更新间隔
+	userTokenTouchInterval = time.Minute
+)
+
+var (
+	ErrUserTokenInvalid  = errors.New("个人令牌无效或已过期")
+	ErrUserTokenNotFound = errors.New("个人令牌不存在")
+	ErrUserTokenExpired  = errors.New("过期时间须晚于当前时间")
+)
+
+type UserTokenService struct{}
+
+var UserTokenServiceApp = new(UserTokenService)
+
+// IsUserToken 判断请求携带的是否为个人访问令牌
+func IsUserToken(token string) bool {
+	return strings.HasPrefix(token, UserTokenPrefix)
+}
+
+//@function: CreateToken
+//@description: 创建个人访问令牌 返回只展示一次的令牌明文
+//@param: userID uint, req systemReq.CreateUserToken
+//@return: token string, record system.SysUserToken, err error
+
+func (tokenService *UserTokenService) CreateToken(userID uint, req systemReq.CreateUserToken) (token string, record system.SysUserToken, err error) {
+	scopes, err := tokenService.checkToken(userID, req)
+	if err != nil {
+		return
+	}
+	random, err := utils.OidcRandom(32)
+	if err != nil {
+		return
+	}
+	token = UserTokenPrefix + random
+	record = system.SysUserToken{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    token[:len(UserTokenPrefix)+6],
+		TokenHash: hashUserToken(token),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	err = global.GVA_DB.Create(&record).Error
+	return
+}
+
+//@function: GetUserTokens
+//@description: 获取用户的个人访问令牌
+//@param: userID uint
+//@return: tokens []system.SysUserToken, err error
+
+func (tokenService *UserTokenService) GetUserTokens(userID uint) (tokens []system.SysUserToken, err error) {
+	err = global.GVA_DB.Where("user_id = ?", userID).Order("id desc").Find(&tokens).Error
+	return
+}
+
+//@function: UpdateToken
+//@description: 修改个人访问令牌的名称、过期时间和允许访问的接口
+//@param: userID uint, req systemReq.UpdateUserToken
+//@return: err error
+
+func (tokenService *UserTokenService) UpdateToken(userID uint, req systemReq.UpdateUserToken) error {
+	scopes, err := tokenService.checkToken(userID, req.CreateUserToken)
+	if err != nil {
+		return err
+	}
+	result := global.GVA_DB.Model(&system.SysUserToken{}).Where("id = ? AND user_id = ?", req.ID, userID).
+		Select("name", "expires_at", "scopes").
+		Updates(&system.SysUserToken{Name: req.Name, ExpiresAt: req.ExpiresAt, Scopes: scopes})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserTokenNotFound
+	}
+	return nil
+}
+
+//@function: DeleteToken
+//@description: 删除个人访问令牌 删除后立即失效
+//@param: userID uint, id uint
+//@return: err error
+
+func (tokenService *UserTokenService) DeleteToken(userID, id uint) error {
+	result := global.GVA_DB.Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&system.SysUserToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserTokenNotFound
+	}
+	return nil
+}
+
+//@function: Authenticate
+//@description: 校验个人访问令牌 返回所属用户的claims和令牌允许访问的接口
+//@param: token string, ip string
+//@return: claims *systemReq.CustomClaims, record system.SysUserToken, err error
+
+func (tokenService *UserTokenService) Authenticate(token, ip string) (claims *systemReq.CustomClaims, record system.SysUserToken, err error) {
+	err = global.GVA_DB.Where("token_hash = ?", hashUserToken(token)).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, record, ErrUserTokenInvalid
+	}
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	if record.ExpiresAt != nil && !now.Before(*record.ExpiresAt) {
+		return nil, record, ErrUserTokenInvalid
+	}
+	var user system.SysUser
+	err = global.GVA_DB.Where("id = ?", record.UserID).Preload("Authorities").First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user.Enable != 1) {
+		return nil, record, ErrUserTokenInvalid
+	}
+	if err == nil && TenantServiceApp.CheckTenant(user.TenantID) != nil {
+		return nil, record, ErrUserTokenInvalid
+	}
+	if err != nil {
+		return
+	}
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= userTokenTouchInterval || record.LastUsedIp != ip {
+		global.GVA_DB.Model(&record).UpdateColumns(map[string]interface{}{"last_used_at": now, "last_used_ip": ip})
+	}
+	claims = &systemReq.CustomClaims{BaseClaims: systemReq.BaseClaims{
+		UUID:         user.UUID,
+		ID:           user.ID,
+		Username:     user.Username,
+		NickName:     user.NickName,
+		AuthorityId:  user.AuthorityId,
+		AuthorityIds: user.GetAuthorityIds(),
+		TenantID:     user.TenantID,
+	}}
+	return claims, record, nil
+}
+
+// userTokenDeniedApis 个人令牌始终不能访问的接口 避免泄露的令牌签发新令牌或修改登录凭据和会话
+var userTokenDeniedApis = []system.SysUserTokenScope{
+	{Path: "/user/changePassword", Method: "POST"},
+	{Path: "/user/setupTotp", Method: "POST"},
+	{Path: "/user/enableTotp", Method: "POST"},
+	{Path: "/user/disableTotp", Method: "POST"},
+	{Path: "/user/regenerateRecoveryCodes", Method: "POST"},
+	{Path: "/user/getTotpStatus", Method: "GET"},
+	{Path: "/user/getSessions", Method: "GET"},
+	{Path: "/user/revokeSession", Method: "POST"},
+	{Path: "/user/createToken", Method: "POST"},
+	{Path: "/user/updateToken", Method: "PUT"},
+	{Path: "/user/deleteToken", Method: "DELETE"},
+	{Path: "/user/getTokens", Method: "GET"},
+}
+
+// UserTokenAllows 判断令牌是否允许访问接口 令牌管理和会话、两步验证等自助接口始终拒绝 其余接口未限制时返回true
+func UserTokenAllows(scopes []system.SysUserTokenScope, path, method string) bool {
+	if slices.Contains(userTokenDeniedApis, system.SysUserTokenScope{Path: path, Method: method}) {
+		return false
+	}
+	if len(scopes) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(scopes, func(scope system.SysUserTokenScope) bool {
+		return scope.Method == method && util.KeyMatch2(path, scope.Path)
+	})
+}
+
+// checkToken 校验过期时间 并确认允许访问的接口均在所属用户当前角色的权限内
+func (tokenService *UserTokenService) checkToken(userID uint, req systemReq.CreateUserToken) ([]system.SysUserTokenScope, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrUserTokenExpired
+	}
+	if len(req.Scopes) == 0 {
+		return nil, nil
+	}
+	var user system.SysUser
+	if err := global.GVA_DB.Select("id", "authority_id", "tenant_id").Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	sub, dom := strconv.Itoa(int(user.AuthorityId)), utils.TenantDomain(user.TenantID)
+	scopes := make([]system.SysUserTokenScope, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scope.Method = strings.ToUpper(scope.Method)
+		if slices.Contains(userTokenDeniedApis, system.SysUserTokenScope{Path: scope.Path, Method: scope.Method}) {
+			return nil, fmt.Errorf("个人令牌不能访问接口 %s %s", scope.Method, scope.Path)
+		}
+		if ok, _ := utils.CasbinEnforce(sub, dom, scope.Path, scope.Method); !ok {
+			return nil, fmt.Errorf("当前角色没有接口 %s %s 的权限", scope.Method, scope.Path)
+		}
+		item := system.SysUserTokenScope{Path: scope.Path, Method: scope.Method}
+		if !slices.Contains(scopes, item) {
+			scopes = append(scopes, item)
+		}
+	}
+	return scopes, nil
+}
+
+// hashUserToken 令牌由随机数生成 使用sha256保存即可 无需加盐
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
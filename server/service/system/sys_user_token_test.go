@@ -0,0 +1,88 @@
+package system
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemReq "github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+func TestUserTokenService(t *testing.T) {
+	user := setupUserTest(t)
+	if err := global.GVA_DB.AutoMigrate(&system.SysUserToken{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := utils.GetCasbin().AddPolicies([][]string{{"888", "/user/getTokens", "GET"}, {"888", "/user/createToken", "POST"}, {"888", "/api/:id", "GET"}}); err != nil {
+		t.Fatalf("add policies: %v", err)
+	}
+	service := UserTokenServiceApp
+
+	token, record, err := service.CreateToken(user.ID, systemReq.CreateUserToken{
+		Name:   "ci",
+		Scopes: []systemReq.CasbinInfo{{Path: "/api/:id", Method: "get"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	if !IsUserToken(token) || record.TokenHash == token || record.Prefix != token[:len(record.Prefix)] {
+		t.Fatalf("token should be stored hashed: %q %+v", token, record)
+	}
+	claims, got, err := service.Authenticate(token, "10.0.0.1")
+	if err != nil || claims.BaseClaims.ID != user.ID || claims.AuthorityId != 888 {
+		t.Fatalf("Authenticate = %+v, %v", claims, err)
+	}
+	if !UserTokenAllows(got.Scopes, "/api/12", "GET") || UserTokenAllows(got.Scopes, "/user/getTokens", "GET") {
+		t.Errorf("unexpected scope check for %+v", got.Scopes)
+	}
+	global.GVA_DB.First(&record, record.ID)
+	if record.LastUsedAt == nil || record.LastUsedIp != "10.0.0.1" {
+		t.Errorf("last used not tracked: %+v", record)
+	}
+
+	// 只能授权所属角色拥有的接口
+	if _, _, err = service.CreateToken(user.ID, systemReq.CreateUserToken{
+		Name:   "admin",
+		Scopes: []systemReq.CasbinInfo{{Path: "/user/deleteUser", Method: "DELETE"}},
+	}); err == nil {
+		t.Error("scope outside owner's policies should be rejected")
+	}
+	// 令牌管理等自助接口不能授权给令牌 未限制接口的令牌同样不能访问
+	if _, _, err = service.CreateToken(user.ID, systemReq.CreateUserToken{
+		Name:   "mint",
+		Scopes: []systemReq.CasbinInfo{{Path: "/user/createToken", Method: "POST"}},
+	}); err == nil {
+		t.Error("token management scope should be rejected")
+	}
+	if !UserTokenAllows(nil, "/api/12", "GET") || UserTokenAllows(nil, "/user/createToken", "POST") || UserTokenAllows(nil, "/user/revokeSession", "POST") {
+		t.Error("unscoped token should be denied self-service apis only")
+	}
+	past := time.Now().Add(-time.Minute)
+	if _, _, err = service.CreateToken(user.ID, systemReq.CreateUserToken{Name: "old", ExpiresAt: &past}); !errors.Is(err, ErrUserTokenExpired) {
+		t.Errorf("past expiry = %v, want ErrUserTokenExpired", err)
+	}
+
+	// 过期、用户禁用、删除后令牌失效
+	global.GVA_DB.Model(&record).Update("expires_at", past)
+	if _, _, err = service.Authenticate(token, ""); !errors.Is(err, ErrUserTokenInvalid) {
+		t.Errorf("expired token = %v, want ErrUserTokenInvalid", err)
+	}
+	token, record, _ = service.CreateToken(user.ID, systemReq.CreateUserToken{Name: "ci"})
+	global.GVA_DB.Model(&user).Update("enable", 2)
+	if _, _, err = service.Authenticate(token, ""); !errors.Is(err, ErrUserTokenInvalid) {
+		t.Errorf("disabled user = %v, want ErrUserTokenInvalid", err)
+	}
+	global.GVA_DB.Model(&user).Update("enable", 1)
+	if err = service.DeleteToken(user.ID+1, record.ID); !errors.Is(err, ErrUserTokenNotFound) {
+		t.Errorf("deleting another user's token = %v, want ErrUserTokenNotFound", err)
+	}
+	if err = service.DeleteToken(user.ID, record.ID); err != nil {
+		t.Fatalf("DeleteToken: %v", err)
+	}
+	if _, _, err = service.Authenticate(token, ""); !errors.Is(err, ErrUserTokenInvalid) {
+		t.Errorf("deleted token = %v, want ErrUserTokenInvalid", err)
+	}
+}
@@ -0,0 +1,394 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+var (
+	ErrTotpChallengeInvalid = errors.New("两步验证已过期, 请重新登录")
+	ErrTotpCodeInvalid      = errors.New("验证码错误")
+)
+
+const totpChallengeKeyPrefix = "totp_challenge:"
+
+type UserTotpService struct{}
+
+var UserTotpServiceApp = new(UserTotpService)
+
+// totpChallengeMu 串行化本地缓存中的尝试次数更新 Redis模式下由脚本保证原子性
+var totpChallengeMu sync.Mutex
+
+// totpChallenge 两步登录挑战 密码校验通过后签发 仅用于提交验证码
+type totpChallenge struct {
+	UserID   uint `redis:"userId"`
+	Attempts int  `redis:"attempts"`
+}
+
+// incrTotpChallengeScript 挑战存在时原子地累加尝试次数 不存在时返回-1
+// KEYS: 挑战key
+var incrTotpChallengeScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+return redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+`)
+
+//@function: TotpState
+//@description: 获取用户两步验证状态 required 表示所属角色强制要求两步验证
+//@param: user system.SysUser
+//@return: enabled bool, required bool, err error
+
+func (totpService *UserTotpService) TotpState(user system.SysUser) (enabled bool, required bool, err error) {
+	var totp system.SysUserTotp
+	err = global.GVA_DB.Where("user_id = ?", user.ID).First(&totp).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+	enabled = err == nil && totp.Enabled
+	authorityIds := append(user.GetAuthorityIds(), user.AuthorityId)
+	var count int64
+	err = global.GVA_DB.Model(&system.SysAuthority{}).Where("authority_id in ? AND require_totp = ?", authorityIds, true).Count(&count).Error
+	required = count > 0
+	return
+}
+
+//@function: CreateChallenge
+//@description: 密码校验通过后创建两步登录挑战令牌
+//@param: userID uint
+//@return: token string, expiresAt time.Time, err error
+
+func (totpService *UserTotpService) CreateChallenge(userID uint) (token string, expiresAt time.Time, err error) {
+	ttl, err := utils.ParseDuration(global.GVA_CONFIG.Totp.ChallengeExpiresTime)
+	if err != nil || ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	token = uuid.New().String()
+	err = totpService.saveChallenge(token, totpChallenge{UserID: userID}, ttl)
+	return token, time.Now().Add(ttl), err
+}
+
+//@function: EnrollByChallenge
+//@description: 角色强制两步验证但用户尚未绑定时 凭挑战令牌生成密钥完成绑定
+//@param: token string
+//@return: secret string, uri string, err error
+
+func (totpService *UserTotpService) EnrollByChallenge(token string) (secret string, uri string, err error) {
+	challenge, ok := totpService.loadChallenge(token)
+	if !ok {
+		return "", "", ErrTotpChallengeInvalid
+	}
+	var user system.SysUser
+	if err = global.GVA_DB.Select("id", "username").First(&user, challenge.UserID).Error; err != nil {
+		return "", "", ErrTotpChallengeInvalid
+	}
+	return totpService.Setup(user.ID, user.Username)
+}
+
+//@function: VerifyChallenge
+//@description: 校验挑战令牌与验证码(或恢复码) 首次绑定时启用两步验证并返回恢复码
+//@param: token string, code string, recoveryCode string
+//@return: user system.SysUser, recoveryCodes []string, err error
+
+func (totpService *UserTotpService) VerifyChallenge(token, code, recoveryCode string) (user system.SysUser, recoveryCodes []string, err error) {
+	challenge, ok := totpService.loadChallenge(token)
+	if !ok {
+		return user, nil, ErrTotpChallengeInvalid
+	}
+	maxAttempts := global.GVA_CONFIG.Totp.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	// 校验前先原子地累加尝试次数 并发提交时同样受次数限制
+	attempts, ok := totpService.incrChallengeAttempts(token)
+	if !ok || attempts > maxAttempts {
+		totpService.deleteChallenge(token)
+		return user, nil, ErrTotpChallengeInvalid
+	}
+
+	err = global.GVA_DB.Preload("Authorities").Preload("Authority").First(&user, challenge.UserID).Error
+	if err != nil || user.Enable != 1 {
+		totpService.deleteChallenge(token)
+		return user, nil, ErrTotpChallengeInvalid
+	}
+
+	var totp system.SysUserTotp
+	if err = global.GVA_DB.Where("user_id = ?", user.ID).First(&totp).Error; err != nil {
+		return user, nil, errors.New("请先绑定身份验证器")
+	}
+	if totp.Enabled {
+		if recoveryCode != "" {
+			err = totpService.useRecoveryCode(user.ID, recoveryCode)
+		} else {
+			err = totpService.verifyCode(&totp, code)
+		}
+	} else {
+		// 强制绑定流程 首次校验通过即启用
+		recoveryCodes, err = totpService.enable(&totp, code)
+	}
+	if err != nil {
+		return user, nil, err
+	}
+	totpService.deleteChallenge(token)
+	MenuServiceApp.UserAuthorityDefaultRouter(&user)
+	return user, recoveryCodes, nil
+}
+
+//@function: Setup
+//@description: 生成新的TOTP密钥 校验通过前不会启用
+//@param: userID uint, username string
+//@return: secret string, uri string, err error
+
+func (totpService *UserTotpService) Setup(userID uint, username string) (secret string, uri string, err error) {
+	var totp system.SysUserTotp
+	err = global.GVA_DB.Where("user_id = ?", userID).First(&totp).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+	if err == nil && totp.Enabled {
+		return "", "", errors.New("已启用两步验证, 如需重新绑定请先关闭")
+	}
+	if secret, err = utils.GenerateTotpSecret(); err != nil {
+		return
+	}
+	totp.UserID = userID
+	totp.Secret = secret
+	totp.LastCounter = 0
+	if err = global.GVA_DB.Save(&totp).Error; err != nil {
+		return
+	}
+	return secret, utils.TotpProvisioningURI(global.GVA_CONFIG.Totp.Issuer, username, secret), nil
+}
+
+//@function: Enable
+//@description: 校验验证码后启用两步验证 返回一次性恢复码
+//@param: userID uint, code string
+//@return: recoveryCodes []string, err error
+
+func (totpService *UserTotpService) Enable(userID uint, code string) (recoveryCodes []string, err error) {
+	var totp system.SysUserTotp
+	if err = global.GVA_DB.Where("user_id = ?", userID).First(&totp).Error; err != nil {
+		return nil, errors.New("请先绑定身份验证器")
+	}
+	if totp.Enabled {
+		return nil, errors.New("已启用两步验证")
+	}
+	return totpService.enable(&totp, code)
+}
+
+//@function: Disable
+//@description: 校验验证码或恢复码后关闭两步验证 角色强制要求时不允许关闭
+//@param: user system.SysUser, code string, recoveryCode string
+//@return: err error
+
+func (totpService *UserTotpService) Disable(user system.SysUser, code, recoveryCode string) (err error) {
+	_, required, err := totpService.TotpState(user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("所属角色要求必须开启两步验证")
+	}
+	if err = totpService.verifyEnabled(user.ID, code, recoveryCode); err != nil {
+		return err
+	}
+	return totpService.ResetTotp(user.ID)
+}
+
+//@function: RegenerateRecoveryCodes
+//@description: 校验验证码后重新生成恢复码 旧恢复码全部失效
+//@param: userID uint, code string
+//@return: recoveryCodes []string, err error
+
+func (totpService *UserTotpService) RegenerateRecoveryCodes(userID uint, code string) (recoveryCodes []string, err error) {
+	if err = totpService.verifyEnabled(userID, code, ""); err != nil {
+		return nil, err
+	}
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		recoveryCodes, err = totpService.createRecoveryCodes(tx, userID)
+		return err
+	})
+	return
+}
+
+//@function: RecoveryCodesLeft
+//@description: 获取剩余可用的恢复码数量
+//@param: userID uint
+//@return: count int64, err error
+
+func (totpService *UserTotpService) RecoveryCodesLeft(userID uint) (count int64, err error) {
+	err = global.GVA_DB.Model(&system.SysUserRecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return
+}
+
+//@function: ResetTotp
+//@description: 清除用户两步验证设置及恢复码 用于关闭或管理员重置
+//@param: userID uint
+//@return: err error
+
+func (totpService *UserTotpService) ResetTotp(userID uint) (err error) {
+	return global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&system.SysUserTotp{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&system.SysUserRecoveryCode{}).Error
+	})
+}
+
+func (totpService *UserTotpService) verifyEnabled(userID uint, code, recoveryCode string) error {
+	var totp system.SysUserTotp
+	if err := global.GVA_DB.Where("user_id = ?", userID).First(&totp).Error; err != nil || !totp.Enabled {
+		return errors.New("未启用两步验证")
+	}
+	if recoveryCode != "" {
+		return totpService.useRecoveryCode(userID, recoveryCode)
+	}
+	return totpService.verifyCode(&totp, code)
+}
+
+// verifyCode 校验验证码 同一时间步的验证码只能使用一次
+func (totpService *UserTotpService) verifyCode(totp *system.SysUserTotp, code string) error {
+	counter, ok := utils.ValidateTotp(totp.Secret, code, time.Now())
+	if !ok || counter <= totp.LastCounter {
+		return ErrTotpCodeInvalid
+	}
+	res := global.GVA_DB.Model(&system.SysUserTotp{}).
+		Where("id = ? AND last_counter < ?", totp.ID, counter).
+		Update("last_counter", counter)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTotpCodeInvalid
+	}
+	totp.LastCounter = counter
+	return nil
+}
+
+func (totpService *UserTotpService) enable(totp *system.SysUserTotp, code string) (recoveryCodes []string, err error) {
+	if err = totpService.verifyCode(totp, code); err != nil {
+		return nil, err
+	}
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(totp).Updates(map[string]interface{}{"enabled": true, "enabled_at": &now}).Error; err != nil {
+			return err
+		}
+		recoveryCodes, err = totpService.createRecoveryCodes(tx, totp.UserID)
+		return err
+	})
+	return
+}
+
+func (totpService *UserTotpService) createRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	n := global.GVA_CONFIG.Totp.RecoveryCodeCount
+	if n <= 0 {
+		n = 10
+	}
+	codes, err := utils.GenerateRecoveryCodes(n)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Unscoped().Where("user_id = ?", userID).Delete(&system.SysUserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	entities := make([]system.SysUserRecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		entities = append(entities, system.SysUserRecoveryCode{UserID: userID, CodeHash: utils.HashRecoveryCode(code)})
+	}
+	return codes, tx.Create(&entities).Error
+}
+
+func (totpService *UserTotpService) useRecoveryCode(userID uint, code string) error {
+	res := global.GVA_DB.Model(&system.SysUserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, utils.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("恢复码无效或已使用")
+	}
+	return nil
+}
+
+// useRedisTotpChallenge 开启redis时挑战令牌存放于redis 以支持多实例部署
+func useRedisTotpChallenge() bool {
+	return global.GVA_CONFIG.System.UseRedis && global.GVA_REDIS != nil
+}
+
+func (totpService *UserTotpService) saveChallenge(token string, challenge totpChallenge, ttl time.Duration) error {
+	key := totpChallengeKeyPrefix + token
+	if !useRedisTotpChallenge() {
+		global.BlackCache.Set(key, challenge, ttl)
+		return nil
+	}
+	_, err := global.GVA_REDIS.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.HSet(context.Background(), key, challenge)
+		pipe.Expire(context.Background(), key, ttl)
+		return nil
+	})
+	return err
+}
+
+// incrChallengeAttempts 累加挑战的尝试次数 返回累加后的次数 挑战不存在或已过期时返回false
+func (totpService *UserTotpService) incrChallengeAttempts(token string) (int, bool) {
+	key := totpChallengeKeyPrefix + token
+	if useRedisTotpChallenge() {
+		attempts, err := incrTotpChallengeScript.Run(context.Background(), global.GVA_REDIS, []string{key}).Int()
+		return attempts, err == nil && attempts > 0
+	}
+	totpChallengeMu.Lock()
+	defer totpChallengeMu.Unlock()
+	v, expire, ok := global.BlackCache.GetWithExpire(key)
+	if !ok {
+		return 0, false
+	}
+	challenge := v.(totpChallenge)
+	challenge.Attempts++
+	ttl := time.Until(expire)
+	if ttl <= 0 {
+		return 0, false
+	}
+	global.BlackCache.Set(key, challenge, ttl)
+	return challenge.Attempts, true
+}
+
+func (totpService *UserTotpService) loadChallenge(token string) (challenge totpChallenge, ok bool) {
+	if token == "" {
+		return challenge, false
+	}
+	key := totpChallengeKeyPrefix + token
+	if !useRedisTotpChallenge() {
+		v, exist := global.BlackCache.Get(key)
+		if !exist {
+			return challenge, false
+		}
+		challenge, ok = v.(totpChallenge)
+		return challenge, ok
+	}
+	res := global.GVA_REDIS.HGetAll(context.Background(), key)
+	if res.Err() != nil || len(res.Val()) == 0 {
+		return challenge, false
+	}
+	return challenge, res.Scan(&challenge) == nil
+}
+
+func (totpService *UserTotpService) deleteChallenge(token string) {
+	key := totpChallengeKeyPrefix + token
+	if !useRedisTotpChallenge() {
+		global.BlackCache.Delete(key)
+		return
+	}
+	global.GVA_REDIS.Del(context.Background(), key)
+}
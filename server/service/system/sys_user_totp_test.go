@@ -0,0 +1,97 @@
+package system
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/utils"
+)
+
+func TestUserTotpService_ForcedEnrollment(t *testing.T) {
+	user := setupUserTest(t)
+	global.GVA_DB.Create(&system.SysAuthority{AuthorityId: 888, AuthorityName: "admin", RequireTotp: true})
+	s := UserTotpServiceApp
+
+	enabled, required, err := s.TotpState(user)
+	if err != nil || enabled || !required {
+		t.Fatalf("TotpState() = %v, %v, %v; want false, true, nil", enabled, required, err)
+	}
+
+	challenge, _, err := s.CreateChallenge(user.ID)
+	if err != nil {
+		t.Fatalf("CreateChallenge: %v", err)
+	}
+	secret, _, err := s.EnrollByChallenge(challenge)
+	if err != nil {
+		t.Fatalf("EnrollByChallenge: %v", err)
+	}
+	code, _ := utils.TotpCode(secret, uint64(time.Now().Unix()/30))
+	_, recoveryCodes, err := s.VerifyChallenge(challenge, code, "")
+	if err != nil {
+		t.Fatalf("VerifyChallenge: %v", err)
+	}
+	if len(recoveryCodes) == 0 {
+		t.Fatal("first verification should return recovery codes")
+	}
+	if _, _, err = s.VerifyChallenge(challenge, code, ""); !errors.Is(err, ErrTotpChallengeInvalid) {
+		t.Errorf("challenge must be single use, got %v", err)
+	}
+
+	// 同一验证码不可重放
+	challenge, _, _ = s.CreateChallenge(user.ID)
+	if _, _, err = s.VerifyChallenge(challenge, code, ""); !errors.Is(err, ErrTotpCodeInvalid) {
+		t.Errorf("replayed code should be rejected, got %v", err)
+	}
+	// 恢复码仅能使用一次
+	if _, _, err = s.VerifyChallenge(challenge, "", recoveryCodes[0]); err != nil {
+		t.Errorf("recovery code should be accepted, got %v", err)
+	}
+	challenge, _, _ = s.CreateChallenge(user.ID)
+	if _, _, err = s.VerifyChallenge(challenge, "", recoveryCodes[0]); err == nil {
+		t.Error("used recovery code should be rejected")
+	}
+	if err = s.Disable(user, "", recoveryCodes[1]); err == nil {
+		t.Error("required role should not be able to disable totp")
+	}
+}
+
+// 并发提交同一挑战时 尝试次数同样受上限约束
+func TestUserTotpService_ChallengeAttempts(t *testing.T) {
+	user := setupUserTest(t)
+	global.GVA_CONFIG.Totp.MaxAttempts = 3
+	t.Cleanup(func() { global.GVA_CONFIG.Totp.MaxAttempts = 0 })
+	s := UserTotpServiceApp
+	secret, _, err := s.Setup(user.ID, user.Username)
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	code, _ := utils.TotpCode(secret, uint64(time.Now().Unix()/30))
+	if _, err = s.Enable(user.ID, code); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+
+	challenge, _, _ := s.CreateChallenge(user.ID)
+	var wg sync.WaitGroup
+	var guesses atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := s.VerifyChallenge(challenge, "000000", ""); errors.Is(err, ErrTotpCodeInvalid) {
+				guesses.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := guesses.Load(); n > 3 {
+		t.Errorf("verified %d guesses, want at most 3", n)
+	}
+	if _, _, err = s.VerifyChallenge(challenge, code, ""); !errors.Is(err, ErrTotpChallengeInvalid) {
+		t.Errorf("challenge should be invalid after max attempts, got %v", err)
+	}
+}
@@ -57,6 +57,21 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "系统用户", Method: "POST", Path: "/user/setUserAuthority", Description: "修改用户角色(必选)"},
 		{ApiGroup: "系统用户", Method: "POST", Path: "/user/resetPassword", Description: "重置用户密码"},
 		{ApiGroup: "系统用户", Method: "PUT", Path: "/user/setSelfSetting", Description: "用户界面配置"},
+		{ApiGroup: "系统用户", Method: "GET", Path: "/user/getTotpStatus", Description: "获取两步验证状态"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/setupTotp", Description: "生成两步验证密钥"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/enableTotp", Description: "启用两步验证"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/disableTotp", Description: "关闭两步验证"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/regenerateRecoveryCodes", Description: "重新生成两步验证恢复码"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/resetUserTotp", Description: "重置用户两步验证"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/unlockUser", Description: "解除用户登录锁定"},
+		{ApiGroup: "系统用户", Method: "GET", Path: "/user/getSessions", Description: "获取自身的登录会话"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/revokeSession", Description: "撤销自身的登录会话"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/getSessionList", Description: "分页获取全部在线会话"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/createToken", Description: "创建个人访问令牌"},
+		{ApiGroup: "系统用户", Method: "GET", Path: "/user/getTokens", Description: "获取自身的个人访问令牌"},
+		{ApiGroup: "系统用户", Method: "PUT", Path: "/user/updateToken", Description: "修改个人访问令牌"},
+		{ApiGroup: "系统用户", Method: "DELETE", Path: "/user/deleteToken", Description: "删除个人访问令牌"},
+		{ApiGroup: "系统用户", Method: "POST", Path: "/user/forceLogout", Description: "强制用户下线"},
 
 		{ApiGroup: "api", Method: "POST", Path: "/api/createApi", Description: "创建api"},
 		{ApiGroup: "api", Method: "POST", Path: "/api/deleteApi", Description: "删除Api"},
@@ -79,6 +94,7 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 
 		{ApiGroup: "casbin", Method: "POST", Path: "/casbin/updateCasbin", Description: "更改角色api权限"},
 		{ApiGroup: "casbin", Method: "POST", Path: "/casbin/getPolicyPathByAuthorityId", Description: "获取权限列表"},
+		{ApiGroup: "casbin", Method: "POST", Path: "/casbin/explainPermission", Description: "权限模拟"},
 
 		{ApiGroup: "菜单", Method: "POST", Path: "/menu/addBaseMenu", Description: "新增菜单"},
 		{ApiGroup: "菜单", Method: "POST", Path: "/menu/getMenu", Description: "获取菜单树(必选)"},
@@ -122,6 +138,14 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "代码生成器", Method: "POST", Path: "/autoCode/mcpTest", Description: "MCP Tool 测试"},
 		{ApiGroup: "代码生成器", Method: "POST", Path: "/autoCode/mcpList", Description: "获取 MCP ToolList"},
 
+		{ApiGroup: "MCP工具", Method: "POST", Path: "/mcp/create_api", Description: "MCP工具 创建API"},
+		{ApiGroup: "MCP工具", Method: "POST", Path: "/mcp/currentTime", Description: "MCP工具 获取当前时间"},
+		{ApiGroup: "MCP工具", Method: "POST", Path: "/mcp/generate_dictionary_options", Description: "MCP工具 生成字典选项"},
+		{ApiGroup: "MCP工具", Method: "POST", Path: "/mcp/query_dictionaries", Description: "MCP工具 查询字典"},
+		{ApiGroup: "MCP工具", Method: "POST", Path: "/mcp/getNickname", Description: "MCP工具 获取用户昵称"},
+		{ApiGroup: "MCP工具", Method: "POST", Path: "/mcp/gva_auto_generate", Description: "MCP工具 自动生成代码"},
+		{ApiGroup: "MCP工具", Method: "POST", Path: "/mcp/create_menu", Description: "MCP工具 创建菜单"},
+
 		{ApiGroup: "模板配置", Method: "POST", Path: "/autoCode/createPackage", Description: "配置模板"},
 		{ApiGroup: "模板配置", Method: "GET", Path: "/autoCode/getTemplates", Description: "获取模板文件"},
 		{ApiGroup: "模板配置", Method: "POST", Path: "/autoCode/getPackage", Description: "获取所有模板"},
@@ -150,6 +174,9 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "操作记录", Method: "GET", Path: "/sysOperationRecord/getSysOperationRecordList", Description: "获取操作记录列表"},
 		{ApiGroup: "操作记录", Method: "DELETE", Path: "/sysOperationRecord/deleteSysOperationRecord", Description: "删除操作记录"},
 		{ApiGroup: "操作记录", Method: "DELETE", Path: "/sysOperationRecord/deleteSysOperationRecordByIds", Description: "批量删除操作历史"},
+		{ApiGroup: "操作记录", Method: "GET", Path: "/sysOperationRecord/getOperationRecordStats", Description: "获取操作记录写入队列指标"},
+		{ApiGroup: "操作记录", Method: "GET", Path: "/sysOperationRecord/getSysOperationRecordStatistics", Description: "统计操作记录"},
+		{ApiGroup: "操作记录", Method: "GET", Path: "/sysOperationRecord/verifySysOperationRecordChain", Description: "校验操作记录哈希链"},
 
 		{ApiGroup: "断点续传(插件版)", Method: "POST", Path: "/simpleUploader/upload", Description: "插件版分片上传"},
 		{ApiGroup: "断点续传(插件版)", Method: "GET", Path: "/simpleUploader/checkFileMd5", Description: "文件完整度验证"},
@@ -190,6 +217,35 @@ func (i *initApi) InitializeData(ctx context.Context) (context.Context, error) {
 		{ApiGroup: "媒体库分类", Method: "POST", Path: "/attachmentCategory/addCategory", Description: "添加/编辑分类"},
 		{ApiGroup: "媒体库分类", Method: "POST", Path: "/attachmentCategory/deleteCategory", Description: "删除分类"},
 
+		{ApiGroup: "部门管理", Method: "POST", Path: "/dept/createDept", Description: "新增部门"},
+		{ApiGroup: "部门管理", Method: "POST", Path: "/dept/deleteDept", Description: "删除部门"},
+		{ApiGroup: "部门管理", Method: "PUT", Path: "/dept/updateDept", Description: "更新部门"},
+		{ApiGroup: "部门管理", Method: "GET", Path: "/dept/findDept", Description: "根据ID获取部门"},
+		{ApiGroup: "部门管理", Method: "GET", Path: "/dept/getDeptTree", Description: "获取部门树"},
+		{ApiGroup: "部门管理", Method: "POST", Path: "/dept/setUserDept", Description: "设置用户部门"},
+
+		{ApiGroup: "租户管理", Method: "POST", Path: "/tenant/createTenant", Description: "新增租户"},
+		{ApiGroup: "租户管理", Method: "PUT", Path: "/tenant/updateTenant", Description: "更新租户"},
+		{ApiGroup: "租户管理", Method: "DELETE", Path: "/tenant/deleteTenant", Description: "删除租户"},
+		{ApiGroup: "租户管理", Method: "GET", Path: "/tenant/findTenant", Description: "根据ID获取租户"},
+		{ApiGroup: "租户管理", Method: "GET", Path: "/tenant/getTenantList", Description: "获取租户列表"},
+
+		{ApiGroup: "临时授权", Method: "POST", Path: "/authorityGrant/createAuthorityGrant", Description: "创建临时授权"},
+		{ApiGroup: "临时授权", Method: "POST", Path: "/authorityGrant/reviewAuthorityGrant", Description: "审批临时授权"},
+		{ApiGroup: "临时授权", Method: "POST", Path: "/authorityGrant/revokeAuthorityGrant", Description: "撤销临时授权"},
+		{ApiGroup: "临时授权", Method: "GET", Path: "/authorityGrant/getAuthorityGrantList", Description: "获取临时授权列表"},
+
+		{ApiGroup: "定时任务", Method: "POST", Path: "/sysJob/createSysJob", Description: "创建定时任务"},
+		{ApiGroup: "定时任务", Method: "PUT", Path: "/sysJob/updateSysJob", Description: "更新定时任务"},
+		{ApiGroup: "定时任务", Method: "DELETE", Path: "/sysJob/deleteSysJobsByIds", Description: "删除定时任务"},
+		{ApiGroup: "定时任务", Method: "POST", Path: "/sysJob/pauseSysJob", Description: "暂停定时任务"},
+		{ApiGroup: "定时任务", Method: "POST", Path: "/sysJob/resumeSysJob", Description: "恢复定时任务"},
+		{ApiGroup: "定时任务", Method: "POST", Path: "/sysJob/runSysJob", Description: "立即执行定时任务"},
+		{ApiGroup: "定时任务", Method: "GET", Path: "/sysJob/findSysJob", Description: "根据ID获取定时任务"},
+		{ApiGroup: "定时任务", Method: "GET", Path: "/sysJob/getSysJobList", Description: "获取定时任务列表"},
+		{ApiGroup: "定时任务", Method: "GET", Path: "/sysJob/getSysJobRunList", Description: "获取定时任务执行记录"},
+		{ApiGroup: "定时任务", Method: "GET", Path: "/sysJob/getSysJobHandlers", Description: "获取定时任务处理函数"},
+
 		{ApiGroup: "版本控制", Method: "GET", Path: "/sysVersion/findSysVersion", Description: "获取单一版本"},
 		{ApiGroup: "版本控制", Method: "GET", Path: "/sysVersion/getSysVersionList", Description: "获取版本列表"},
 		{ApiGroup: "版本控制", Method: "GET", Path: "/sysVersion/downloadVersionJson", Description: "下载版本json"},
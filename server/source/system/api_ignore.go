@@ -52,6 +52,9 @@ func (i *initApiIgnore) InitializeData(ctx context.Context) (context.Context, er
 		{Method: "POST", Path: "/system/reloadSystem"},
 		{Method: "POST", Path: "/base/login"},
 		{Method: "POST", Path: "/base/captcha"},
+		{Method: "POST", Path: "/base/refresh"},
+		{Method: "POST", Path: "/base/totpLogin"},
+		{Method: "POST", Path: "/base/totpEnroll"},
 		{Method: "POST", Path: "/init/initdb"},
 		{Method: "POST", Path: "/init/checkdb"},
 		{Method: "GET", Path: "/info/getInfoDataSource"},
@@ -86,6 +86,21 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/user/setUserAuthorities", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/user/resetPassword", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/user/setSelfSetting", V2: "PUT"},
+		{Ptype: "p", V0: "888", V1: "/user/getTotpStatus", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/user/setupTotp", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/enableTotp", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/disableTotp", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/regenerateRecoveryCodes", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/resetUserTotp", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/unlockUser", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/getSessions", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/user/revokeSession", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/createToken", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/getTokens", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/user/updateToken", V2: "PUT"},
+		{Ptype: "p", V0: "888", V1: "/user/deleteToken", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/user/getSessionList", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/user/forceLogout", V2: "POST"},
 
 		{Ptype: "p", V0: "888", V1: "/fileUploadAndDownload/findFile", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/fileUploadAndDownload/breakpointContinueFinish", V2: "POST"},
@@ -100,6 +115,7 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 
 		{Ptype: "p", V0: "888", V1: "/casbin/updateCasbin", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/casbin/getPolicyPathByAuthorityId", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/casbin/explainPermission", V2: "POST"},
 
 		{Ptype: "p", V0: "888", V1: "/jwt/jsonInBlacklist", V2: "POST"},
 
@@ -133,6 +149,13 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/autoCode/mcp", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/autoCode/mcpTest", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/autoCode/mcpList", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/mcp/create_api", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/mcp/currentTime", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/mcp/generate_dictionary_options", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/mcp/query_dictionaries", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/mcp/getNickname", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/mcp/gva_auto_generate", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/mcp/create_menu", V2: "POST"},
 
 		{Ptype: "p", V0: "888", V1: "/sysDictionaryDetail/findSysDictionaryDetail", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysDictionaryDetail/updateSysDictionaryDetail", V2: "PUT"},
@@ -152,6 +175,9 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/getSysOperationRecordList", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/deleteSysOperationRecord", V2: "DELETE"},
 		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/deleteSysOperationRecordByIds", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/getOperationRecordStats", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/getSysOperationRecordStatistics", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysOperationRecord/verifySysOperationRecordChain", V2: "GET"},
 
 		{Ptype: "p", V0: "888", V1: "/email/emailTest", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/email/sendEmail", V2: "POST"},
@@ -188,6 +214,32 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "888", V1: "/sysParams/findSysParams", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysParams/getSysParamsList", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/sysParams/getSysParam", V2: "GET"},
+
+		{Ptype: "p", V0: "888", V1: "/dept/createDept", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/dept/deleteDept", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/dept/updateDept", V2: "PUT"},
+		{Ptype: "p", V0: "888", V1: "/dept/findDept", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/dept/getDeptTree", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/dept/setUserDept", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/tenant/createTenant", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/tenant/updateTenant", V2: "PUT"},
+		{Ptype: "p", V0: "888", V1: "/tenant/deleteTenant", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/tenant/findTenant", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/tenant/getTenantList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/authorityGrant/createAuthorityGrant", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/authorityGrant/reviewAuthorityGrant", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/authorityGrant/revokeAuthorityGrant", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/authorityGrant/getAuthorityGrantList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysJob/createSysJob", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysJob/updateSysJob", V2: "PUT"},
+		{Ptype: "p", V0: "888", V1: "/sysJob/deleteSysJobsByIds", V2: "DELETE"},
+		{Ptype: "p", V0: "888", V1: "/sysJob/pauseSysJob", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysJob/resumeSysJob", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysJob/runSysJob", V2: "POST"},
+		{Ptype: "p", V0: "888", V1: "/sysJob/findSysJob", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysJob/getSysJobList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysJob/getSysJobRunList", V2: "GET"},
+		{Ptype: "p", V0: "888", V1: "/sysJob/getSysJobHandlers", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/attachmentCategory/getCategoryList", V2: "GET"},
 		{Ptype: "p", V0: "888", V1: "/attachmentCategory/addCategory", V2: "POST"},
 		{Ptype: "p", V0: "888", V1: "/attachmentCategory/deleteCategory", V2: "POST"},
@@ -221,6 +273,17 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "8881", V1: "/menu/updateBaseMenu", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/menu/getBaseMenuById", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/user/changePassword", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/getTotpStatus", V2: "GET"},
+		{Ptype: "p", V0: "8881", V1: "/user/getSessions", V2: "GET"},
+		{Ptype: "p", V0: "8881", V1: "/user/revokeSession", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/createToken", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/getTokens", V2: "GET"},
+		{Ptype: "p", V0: "8881", V1: "/user/updateToken", V2: "PUT"},
+		{Ptype: "p", V0: "8881", V1: "/user/deleteToken", V2: "DELETE"},
+		{Ptype: "p", V0: "8881", V1: "/user/setupTotp", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/enableTotp", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/disableTotp", V2: "POST"},
+		{Ptype: "p", V0: "8881", V1: "/user/regenerateRecoveryCodes", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/user/getUserList", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/user/setUserAuthority", V2: "POST"},
 		{Ptype: "p", V0: "8881", V1: "/fileUploadAndDownload/upload", V2: "POST"},
@@ -263,6 +326,17 @@ func (i *initCasbin) InitializeData(ctx context.Context) (context.Context, error
 		{Ptype: "p", V0: "9528", V1: "/menu/updateBaseMenu", V2: "POST"},
 		{Ptype: "p", V0: "9528", V1: "/menu/getBaseMenuById", V2: "POST"},
 		{Ptype: "p", V0: "9528", V1: "/user/changePassword", V2: "POST"},
+		{Ptype: "p", V0: "9528", V1: "/user/getTotpStatus", V2: "GET"},
+		{Ptype: "p", V0: "9528", V1: "/user/getSessions", V2: "GET"},
+		{Ptype: "p", V0: "9528", V1: "/user/revokeSession", V2: "POST"},
+		{Ptype: "p", V0: "9528", V1: "/user/createToken", V2: "POST"},
+		{Ptype: "p", V0: "9528", V1: "/user/getTokens", V2: "GET"},
+		{Ptype: "p", V0: "9528", V1: "/user/updateToken", V2: "PUT"},
+		{Ptype: "p", V0: "9528", V1: "/user/deleteToken", V2: "DELETE"},
+		{Ptype: "p", V0: "9528", V1: "/user/setupTotp", V2: "POST"},
+		{Ptype: "p", V0: "9528", V1: "/user/enableTotp", V2: "POST"},
+		{Ptype: "p", V0: "9528", V1: "/user/disableTotp", V2: "POST"},
+		{Ptype: "p", V0: "9528", V1: "/user/regenerateRecoveryCodes", V2: "POST"},
 		{Ptype: "p", V0: "9528", V1: "/user/getUserList", V2: "POST"},
 		{Ptype: "p", V0: "9528", V1: "/user/setUserAuthority", V2: "POST"},
 		{Ptype: "p", V0: "9528", V1: "/fileUploadAndDownload/upload", V2: "POST"},
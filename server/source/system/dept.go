@@ -0,0 +1,82 @@
+package system
+
+import (
+	"context"
+	sysModel "github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const initOrderDept = initOrderUser + 1
+
+type initDept struct{}
+
+// auto run
+func init() {
+	system.RegisterInit(initOrderDept, &initDept{})
+}
+
+func (i *initDept) MigrateTable(ctx context.Context) (context.Context, error) {
+	db, ok := ctx.Value("db").(*gorm.DB)
+	if !ok {
+		return ctx, system.ErrMissingDBContext
+	}
+	return ctx, db.AutoMigrate(&sysModel.SysDept{})
+}
+
+func (i *initDept) TableCreated(ctx context.Context) bool {
+	db, ok := ctx.Value("db").(*gorm.DB)
+	if !ok {
+		return false
+	}
+	return db.Migrator().HasTable(&sysModel.SysDept{})
+}
+
+func (i *initDept) InitializerName() string {
+	return sysModel.SysDept{}.TableName()
+}
+
+func (i *initDept) InitializeData(ctx context.Context) (next context.Context, err error) {
+	db, ok := ctx.Value("db").(*gorm.DB)
+	if !ok {
+		return ctx, system.ErrMissingDBContext
+	}
+	root := sysModel.SysDept{DeptName: "总公司", Leader: "admin", Sort: 1, Enable: 1}
+	if err = db.Create(&root).Error; err != nil {
+		return ctx, errors.Wrap(err, sysModel.SysDept{}.TableName()+"表数据初始化失败!")
+	}
+	children := []sysModel.SysDept{
+		{ParentId: root.ID, DeptName: "研发部", Sort: 1, Enable: 1},
+		{ParentId: root.ID, DeptName: "测试部", Sort: 2, Enable: 1},
+		{ParentId: root.ID, DeptName: "市场部", Sort: 3, Enable: 1},
+	}
+	if err = db.Create(&children).Error; err != nil {
+		return ctx, errors.Wrap(err, sysModel.SysDept{}.TableName()+"表数据初始化失败!")
+	}
+	entities := append([]sysModel.SysDept{root}, children...)
+	next = context.WithValue(ctx, i.InitializerName(), entities)
+
+	users, ok := ctx.Value(new(initUser).InitializerName()).([]sysModel.SysUser)
+	if !ok {
+		return next, errors.Wrap(system.ErrMissingDependentContext, "创建 [用户-部门] 关联失败, 未找到用户表初始化数据")
+	}
+	if err = db.Model(&users[0]).Update("dept_id", root.ID).Error; err != nil {
+		return next, err
+	}
+	if err = db.Model(&users[1]).Update("dept_id", children[0].ID).Error; err != nil {
+		return next, err
+	}
+	return next, nil
+}
+
+func (i *initDept) DataInserted(ctx context.Context) bool {
+	db, ok := ctx.Value("db").(*gorm.DB)
+	if !ok {
+		return false
+	}
+	if errors.Is(db.Where("dept_name = ? AND parent_id = ?", "总公司", 0).First(&sysModel.SysDept{}).Error, gorm.ErrRecordNotFound) {
+		return false
+	}
+	return true
+}
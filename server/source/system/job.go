@@ -0,0 +1,74 @@
+package system
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	sysModel "github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/service/system"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const initOrderJob = initOrderExcelTemplate + 1
+
+type initJob struct{}
+
+// auto run
+func init() {
+	system.RegisterInit(initOrderJob, &initJob{})
+}
+
+func (i *initJob) MigrateTable(ctx context.Context) (context.Context, error) {
+	db, ok := ctx.Value("db").(*gorm.DB)
+	if !ok {
+		return ctx, system.ErrMissingDBContext
+	}
+	return ctx, db.AutoMigrate(&sysModel.SysJob{}, &sysModel.SysJobRun{})
+}
+
+func (i *initJob) TableCreated(ctx context.Context) bool {
+	db, ok := ctx.Value("db").(*gorm.DB)
+	if !ok {
+		return false
+	}
+	return db.Migrator().HasTable(&sysModel.SysJob{}) && db.Migrator().HasTable(&sysModel.SysJobRun{})
+}
+
+func (i *initJob) InitializerName() string {
+	return sysModel.SysJob{}.TableName()
+}
+
+func (i *initJob) InitializeData(ctx context.Context) (context.Context, error) {
+	db, ok := ctx.Value("db").(*gorm.DB)
+	if !ok {
+		return ctx, system.ErrMissingDBContext
+	}
+	// 操作记录保留天数 默认90 小于0时不清理 任务保留但不启用
+	days := global.GVA_CONFIG.OperationRecord.RetentionDays
+	prune := days >= 0
+	if days <= 0 {
+		days = 90
+	}
+	entities := []sysModel.SysJob{
+		{Name: "清理数据库", Spec: "@daily", Handler: "clearTable", Enabled: true, Description: "定时清理数据库【黑名单】内容"},
+		{Name: "清理操作记录", Spec: "@daily", Handler: "pruneOperationRecords", Args: fmt.Sprintf(`{"retentionDays":%d}`, days), Enabled: prune, Description: "定时清理操作记录 清理前写入哈希链检查点"},
+	}
+	if err := db.Create(&entities).Error; err != nil {
+		return ctx, errors.Wrap(err, sysModel.SysJob{}.TableName()+"表数据初始化失败!")
+	}
+	next := context.WithValue(ctx, i.InitializerName(), entities)
+	return next, nil
+}
+
+func (i *initJob) DataInserted(ctx context.Context) bool {
+	db, ok := ctx.Value("db").(*gorm.DB)
+	if !ok {
+		return false
+	}
+	if errors.Is(db.Where("handler = ?", "clearTable").First(&sysModel.SysJob{}).Error, gorm.ErrRecordNotFound) {
+		return false
+	}
+	return true
+}
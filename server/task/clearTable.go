@@ -18,18 +18,26 @@ import (
 func ClearTable(db *gorm.DB) error {
 	var ClearTableDetail []common.ClearDB
 
+	// 操作记录由 OperationRecordService.PruneSysOperationRecords 清理 以保留哈希链检查点
+
 	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
-		TableName:    "sys_operation_records",
+		TableName:    "jwt_blacklists",
 		CompareField: "created_at",
-		Interval:     "2160h",
+		Interval:     "168h",
 	})
 
 	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
-		TableName:    "jwt_blacklists",
-		CompareField: "created_at",
+		TableName:    "sys_user_sessions",
+		CompareField: "expires_at",
 		Interval:     "168h",
 	})
 
+	ClearTableDetail = append(ClearTableDetail, common.ClearDB{
+		TableName:    "sys_job_runs",
+		CompareField: "created_at",
+		Interval:     "720h",
+	})
+
 	if db == nil {
 		return errors.New("db Cannot be empty")
 	}
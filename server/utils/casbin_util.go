@@ -1,13 +1,26 @@
 package utils
 
 import (
+	"strconv"
 	"sync"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/util"
 	gormadapter "github.com/casbin/gorm-adapter/v3"
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// CasbinModelDomain 子角色继承父角色的策略 策略可按域(租户)配置
+	CasbinModelDomain = "domain"
+	// CasbinAllDomains 对所有域生效的策略及角色继承关系所在的域
+	CasbinAllDomains = "*"
+	// DefaultCasbinDomain 请求所属的默认域
+	DefaultCasbinDomain = "default"
 )
 
 var (
@@ -15,38 +28,168 @@ var (
 	once                 sync.Once
 )
 
-// GetCasbin 获取casbin实例
-func GetCasbin() *casbin.SyncedCachedEnforcer {
-	once.Do(func() {
-		a, err := gormadapter.NewAdapterByDB(global.GVA_DB)
-		if err != nil {
-			zap.L().Error("适配数据库失败请检查casbin表是否为InnoDB引擎!", zap.Error(err))
-			return
-		}
-		text := `
+// basicCasbinModel 角色只拥有自身的策略 p = 角色, 路径, 方法
+const basicCasbinModel = `
 		[request_definition]
 		r = sub, obj, act
-		
+
 		[policy_definition]
 		p = sub, obj, act
-		
+
 		[role_definition]
 		g = _, _
-		
+
 		[policy_effect]
 		e = some(where (p.eft == allow))
-		
+
 		[matchers]
 		m = r.sub == p.sub && keyMatch2(r.obj,p.obj) && r.act == p.act
 		`
+
+// domainCasbinModel p = 角色, 域, 路径, 方法 g = 子角色, 父角色, 域 域为*时对所有域生效
+const domainCasbinModel = `
+		[request_definition]
+		r = sub, dom, obj, act
+
+		[policy_definition]
+		p = sub, dom, obj, act
+
+		[role_definition]
+		g = _, _, _
+
+		[policy_effect]
+		e = some(where (p.eft == allow))
+
+		[matchers]
+		m = g(r.sub, p.sub, r.dom) && (p.dom == r.dom || p.dom == "*") && keyMatch2(r.obj,p.obj) && r.act == p.act
+		`
+
+// GetCasbin 获取casbin实例
+func GetCasbin() *casbin.SyncedCachedEnforcer {
+	once.Do(func() {
+		a, err := gormadapter.NewAdapterByDB(global.GVA_DB)
+		if err != nil {
+			zap.L().Error("适配数据库失败请检查casbin表是否为InnoDB引擎!", zap.Error(err))
+			return
+		}
+		text := basicCasbinModel
+		if CasbinDomainEnabled() {
+			text = domainCasbinModel
+		}
 		m, err := model.NewModelFromString(text)
 		if err != nil {
 			zap.L().Error("字符串加载模型失败!", zap.Error(err))
 			return
 		}
+		// 创建实例时即加载策略 需先将策略迁移为当前模型的格式
+		if err = SyncCasbinRules(global.GVA_DB); err != nil {
+			zap.L().Error("同步casbin策略失败!", zap.Error(err))
+		}
 		syncedCachedEnforcer, _ = casbin.NewSyncedCachedEnforcer(m, a)
 		syncedCachedEnforcer.SetExpireTime(60 * 60)
+		if CasbinDomainEnabled() {
+			// 域为*的继承关系对所有域生效
+			syncedCachedEnforcer.AddNamedDomainMatchingFunc("g", "KeyMatch", util.KeyMatch)
+		}
 		_ = syncedCachedEnforcer.LoadPolicy()
+		// 多节点部署时 策略变更同步到其他节点
+		watchCasbin(syncedCachedEnforcer)
 	})
 	return syncedCachedEnforcer
 }
+
+// CasbinDomainEnabled 是否使用支持角色继承和域的权限模型
+func CasbinDomainEnabled() bool {
+	return global.GVA_CONFIG.Casbin.Model == CasbinModelDomain
+}
+
+// CasbinEnforce 判断角色在域中是否拥有接口权限 未开启域时忽略dom
+func CasbinEnforce(sub, dom, obj, act string) (bool, error) {
+	e := GetCasbin()
+	if !CasbinDomainEnabled() {
+		return e.Enforce(sub, obj, act)
+	}
+	if dom == "" {
+		dom = DefaultCasbinDomain
+	}
+	return e.Enforce(sub, dom, obj, act)
+}
+
+// CasbinExplain 判断角色在域中是否拥有接口权限 并返回命中的策略 未命中时策略为空
+func CasbinExplain(sub, dom, obj, act string) (bool, []string, error) {
+	e := GetCasbin()
+	if !CasbinDomainEnabled() {
+		return e.EnforceEx(sub, obj, act)
+	}
+	if dom == "" {
+		dom = DefaultCasbinDomain
+	}
+	return e.EnforceEx(sub, dom, obj, act)
+}
+
+// CasbinPolicy 按当前权限模型组装策略 dom为空时对所有域生效
+func CasbinPolicy(sub, dom, path, method string) []string {
+	if !CasbinDomainEnabled() {
+		return []string{sub, path, method}
+	}
+	if dom == "" {
+		dom = CasbinAllDomains
+	}
+	return []string{sub, dom, path, method}
+}
+
+// CasbinPolicyFilter 按角色筛选策略的条件 开启域时只筛选该域的策略 dom为空时为对所有域生效的策略
+func CasbinPolicyFilter(sub, dom string) []string {
+	if !CasbinDomainEnabled() {
+		return []string{sub}
+	}
+	if dom == "" {
+		dom = CasbinAllDomains
+	}
+	return []string{sub, dom}
+}
+
+// CasbinPathField 策略中路径所在的字段 方法紧随其后
+func CasbinPathField() int {
+	if CasbinDomainEnabled() {
+		return 2
+	}
+	return 1
+}
+
+// SyncCasbinRules 将已有策略迁移为当前权限模型的格式 开启域时按角色树重建继承关系
+// 基础模型的策略迁移后对所有域生效 切换回基础模型时只保留对所有域生效的策略
+func SyncCasbinRules(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if !CasbinDomainEnabled() {
+			if err := tx.Exec("UPDATE casbin_rule SET v1 = v2, v2 = v3, v3 = '' WHERE ptype = 'p' AND v1 = ?", CasbinAllDomains).Error; err != nil {
+				return err
+			}
+			return tx.Where("ptype = 'g' AND v2 = ?", CasbinAllDomains).Delete(&gormadapter.CasbinRule{}).Error
+		}
+		// mysql按从左到右的顺序赋值并使用新值 先赋值v3保证与其他数据库结果一致
+		if err := tx.Exec("UPDATE casbin_rule SET v3 = v2, v2 = v1, v1 = ? WHERE ptype = 'p' AND (v3 = '' OR v3 IS NULL)", CasbinAllDomains).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("ptype = 'g' AND v2 = ?", CasbinAllDomains).Delete(&gormadapter.CasbinRule{}).Error; err != nil {
+			return err
+		}
+		var authorities []system.SysAuthority
+		if err := tx.Select("authority_id", "parent_id").Where("parent_id IS NOT NULL AND parent_id <> 0").Find(&authorities).Error; err != nil {
+			return err
+		}
+		rules := make([]gormadapter.CasbinRule, 0, len(authorities))
+		for _, authority := range authorities {
+			rules = append(rules, gormadapter.CasbinRule{
+				Ptype: "g",
+				V0:    strconv.Itoa(int(authority.AuthorityId)),
+				V1:    strconv.Itoa(int(*authority.ParentId)),
+				V2:    CasbinAllDomains,
+			})
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+}
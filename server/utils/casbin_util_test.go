@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"sync"
+	"testing"
+
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+// setupCasbinTest 使用基础模型格式的策略初始化数据库 按指定模型重新创建casbin实例
+func setupCasbinTest(t *testing.T, casbinModel string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&system.SysAuthority{}, &gormadapter.CasbinRule{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	// 888 > 8881 > 88811 9528 独立
+	for _, authority := range []system.SysAuthority{
+		{AuthorityId: 888, ParentId: Pointer[uint](0)},
+		{AuthorityId: 8881, ParentId: Pointer[uint](888)},
+		{AuthorityId: 88811, ParentId: Pointer[uint](8881)},
+		{AuthorityId: 9528, ParentId: Pointer[uint](0)},
+	} {
+		authority.AuthorityName = "role"
+		db.Create(&authority)
+	}
+	db.Create(&[]gormadapter.CasbinRule{
+		{Ptype: "p", V0: "888", V1: "/user/deleteUser", V2: "DELETE"},
+		{Ptype: "p", V0: "8881", V1: "/api/:id", V2: "GET"},
+		{Ptype: "p", V0: "9528", V1: "/user/getUserInfo", V2: "GET"},
+	})
+	global.GVA_DB = db
+	global.GVA_CONFIG.Casbin.Model = casbinModel
+	once, syncedCachedEnforcer = sync.Once{}, nil
+	t.Cleanup(func() {
+		global.GVA_CONFIG.Casbin.Model = ""
+		once, syncedCachedEnforcer = sync.Once{}, nil
+	})
+	return db
+}
+
+func enforce(t *testing.T, sub, dom, obj, act string) bool {
+	t.Helper()
+	ok, err := CasbinEnforce(sub, dom, obj, act)
+	if err != nil {
+		t.Fatalf("enforce %s %s %s %s: %v", sub, dom, obj, act, err)
+	}
+	return ok
+}
+
+func TestCasbinBasicModel(t *testing.T) {
+	setupCasbinTest(t, "")
+	if !enforce(t, "8881", "", "/api/1", "GET") {
+		t.Error("8881 should keep its own policy")
+	}
+	if enforce(t, "8881", "", "/user/deleteUser", "DELETE") {
+		t.Error("basic model should not inherit parent policies")
+	}
+}
+
+func TestCasbinDomainModel(t *testing.T) {
+	db := setupCasbinTest(t, CasbinModelDomain)
+	e := GetCasbin()
+
+	// 子角色继承父角色及更上层角色的策略 父角色不继承子角色
+	cases := []struct {
+		sub, obj, act string
+		want          bool
+	}{
+		{"8881", "/user/deleteUser", "DELETE", true},
+		{"88811", "/user/deleteUser", "DELETE", true},
+		{"88811", "/api/1", "GET", true},
+		{"888", "/api/1", "GET", false},
+		{"9528", "/user/deleteUser", "DELETE", false},
+		{"9528", "/user/getUserInfo", "GET", true},
+	}
+	for _, c := range cases {
+		if got := enforce(t, c.sub, "", c.obj, c.act); got != c.want {
+			t.Errorf("enforce(%s, %s %s) = %v, want %v", c.sub, c.act, c.obj, got, c.want)
+		}
+	}
+
+	// 按域配置的策略只在该域生效 并同样被子角色继承
+	if _, err := e.AddPolicy(CasbinPolicy("888", "tenant-a", "/order/list", "GET")); err != nil {
+		t.Fatalf("AddPolicy: %v", err)
+	}
+	for _, c := range []struct {
+		sub, dom string
+		want     bool
+	}{
+		{"888", "tenant-a", true},
+		{"8881", "tenant-a", true},
+		{"888", "tenant-b", false},
+		{"888", "", false},
+		{"9528", "tenant-a", false},
+	} {
+		if got := enforce(t, c.sub, c.dom, "/order/list", "GET"); got != c.want {
+			t.Errorf("enforce(%s, %q) = %v, want %v", c.sub, c.dom, got, c.want)
+		}
+	}
+	// 对所有域生效的策略在任意域都生效
+	if !enforce(t, "9528", "tenant-b", "/user/getUserInfo", "GET") {
+		t.Error("policy for all domains should apply in tenant-b")
+	}
+
+	// 切换回基础模型时 只保留对所有域生效的策略
+	global.GVA_CONFIG.Casbin.Model = ""
+	if err := SyncCasbinRules(db); err != nil {
+		t.Fatalf("SyncCasbinRules: %v", err)
+	}
+	var rules []gormadapter.CasbinRule
+	db.Order("id").Find(&rules)
+	for _, rule := range rules {
+		if rule.Ptype == "g" || rule.V1 == CasbinAllDomains {
+			t.Errorf("rule not migrated back: %+v", rule)
+		}
+	}
+	if rules[0].V1 != "/user/deleteUser" || rules[0].V2 != "DELETE" || rules[0].V3 != "" {
+		t.Errorf("unexpected migrated rule: %+v", rules[0])
+	}
+}
@@ -0,0 +1,320 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// CasbinWatcherRedis 通过redis发布订阅同步策略
+	CasbinWatcherRedis = "redis"
+	// CasbinWatcherDB 轮询数据库同步策略 未部署redis时使用
+	CasbinWatcherDB = "db"
+)
+
+const (
+	casbinOpReload         = "reload"
+	casbinOpAdd            = "add"
+	casbinOpRemove         = "remove"
+	casbinOpRemoveFiltered = "removeFiltered"
+)
+
+// casbinEventRetention 数据库中策略变更事件的保留时间
+const casbinEventRetention = 10 * time.Minute
+
+// casbinMessage 节点间同步的策略变更 增删策略时只同步变更的部分 其余情况通知全部重新加载
+type casbinMessage struct {
+	Node       string     `json:"node"`
+	Op         string     `json:"op"`
+	Sec        string     `json:"sec,omitempty"`
+	Ptype      string     `json:"ptype,omitempty"`
+	FieldIndex int        `json:"fieldIndex,omitempty"`
+	Rules      [][]string `json:"rules,omitempty"`
+}
+
+// casbinWatcher 在节点间广播策略变更 实现 persist.WatcherEx
+type casbinWatcher struct {
+	node     string
+	publish  func(payload string) error
+	stop     func()
+	mu       sync.RWMutex
+	callback func(string)
+}
+
+var _ persist.WatcherEx = (*casbinWatcher)(nil)
+
+var activeCasbinWatcher *casbinWatcher
+
+// newCasbinWatcher 按配置创建策略同步器 未配置时返回nil
+func newCasbinWatcher() (*casbinWatcher, error) {
+	switch global.GVA_CONFIG.Casbin.Watcher {
+	case "":
+		return nil, nil
+	case CasbinWatcherRedis:
+		if global.GVA_REDIS == nil {
+			return nil, errors.New("redis未启用, 无法通过redis同步casbin策略")
+		}
+		channel := global.GVA_CONFIG.Casbin.Channel
+		if channel == "" {
+			channel = "gva:casbin"
+		}
+		return newRedisCasbinWatcher(channel), nil
+	case CasbinWatcherDB:
+		interval := time.Duration(global.GVA_CONFIG.Casbin.PollInterval) * time.Second
+		if interval <= 0 {
+			interval = 3 * time.Second
+		}
+		return newDBCasbinWatcher(global.GVA_DB, interval)
+	default:
+		return nil, errors.New("不支持的casbin策略同步方式: " + global.GVA_CONFIG.Casbin.Watcher)
+	}
+}
+
+// newRedisCasbinWatcher 通过redis频道发布和订阅策略变更
+func newRedisCasbinWatcher(channel string) *casbinWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	pubsub := global.GVA_REDIS.Subscribe(ctx, channel)
+	w := &casbinWatcher{node: uuid.NewString()}
+	w.publish = func(payload string) error {
+		return global.GVA_REDIS.Publish(context.Background(), channel, payload).Err()
+	}
+	w.stop = func() {
+		cancel()
+		_ = pubsub.Close()
+	}
+	go func() {
+		for msg := range pubsub.Channel() {
+			w.dispatch(msg.Payload)
+		}
+	}()
+	return w
+}
+
+// newDBCasbinWatcher 将策略变更写入事件表 并定时轮询其他节点写入的事件
+func newDBCasbinWatcher(db *gorm.DB, interval time.Duration) (*casbinWatcher, error) {
+	var lastID uint
+	err := db.Model(&system.SysCasbinEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&lastID).Error
+	if err != nil {
+		return nil, err
+	}
+	w := &casbinWatcher{node: uuid.NewString()}
+	w.publish = func(payload string) error {
+		// 顺带清理过期的事件 各节点已在轮询中处理
+		db.Where("created_at < ?", time.Now().Add(-casbinEventRetention)).Delete(&system.SysCasbinEvent{})
+		return db.Create(&system.SysCasbinEvent{Node: w.node, Payload: payload}).Error
+	}
+	done := make(chan struct{})
+	var once sync.Once
+	w.stop = func() { once.Do(func() { close(done) }) }
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			var events []system.SysCasbinEvent
+			if err := db.Where("id > ?", lastID).Order("id").Limit(100).Find(&events).Error; err != nil {
+				zap.L().Error("轮询casbin策略变更失败!", zap.Error(err))
+				continue
+			}
+			for _, event := range events {
+				lastID = event.ID
+				if event.Node != w.node {
+					w.dispatch(event.Payload)
+				}
+			}
+		}
+	}()
+	return w, nil
+}
+
+// dispatch 处理其他节点发布的策略变更 忽略本节点发布的消息
+func (w *casbinWatcher) dispatch(payload string) {
+	var msg casbinMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		zap.L().Error("解析casbin策略变更失败!", zap.Error(err))
+		return
+	}
+	if msg.Node == w.node {
+		return
+	}
+	w.mu.RLock()
+	callback := w.callback
+	w.mu.RUnlock()
+	if callback != nil {
+		callback(payload)
+	}
+}
+
+func (w *casbinWatcher) send(msg casbinMessage) error {
+	msg.Node = w.node
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return w.publish(string(payload))
+}
+
+func (w *casbinWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+func (w *casbinWatcher) Update() error {
+	return w.send(casbinMessage{Op: casbinOpReload})
+}
+
+func (w *casbinWatcher) Close() {
+	w.stop()
+}
+
+func (w *casbinWatcher) UpdateForAddPolicy(sec, ptype string, params ...string) error {
+	return w.send(casbinMessage{Op: casbinOpAdd, Sec: sec, Ptype: ptype, Rules: [][]string{params}})
+}
+
+func (w *casbinWatcher) UpdateForRemovePolicy(sec, ptype string, params ...string) error {
+	return w.send(casbinMessage{Op: casbinOpRemove, Sec: sec, Ptype: ptype, Rules: [][]string{params}})
+}
+
+func (w *casbinWatcher) UpdateForRemoveFilteredPolicy(sec, ptype string, fieldIndex int, fieldValues ...string) error {
+	return w.send(casbinMessage{Op: casbinOpRemoveFiltered, Sec: sec, Ptype: ptype, FieldIndex: fieldIndex, Rules: [][]string{fieldValues}})
+}
+
+func (w *casbinWatcher) UpdateForSavePolicy(model.Model) error {
+	return w.Update()
+}
+
+func (w *casbinWatcher) UpdateForAddPolicies(sec string, ptype string, rules ...[]string) error {
+	return w.send(casbinMessage{Op: casbinOpAdd, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+func (w *casbinWatcher) UpdateForRemovePolicies(sec string, ptype string, rules ...[]string) error {
+	return w.send(casbinMessage{Op: casbinOpRemove, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+// applyCasbinMessage 将其他节点的策略变更应用到本节点
+// 策略已由发布的节点写入数据库 增量应用时只修改内存中的策略 并清空判定缓存
+func applyCasbinMessage(e *casbin.SyncedCachedEnforcer, payload string) error {
+	var msg casbinMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		return err
+	}
+	switch msg.Op {
+	case casbinOpAdd, casbinOpRemove, casbinOpRemoveFiltered:
+	default:
+		return e.LoadPolicy()
+	}
+	if len(msg.Rules) == 0 {
+		return nil
+	}
+	err := func() error {
+		lock := e.GetLock()
+		lock.Lock()
+		defer lock.Unlock()
+		e.Enforcer.EnableAutoSave(false)
+		defer e.Enforcer.EnableAutoSave(true)
+		var err error
+		switch msg.Op {
+		case casbinOpAdd:
+			_, err = e.Enforcer.SelfAddPoliciesEx(msg.Sec, msg.Ptype, msg.Rules)
+		case casbinOpRemove:
+			_, err = e.Enforcer.SelfRemovePolicies(msg.Sec, msg.Ptype, msg.Rules)
+		case casbinOpRemoveFiltered:
+			_, err = e.Enforcer.SelfRemoveFilteredPolicy(msg.Sec, msg.Ptype, msg.FieldIndex, msg.Rules[0]...)
+		}
+		return err
+	}()
+	if err != nil {
+		return err
+	}
+	return e.InvalidateCache()
+}
+
+// reloadEvery 定时全量加载策略 补偿轮询时跳过的事件和redis重连期间丢失的消息
+func (w *casbinWatcher) reloadEvery(e *casbin.SyncedCachedEnforcer, interval time.Duration) {
+	done := make(chan struct{})
+	var once sync.Once
+	stop := w.stop
+	w.stop = func() {
+		once.Do(func() { close(done) })
+		stop()
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if err := e.LoadPolicy(); err != nil {
+				zap.L().Error("定时加载casbin策略失败!", zap.Error(err))
+			}
+		}
+	}()
+}
+
+// watchCasbin 为实例启用多节点策略同步
+func watchCasbin(e *casbin.SyncedCachedEnforcer) {
+	w, err := newCasbinWatcher()
+	if err != nil {
+		zap.L().Error("创建casbin策略同步器失败!", zap.Error(err))
+		return
+	}
+	if w == nil {
+		return
+	}
+	if err = attachCasbinWatcher(e, w); err != nil {
+		zap.L().Error("设置casbin策略同步器失败!", zap.Error(err))
+		w.Close()
+		return
+	}
+	if interval := global.GVA_CONFIG.Casbin.ReloadInterval; interval >= 0 {
+		if interval == 0 {
+			interval = 60
+		}
+		w.reloadEvery(e, time.Duration(interval)*time.Second)
+	}
+	activeCasbinWatcher = w
+}
+
+// attachCasbinWatcher 本节点的策略变更通过同步器广播 其他节点的变更应用到本节点
+func attachCasbinWatcher(e *casbin.SyncedCachedEnforcer, w *casbinWatcher) error {
+	if err := e.SetWatcher(w); err != nil {
+		return err
+	}
+	return w.SetUpdateCallback(func(payload string) {
+		if err := applyCasbinMessage(e, payload); err != nil {
+			zap.L().Error("同步casbin策略失败!", zap.Error(err))
+		}
+	})
+}
+
+// NotifyCasbinReload 直接修改数据库中的策略并重新加载后 通知其他节点重新加载全部策略
+// 通知失败不影响本节点 其他节点最迟在下次定时全量加载策略后生效
+func NotifyCasbinReload() {
+	if activeCasbinWatcher == nil {
+		return
+	}
+	if err := activeCasbinWatcher.Update(); err != nil {
+		zap.L().Error("通知其他节点重新加载casbin策略失败!", zap.Error(err))
+	}
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+func TestCasbinWatcherDB(t *testing.T) {
+	db := setupCasbinTest(t, "")
+	if err := db.AutoMigrate(&system.SysCasbinEvent{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	global.GVA_CONFIG.Casbin.Watcher = CasbinWatcherDB
+	t.Cleanup(func() {
+		global.GVA_CONFIG.Casbin.Watcher = ""
+		if activeCasbinWatcher != nil {
+			activeCasbinWatcher.Close()
+			activeCasbinWatcher = nil
+		}
+	})
+	local := GetCasbin()
+	if activeCasbinWatcher == nil {
+		t.Fatal("watcher should be attached to the enforcer")
+	}
+
+	// 模拟另一个节点 共用同一个数据库
+	m, _ := model.NewModelFromString(basicCasbinModel)
+	a, _ := gormadapter.NewAdapterByDB(db)
+	remote, err := casbin.NewSyncedCachedEnforcer(m, a)
+	if err != nil {
+		t.Fatalf("new enforcer: %v", err)
+	}
+	remote.SetExpireTime(time.Hour)
+	w, err := newDBCasbinWatcher(db, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("newDBCasbinWatcher: %v", err)
+	}
+	defer w.Close()
+	if err = attachCasbinWatcher(remote, w); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	eventually := func(sub, obj, act string, want bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			if ok, _ := remote.Enforce(sub, obj, act); ok == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("remote enforce %s %s %s != %v", sub, obj, act, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// 先缓存拒绝的判定 同步后需清空缓存
+	if ok, _ := remote.Enforce("9528", "/api/getApiList", "POST"); ok {
+		t.Fatal("policy should not exist yet")
+	}
+	if _, err = local.AddPolicies([][]string{{"9528", "/api/getApiList", "POST"}, {"9528", "/api/createApi", "POST"}}); err != nil {
+		t.Fatalf("AddPolicies: %v", err)
+	}
+	eventually("9528", "/api/getApiList", "POST", true)
+	var count int64
+	db.Model(&gormadapter.CasbinRule{}).Where("v0 = ?", "9528").Count(&count)
+	if count != 3 {
+		t.Errorf("policies of 9528 = %d, want 3 (applied changes should not be saved again)", count)
+	}
+
+	local.RemoveFilteredPolicy(0, "9528")
+	eventually("9528", "/api/createApi", "POST", false)
+	eventually("9528", "/user/getUserInfo", "GET", false)
+
+	// 直接修改数据库后通知其他节点重新加载
+	db.Create(&gormadapter.CasbinRule{Ptype: "p", V0: "9528", V1: "/menu/getMenu", V2: "POST"})
+	_ = local.LoadPolicy()
+	NotifyCasbinReload()
+	eventually("9528", "/menu/getMenu", "POST", true)
+	if ok, _ := local.Enforce("9528", "/menu/getMenu", "POST"); !ok {
+		t.Error("local enforcer should keep its own policies")
+	}
+
+	// 丢失的变更在定时全量加载后生效
+	w.reloadEvery(remote, 20*time.Millisecond)
+	db.Create(&gormadapter.CasbinRule{Ptype: "p", V0: "9528", V1: "/menu/addBaseMenu", V2: "POST"})
+	eventually("9528", "/menu/addBaseMenu", "POST", true)
+}
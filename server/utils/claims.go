@@ -55,6 +55,12 @@ func GetToken(c *gin.Context) string {
 }
 
 func GetClaims(c *gin.Context) (*systemReq.CustomClaims, error) {
+	// 鉴权中间件已解析的claims 个人访问令牌无法按jwt解析
+	if claims, exists := c.Get("claims"); exists {
+		if cl, ok := claims.(*systemReq.CustomClaims); ok {
+			return cl, nil
+		}
+	}
 	token := GetToken(c)
 	j := NewJWT()
 	claims, err := j.ParseToken(token)
@@ -106,6 +112,20 @@ func GetUserAuthorityId(c *gin.Context) uint {
 	}
 }
 
+// GetUserTenantId 从Gin的Context中获取从jwt解析出来的用户所属租户id 0为平台用户
+func GetUserTenantId(c *gin.Context) uint {
+	if claims, exists := c.Get("claims"); !exists {
+		if cl, err := GetClaims(c); err != nil {
+			return 0
+		} else {
+			return cl.TenantID
+		}
+	} else {
+		waitUse := claims.(*systemReq.CustomClaims)
+		return waitUse.TenantID
+	}
+}
+
 // GetUserInfo 从Gin的Context中获取从jwt解析出来的用户角色id
 func GetUserInfo(c *gin.Context) *systemReq.CustomClaims {
 	if claims, exists := c.Get("claims"); !exists {
@@ -137,13 +157,41 @@ func GetUserName(c *gin.Context) string {
 func LoginToken(user system.Login) (token string, claims systemReq.CustomClaims, err error) {
 	j := NewJWT()
 	claims = j.CreateClaims(systemReq.BaseClaims{
-		UUID:         user.GetUUID(),
-		ID:           user.GetUserId(),
-		NickName:     user.GetNickname(),
-		Username:     user.GetUsername(),
-		AuthorityId:  user.GetAuthorityId(),
-		AuthorityIds: user.GetAuthorityIds(),
+		UUID:               user.GetUUID(),
+		ID:                 user.GetUserId(),
+		NickName:           user.GetNickname(),
+		Username:           user.GetUsername(),
+		AuthorityId:        user.GetAuthorityId(),
+		AuthorityIds:       user.GetAuthorityIds(),
+		TenantID:           user.GetTenantId(),
+		MustChangePassword: user.GetMustChangePassword(),
 	})
+	// 未开启刷新令牌时令牌族仅用于标识登录会话
+	claims.FamilyID = uuid.New().String()
 	token, err = j.CreateToken(claims)
 	return
 }
+
+// LoginTokenPair 签发同一令牌族的访问令牌与刷新令牌 访问令牌不参与缓冲续期
+func LoginTokenPair(user system.Login, familyID string) (pair TokenPair, err error) {
+	j := NewJWT()
+	baseClaims := systemReq.BaseClaims{
+		UUID:               user.GetUUID(),
+		ID:                 user.GetUserId(),
+		NickName:           user.GetNickname(),
+		Username:           user.GetUsername(),
+		AuthorityId:        user.GetAuthorityId(),
+		AuthorityIds:       user.GetAuthorityIds(),
+		TenantID:           user.GetTenantId(),
+		MustChangePassword: user.GetMustChangePassword(),
+	}
+	pair.Claims = j.CreateClaims(baseClaims)
+	pair.Claims.BufferTime = 0
+	pair.Claims.FamilyID = familyID
+	if pair.Token, err = j.CreateToken(pair.Claims); err != nil {
+		return
+	}
+	pair.RefreshClaims = j.CreateRefreshClaims(baseClaims, familyID)
+	pair.RefreshToken, err = j.CreateToken(pair.RefreshClaims)
+	return
+}
@@ -0,0 +1,277 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// CronLockRedis 通过redis领取每次触发
+	CronLockRedis = "redis"
+	// CronLockDB 通过数据库行的条件更新领取每次触发 未部署redis时使用
+	CronLockDB = "db"
+)
+
+const (
+	// CronMisfireFireOnce 补偿执行一次 错过多次时也只执行一次
+	CronMisfireFireOnce = "fire-once"
+	// CronMisfireIgnore 跳过错过的触发
+	CronMisfireIgnore = "ignore"
+)
+
+const cronLockKeyPrefix = "gva:cron:"
+
+// claimCronRunScript 触发时间晚于最近一次执行时领取成功
+// KEYS: 任务key ARGV: 触发时间 unix秒
+var claimCronRunScript = redis.NewScript(`
+local last = tonumber(redis.call('GET', KEYS[1]) or '0')
+if last >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1])
+return 1
+`)
+
+// CronParser 定时任务的cron表达式解析 秒可省略
+// 开启集群模式时 @every 按固定时间对齐触发 各节点的触发时间一致 才能按触发时间领取
+// 未开启时与 cron 默认行为一致 从加入定时任务起按间隔触发
+var CronParser cron.ScheduleParser = cronParser{cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)}
+
+type cronParser struct {
+	cron.Parser
+}
+
+func (p cronParser) Parse(spec string) (cron.Schedule, error) {
+	schedule, err := p.Parser.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	if delay, ok := schedule.(cron.ConstantDelaySchedule); ok && global.GVA_CONFIG.Cron.Cluster {
+		return alignedSchedule{delay.Delay}, nil
+	}
+	return schedule, nil
+}
+
+// alignedSchedule 按间隔的整数倍触发 与节点的启动时间无关
+type alignedSchedule struct {
+	delay time.Duration
+}
+
+func (s alignedSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(s.delay).Add(s.delay)
+}
+
+type clusterCronJob struct {
+	schedule cron.Schedule
+	run      func()
+}
+
+var (
+	clusterCronJobsMu sync.RWMutex
+	clusterCronJobs   = make(map[string]clusterCronJob)
+	cronNode          = newCronNode()
+)
+
+func newCronNode() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return host + "-" + strconv.Itoa(os.Getpid())
+}
+
+// ClusterJob 包装定时任务 开启集群模式时每次触发只由领取成功的节点执行
+// name 在集群中唯一标识任务 spec 需与加入定时任务时相同 且使用 CronParser 解析
+// spec 无法解析时原样返回 fn 加入定时任务时会返回解析错误
+func ClusterJob(name, spec string, fn func()) func() {
+	schedule, err := CronParser.Parse(spec)
+	if err != nil {
+		return fn
+	}
+	clusterCronJobsMu.Lock()
+	clusterCronJobs[name] = clusterCronJob{schedule: schedule, run: fn}
+	clusterCronJobsMu.Unlock()
+	return func() {
+		if !global.GVA_CONFIG.Cron.Cluster {
+			fn()
+			return
+		}
+		now := time.Now()
+		fire := lastCronFire(schedule, now.Add(-time.Minute), now)
+		if fire.IsZero() {
+			fire = now.Truncate(time.Second)
+		}
+		claimed, err := ClaimCronRun(name, fire)
+		if err != nil {
+			// 领取失败时不执行 恢复后由错过触发的检查补偿
+			global.GVA_LOG.Error("领取定时任务失败!", zap.String("job", name), zap.Error(err))
+			return
+		}
+		if claimed {
+			fn()
+		}
+	}
+}
+
+// RemoveClusterJob 移除任务 不再检查错过的触发
+func RemoveClusterJob(name string) {
+	clusterCronJobsMu.Lock()
+	defer clusterCronJobsMu.Unlock()
+	delete(clusterCronJobs, name)
+}
+
+// RemoveClusterJobs 移除标识以prefix开头的任务
+func RemoveClusterJobs(prefix string) {
+	clusterCronJobsMu.Lock()
+	defer clusterCronJobsMu.Unlock()
+	for name := range clusterCronJobs {
+		if strings.HasPrefix(name, prefix) {
+			delete(clusterCronJobs, name)
+		}
+	}
+}
+
+// ClaimCronRun 领取任务在fire时刻的触发 集群中只有一个节点领取成功
+// 同时记录最近一次执行的触发时间 用于检查错过的触发
+func ClaimCronRun(name string, fire time.Time) (bool, error) {
+	lock, err := cronLock()
+	if err != nil {
+		return false, err
+	}
+	switch lock {
+	case CronLockRedis:
+		claimed, err := claimCronRunScript.Run(context.Background(), global.GVA_REDIS, []string{cronLockKeyPrefix + name}, fire.Unix()).Int()
+		return claimed == 1, err
+	default:
+		err = global.GVA_DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&system.SysCronLock{Name: name}).Error
+		if err != nil {
+			return false, err
+		}
+		result := global.GVA_DB.Model(&system.SysCronLock{}).Where("name = ? AND last_fire < ?", name, fire.Unix()).
+			Updates(map[string]interface{}{"last_fire": fire.Unix(), "node": cronNode})
+		return result.RowsAffected == 1, result.Error
+	}
+}
+
+// LastCronRun 集群中最近一次执行的触发时间 从未执行时为零值
+func LastCronRun(name string) (time.Time, error) {
+	lock, err := cronLock()
+	if err != nil {
+		return time.Time{}, err
+	}
+	var last int64
+	switch lock {
+	case CronLockRedis:
+		v, err := global.GVA_REDIS.Get(context.Background(), cronLockKeyPrefix+name).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return time.Time{}, err
+		}
+		last = v
+	default:
+		var locks []system.SysCronLock
+		if err := global.GVA_DB.Where("name = ?", name).Limit(1).Find(&locks).Error; err != nil {
+			return time.Time{}, err
+		}
+		if len(locks) > 0 {
+			last = locks[0].LastFire
+		}
+	}
+	if last == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(last, 0), nil
+}
+
+// CheckCronMisfires 检查所有节点都未运行时错过的触发 按配置补偿执行一次或跳过
+// 从未执行过的任务无法判断是否错过 不做处理
+func CheckCronMisfires(now time.Time) {
+	if !global.GVA_CONFIG.Cron.Cluster {
+		return
+	}
+	threshold := time.Duration(global.GVA_CONFIG.Cron.MisfireThreshold) * time.Second
+	if threshold <= 0 {
+		threshold = time.Minute
+	}
+	clusterCronJobsMu.RLock()
+	jobs := make(map[string]clusterCronJob, len(clusterCronJobs))
+	for name, job := range clusterCronJobs {
+		jobs[name] = job
+	}
+	clusterCronJobsMu.RUnlock()
+
+	for name, job := range jobs {
+		last, err := LastCronRun(name)
+		if err != nil {
+			global.GVA_LOG.Error("检查错过的定时任务失败!", zap.String("job", name), zap.Error(err))
+			continue
+		}
+		if last.IsZero() {
+			continue
+		}
+		// 错过多次时只处理最近的一次
+		fire := lastCronFire(job.schedule, last, now.Add(-threshold))
+		if fire.IsZero() {
+			continue
+		}
+		claimed, err := ClaimCronRun(name, fire)
+		if err != nil {
+			global.GVA_LOG.Error("领取定时任务失败!", zap.String("job", name), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if global.GVA_CONFIG.Cron.Misfire == CronMisfireIgnore {
+			global.GVA_LOG.Warn("跳过错过的定时任务", zap.String("job", name), zap.Time("fire", fire))
+			continue
+		}
+		global.GVA_LOG.Info("补偿执行错过的定时任务", zap.String("job", name), zap.Time("fire", fire))
+		go job.run()
+	}
+}
+
+// lastCronFire 返回 (from, to] 内最后一次触发时间 没有时为零值
+func lastCronFire(schedule cron.Schedule, from, to time.Time) time.Time {
+	var fire time.Time
+	for next := schedule.Next(from); !next.IsZero() && !next.After(to); next = schedule.Next(next) {
+		fire = next
+	}
+	return fire
+}
+
+// cronLock 按配置选择分布式锁 未配置时启用了redis则使用redis
+func cronLock() (string, error) {
+	lock := global.GVA_CONFIG.Cron.Lock
+	if lock == "" {
+		lock = CronLockDB
+		if global.GVA_REDIS != nil {
+			lock = CronLockRedis
+		}
+	}
+	switch lock {
+	case CronLockRedis:
+		if global.GVA_REDIS == nil {
+			return "", errors.New("redis未启用, 无法通过redis领取定时任务")
+		}
+	case CronLockDB:
+		if global.GVA_DB == nil {
+			return "", errors.New("数据库未初始化, 无法通过数据库领取定时任务")
+		}
+	default:
+		return "", errors.New("不支持的定时任务分布式锁: " + lock)
+	}
+	return lock, nil
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+)
+
+func TestClusterJobDB(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&system.SysCronLock{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	global.GVA_DB = db
+	global.GVA_LOG = zap.NewNop()
+	global.GVA_CONFIG.Cron.Cluster = true
+	global.GVA_CONFIG.Cron.Lock = CronLockDB
+	t.Cleanup(func() {
+		global.GVA_CONFIG.Cron.Cluster = false
+		global.GVA_CONFIG.Cron.Lock = ""
+		global.GVA_CONFIG.Cron.Misfire = ""
+		RemoveClusterJobs("test.")
+	})
+
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	// 未开启集群模式时 @every 从调用时间起按间隔触发
+	global.GVA_CONFIG.Cron.Cluster = false
+	schedule, _ := CronParser.Parse("@every 1m")
+	if next := schedule.Next(base.Add(17 * time.Second)); !next.Equal(base.Add(77 * time.Second)) {
+		t.Errorf("unaligned next = %v", next)
+	}
+
+	// 开启集群模式时 @every 按间隔对齐 与调用时间无关
+	global.GVA_CONFIG.Cron.Cluster = true
+	schedule, _ = CronParser.Parse("@every 1m")
+	if next := schedule.Next(base.Add(17 * time.Second)); !next.Equal(base.Add(time.Minute)) {
+		t.Errorf("aligned next = %v", next)
+	}
+	if fire := lastCronFire(schedule, base.Add(-time.Hour), base.Add(90*time.Second)); !fire.Equal(base.Add(time.Minute)) {
+		t.Errorf("last fire = %v", fire)
+	}
+
+	// 同一触发只有一个节点领取成功
+	fire := base.Add(time.Minute)
+	for i, want := range []bool{true, false} {
+		if claimed, err := ClaimCronRun("test.claim", fire); err != nil || claimed != want {
+			t.Errorf("claim #%d = %v, %v", i, claimed, err)
+		}
+	}
+	if claimed, _ := ClaimCronRun("test.claim", fire.Add(time.Minute)); !claimed {
+		t.Error("next fire should be claimed")
+	}
+	if last, err := LastCronRun("test.claim"); err != nil || !last.Equal(fire.Add(time.Minute)) {
+		t.Errorf("last run = %v, %v", last, err)
+	}
+
+	// 两个节点同时触发 只执行一次
+	var runs int32
+	fn := func() { atomic.AddInt32(&runs, 1) }
+	nodeA, nodeB := ClusterJob("test.job", "@every 1s", fn), ClusterJob("test.job", "@every 1s", fn)
+	nodeA()
+	nodeB()
+	if atomic.LoadInt32(&runs) != 1 {
+		t.Errorf("runs = %d", runs)
+	}
+
+	// 所有节点停止期间错过的触发 恢复后补偿执行一次
+	runs = 0
+	ClusterJob("test.daily", "@daily", fn)
+	if _, err = ClaimCronRun("test.daily", time.Now().AddDate(0, 0, -3)); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	CheckCronMisfires(time.Now())
+	CheckCronMisfires(time.Now())
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&runs) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&runs) != 1 {
+		t.Errorf("misfire runs = %d", runs)
+	}
+
+	global.GVA_CONFIG.Cron.Misfire = CronMisfireIgnore
+	ClusterJob("test.ignore", "@hourly", func() { t.Error("ignored misfire should not run") })
+	if _, err = ClaimCronRun("test.ignore", time.Now().Add(-3*time.Hour)); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	CheckCronMisfires(time.Now())
+	if last, _ := LastCronRun("test.ignore"); time.Since(last) > 2*time.Hour {
+		t.Errorf("ignored misfire should advance the last run, got %v", last)
+	}
+	time.Sleep(50 * time.Millisecond)
+}
@@ -8,12 +8,28 @@ import (
 	"github.com/flipped-aurora/gin-vue-admin/server/global"
 	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
 	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type JWT struct {
 	SigningKey []byte
 }
 
+const (
+	// McpAudience MCP专用令牌的受众 此类令牌只能用于访问MCP服务
+	McpAudience = "GVA_MCP"
+	// RefreshAudience 刷新令牌的受众 此类令牌只能用于 /base/refresh 换取新令牌
+	RefreshAudience = "GVA_REFRESH"
+)
+
+// TokenPair 同一令牌族的访问令牌与刷新令牌
+type TokenPair struct {
+	Token         string
+	Claims        request.CustomClaims
+	RefreshToken  string
+	RefreshClaims request.CustomClaims
+}
+
 var (
 	TokenValid            = errors.New("未知错误")
 	TokenExpired          = errors.New("token已过期")
@@ -45,10 +61,67 @@ func (j *JWT) CreateClaims(baseClaims request.BaseClaims) request.CustomClaims {
 	return claims
 }
 
-// CreateToken 创建一个token
+// CreateMcpClaims 创建MCP专用令牌的claims 不参与缓冲刷新
+func (j *JWT) CreateMcpClaims(baseClaims request.BaseClaims) request.CustomClaims {
+	ep, err := ParseDuration(global.GVA_CONFIG.MCP.TokenExpiresTime)
+	if err != nil || ep <= 0 {
+		ep, _ = ParseDuration(global.GVA_CONFIG.JWT.ExpiresTime)
+	}
+	claims := j.CreateClaims(baseClaims)
+	claims.BufferTime = 0
+	claims.Audience = jwt.ClaimStrings{McpAudience}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(ep))
+	return claims
+}
+
+// CreateRefreshClaims 创建刷新令牌的claims 每个刷新令牌拥有唯一jti 用于检测重复使用
+func (j *JWT) CreateRefreshClaims(baseClaims request.BaseClaims, familyID string) request.CustomClaims {
+	ep, _ := ParseDuration(global.GVA_CONFIG.JWT.RefreshExpiresTime)
+	claims := j.CreateClaims(baseClaims)
+	claims.BufferTime = 0
+	claims.FamilyID = familyID
+	claims.Audience = jwt.ClaimStrings{RefreshAudience}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(ep))
+	claims.RegisteredClaims.ID = uuid.New().String()
+	return claims
+}
+
+// IsMcpClaims 判断是否为MCP专用令牌
+func IsMcpClaims(claims *request.CustomClaims) bool {
+	return hasAudience(claims, McpAudience)
+}
+
+// IsRefreshClaims 判断是否为刷新令牌
+func IsRefreshClaims(claims *request.CustomClaims) bool {
+	return hasAudience(claims, RefreshAudience)
+}
+
+func hasAudience(claims *request.CustomClaims, audience string) bool {
+	for _, aud := range claims.Audience {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateToken 创建一个token 非对称算法签名时在头部写入kid
 func (j *JWT) CreateToken(claims request.CustomClaims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.SigningKey)
+	method, err := jwtSigningMethod()
+	if err != nil {
+		return "", err
+	}
+	if method == jwt.SigningMethodHS256 {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(j.SigningKey)
+	}
+	key, err := jwtKeys.signingKey(method.Alg())
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
 }
 
 // CreateTokenByOldToken 旧token 换新token 使用归并回源避免并发问题
@@ -61,9 +134,18 @@ func (j *JWT) CreateTokenByOldToken(oldToken string, claims request.CustomClaims
 
 // ParseToken 解析 token
 func (j *JWT) ParseToken(tokenString string) (*request.CustomClaims, error) {
+	method, err := jwtSigningMethod()
+	if err != nil {
+		return nil, TokenInvalid
+	}
+	// 只接受配置的签名算法 避免算法混淆
 	token, err := jwt.ParseWithClaims(tokenString, &request.CustomClaims{}, func(token *jwt.Token) (i interface{}, e error) {
-		return j.SigningKey, nil
-	})
+		if method == jwt.SigningMethodHS256 {
+			return j.SigningKey, nil
+		}
+		kid, _ := token.Header["kid"].(string)
+		return jwtKeys.verifyKey(method.Alg(), kid)
+	}, jwt.WithValidMethods([]string{method.Alg()}))
 
 	if err != nil {
 		switch {
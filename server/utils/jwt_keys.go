@@ -0,0 +1,330 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	jwtKeyReloadInterval = time.Minute     // 本节点密钥缓存的刷新周期 其他节点轮换后最迟在此时间内切换签名密钥
+	jwtKeyMissInterval   = 5 * time.Second // 遇到未知kid时重新加载的最小间隔
+)
+
+var ErrJwtKeyNotFound = errors.New("未找到令牌对应的签名密钥")
+
+// jwtKey 解析后的签名密钥
+type jwtKey struct {
+	kid     string
+	alg     string
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// jwtKeyRing 缓存数据库中的非对称密钥 最新的未退役密钥用于签名 宽限期内的密钥都可用于验签
+type jwtKeyRing struct {
+	mu       sync.RWMutex
+	alg      string
+	signing  *jwtKey
+	keys     map[string]*jwtKey
+	loadedAt time.Time
+}
+
+var jwtKeys = new(jwtKeyRing)
+
+// jwtSigningMethod 读取配置的签名算法 默认HS256
+func jwtSigningMethod() (jwt.SigningMethod, error) {
+	switch strings.ToUpper(strings.TrimSpace(global.GVA_CONFIG.JWT.SigningMethod)) {
+	case "", "HS256":
+		return jwt.SigningMethodHS256, nil
+	case "RS256":
+		return jwt.SigningMethodRS256, nil
+	case "ES256":
+		return jwt.SigningMethodES256, nil
+	case "EDDSA":
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("不支持的jwt签名算法: %s", global.GVA_CONFIG.JWT.SigningMethod)
+}
+
+// IsAsymmetricJwt 判断是否使用非对称算法签名
+func IsAsymmetricJwt() bool {
+	method, err := jwtSigningMethod()
+	return err == nil && method != jwt.SigningMethodHS256
+}
+
+// jwtKeyGracePeriod 旧密钥退役后继续验签的时长 未配置时取各类令牌的最长有效期
+func jwtKeyGracePeriod() time.Duration {
+	cfg := global.GVA_CONFIG
+	if d, err := ParseDuration(cfg.JWT.KeyGracePeriod); err == nil && d > 0 {
+		return d
+	}
+	grace, _ := ParseDuration(cfg.JWT.ExpiresTime)
+	if cfg.JWT.UseRefreshToken {
+		if d, err := ParseDuration(cfg.JWT.RefreshExpiresTime); err == nil {
+			grace = max(grace, d)
+		}
+	}
+	if d, err := ParseDuration(cfg.MCP.TokenExpiresTime); err == nil {
+		grace = max(grace, d)
+	}
+	return grace
+}
+
+// signingKey 获取当前签名密钥 没有可用密钥时生成一个
+func (r *jwtKeyRing) signingKey(alg string) (*jwtKey, error) {
+	r.mu.RLock()
+	key := r.signing
+	fresh := r.alg == alg && time.Since(r.loadedAt) < jwtKeyReloadInterval
+	r.mu.RUnlock()
+	if fresh && key != nil {
+		return key, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.alg != alg || time.Since(r.loadedAt) >= jwtKeyReloadInterval || r.signing == nil {
+		if err := r.load(alg); err != nil {
+			return nil, err
+		}
+	}
+	return r.signing, nil
+}
+
+// verifyKey 按kid获取验签公钥 未命中时重新加载 以识别其他节点刚生成的密钥
+func (r *jwtKeyRing) verifyKey(alg, kid string) (crypto.PublicKey, error) {
+	r.mu.RLock()
+	key, ok := r.keys[kid]
+	fresh := r.alg == alg && time.Since(r.loadedAt) < jwtKeyReloadInterval
+	missed := time.Since(r.loadedAt) >= jwtKeyMissInterval
+	r.mu.RUnlock()
+	if ok && fresh {
+		return key.public, nil
+	}
+	if fresh && !missed {
+		return nil, ErrJwtKeyNotFound
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(alg); err != nil {
+		return nil, err
+	}
+	if key, ok = r.keys[kid]; ok {
+		return key.public, nil
+	}
+	return nil, ErrJwtKeyNotFound
+}
+
+// load 从数据库加载密钥 调用方需持有写锁
+func (r *jwtKeyRing) load(alg string) error {
+	if global.GVA_DB == nil {
+		return errors.New("数据库未初始化 无法加载jwt签名密钥")
+	}
+	records, err := loadJwtKeyRecords(global.GVA_DB, alg)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 || records[len(records)-1].RetiredAt != nil {
+		record, err := newJwtKeyRecord(alg)
+		if err != nil {
+			return err
+		}
+		if err = global.GVA_DB.Create(&record).Error; err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	keys := make(map[string]*jwtKey, len(records))
+	var signing *jwtKey
+	for _, record := range records {
+		key, err := parseJwtKeyRecord(record)
+		if err != nil {
+			return err
+		}
+		keys[key.kid] = key
+		if record.RetiredAt == nil {
+			signing = key
+		}
+	}
+	r.alg, r.keys, r.signing, r.loadedAt = alg, keys, signing, time.Now()
+	return nil
+}
+
+// invalidate 使缓存失效 下次使用时重新加载
+func (r *jwtKeyRing) invalidate() {
+	r.mu.Lock()
+	r.loadedAt = time.Time{}
+	r.mu.Unlock()
+}
+
+// loadJwtKeyRecords 查询仍可用于验签的密钥 按创建顺序排列
+func loadJwtKeyRecords(db *gorm.DB, alg string) (records []system.SysJwtKey, err error) {
+	err = db.Where("algorithm = ? AND (retired_at IS NULL OR retired_at > ?)", alg, time.Now().Add(-jwtKeyGracePeriod())).
+		Order("id").Find(&records).Error
+	return
+}
+
+// newJwtKeyRecord 生成指定算法的密钥对
+func newJwtKeyRecord(alg string) (record system.SysJwtKey, err error) {
+	var private crypto.Signer
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jwt.SigningMethodES256.Alg():
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("不支持的jwt签名算法: %s", alg)
+	}
+	if err != nil {
+		return
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return
+	}
+	return system.SysJwtKey{
+		Kid:        uuid.New().String(),
+		Algorithm:  alg,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+	}, nil
+}
+
+func parseJwtKeyRecord(record system.SysJwtKey) (*jwtKey, error) {
+	block, _ := pem.Decode([]byte(record.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("jwt签名密钥 %s 格式错误", record.Kid)
+	}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("jwt签名密钥 %s 类型错误", record.Kid)
+	}
+	return &jwtKey{kid: record.Kid, alg: record.Algorithm, private: signer, public: signer.Public()}, nil
+}
+
+//@function: RotateJwtKeys
+//@description: 当前签名密钥超过轮换周期时生成新密钥并退役旧密钥 同时删除超过宽限期的密钥
+//@return: rotated bool, err error
+
+func RotateJwtKeys() (rotated bool, err error) {
+	method, err := jwtSigningMethod()
+	if err != nil || method == jwt.SigningMethodHS256 {
+		return false, err
+	}
+	interval, _ := ParseDuration(global.GVA_CONFIG.JWT.KeyRotation)
+	now := time.Now()
+	err = global.GVA_DB.Transaction(func(tx *gorm.DB) error {
+		if interval > 0 {
+			// 多个节点同时执行时只有一个节点能退役成功
+			result := tx.Model(&system.SysJwtKey{}).
+				Where("algorithm = ? AND retired_at IS NULL AND created_at <= ?", method.Alg(), now.Add(-interval)).
+				Update("retired_at", now)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				var active int64
+				if err := tx.Model(&system.SysJwtKey{}).Where("algorithm = ? AND retired_at IS NULL", method.Alg()).Count(&active).Error; err != nil {
+					return err
+				}
+				if active == 0 {
+					record, err := newJwtKeyRecord(method.Alg())
+					if err != nil {
+						return err
+					}
+					if err = tx.Create(&record).Error; err != nil {
+						return err
+					}
+				}
+				rotated = true
+			}
+		}
+		return tx.Unscoped().Where("retired_at < ?", now.Add(-jwtKeyGracePeriod())).Delete(&system.SysJwtKey{}).Error
+	})
+	if rotated {
+		jwtKeys.invalidate()
+	}
+	return
+}
+
+//@function: JwtKeySet
+//@description: 获取验签公钥集合 HS256模式下返回空集合
+//@return: set systemRes.JSONWebKeySet, err error
+
+func JwtKeySet() (set systemRes.JSONWebKeySet, err error) {
+	set.Keys = []systemRes.JSONWebKey{}
+	method, err := jwtSigningMethod()
+	if err != nil || method == jwt.SigningMethodHS256 {
+		return
+	}
+	// 确保存在签名密钥且缓存未过期
+	if _, err = jwtKeys.signingKey(method.Alg()); err != nil {
+		return
+	}
+	jwtKeys.mu.RLock()
+	keys := make([]*jwtKey, 0, len(jwtKeys.keys))
+	for _, key := range jwtKeys.keys {
+		keys = append(keys, key)
+	}
+	jwtKeys.mu.RUnlock()
+	for _, key := range keys {
+		jwk, err := toJSONWebKey(key)
+		if err != nil {
+			return set, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return
+}
+
+func toJSONWebKey(key *jwtKey) (jwk systemRes.JSONWebKey, err error) {
+	jwk = systemRes.JSONWebKey{Kid: key.kid, Use: "sig", Alg: key.alg}
+	encode := base64.RawURLEncoding.EncodeToString
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encode(public.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = public.Curve.Params().Name
+		jwk.X = encode(public.X.FillBytes(make([]byte, size)))
+		jwk.Y = encode(public.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encode(public)
+	default:
+		err = fmt.Errorf("jwt签名密钥 %s 类型错误", key.kid)
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system"
+	"github.com/flipped-aurora/gin-vue-admin/server/model/system/request"
+)
+
+func setupJwtKeyTest(t *testing.T, method string) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&system.SysJwtKey{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	global.GVA_DB = db
+	global.GVA_CONFIG.JWT.SigningKey = "test"
+	global.GVA_CONFIG.JWT.ExpiresTime = "1h"
+	global.GVA_CONFIG.JWT.SigningMethod = method
+	global.GVA_CONFIG.JWT.KeyRotation = "1h"
+	global.GVA_CONFIG.JWT.KeyGracePeriod = "2h"
+	jwtKeys.invalidate()
+	t.Cleanup(func() {
+		global.GVA_CONFIG.JWT.SigningMethod = ""
+		jwtKeys.invalidate()
+	})
+}
+
+func TestJwtAsymmetricSigning(t *testing.T) {
+	for _, method := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(method, func(t *testing.T) {
+			setupJwtKeyTest(t, method)
+			j := NewJWT()
+			token, err := j.CreateToken(j.CreateClaims(request.BaseClaims{Username: "test"}))
+			if err != nil {
+				t.Fatalf("CreateToken: %v", err)
+			}
+			claims, err := j.ParseToken(token)
+			if err != nil || claims.Username != "test" {
+				t.Fatalf("ParseToken = %v, %v", claims, err)
+			}
+			set, err := JwtKeySet()
+			if err != nil || len(set.Keys) != 1 || set.Keys[0].Alg != method {
+				t.Fatalf("JwtKeySet = %+v, %v", set, err)
+			}
+
+			// 切换回HS256后 非对称签名的令牌不再被接受 反之亦然
+			global.GVA_CONFIG.JWT.SigningMethod = "HS256"
+			hsToken, _ := j.CreateToken(j.CreateClaims(request.BaseClaims{Username: "test"}))
+			if _, err = j.ParseToken(token); err == nil {
+				t.Error("asymmetric token should be rejected in HS256 mode")
+			}
+			global.GVA_CONFIG.JWT.SigningMethod = method
+			if _, err = j.ParseToken(hsToken); !errors.Is(err, TokenSignatureInvalid) {
+				t.Errorf("HS256 token should be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRotateJwtKeys(t *testing.T) {
+	setupJwtKeyTest(t, "ES256")
+	j := NewJWT()
+	oldToken, err := j.CreateToken(j.CreateClaims(request.BaseClaims{Username: "test"}))
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	if rotated, err := RotateJwtKeys(); err != nil || rotated {
+		t.Fatalf("fresh key should not rotate: %v, %v", rotated, err)
+	}
+
+	// 密钥超过轮换周期
+	global.GVA_DB.Model(&system.SysJwtKey{}).Where("1 = 1").Update("created_at", time.Now().Add(-2*time.Hour))
+	if rotated, err := RotateJwtKeys(); err != nil || !rotated {
+		t.Fatalf("RotateJwtKeys = %v, %v", rotated, err)
+	}
+	newToken, _ := j.CreateToken(j.CreateClaims(request.BaseClaims{Username: "test"}))
+	if _, err = j.ParseToken(oldToken); err != nil {
+		t.Errorf("token signed by retired key should be valid in grace period: %v", err)
+	}
+	if _, err = j.ParseToken(newToken); err != nil {
+		t.Errorf("ParseToken new token: %v", err)
+	}
+	if set, _ := JwtKeySet(); len(set.Keys) != 2 {
+		t.Errorf("JWKS keys = %d, want 2", len(set.Keys))
+	}
+
+	// 超过宽限期后旧密钥被删除
+	global.GVA_DB.Model(&system.SysJwtKey{}).Where("retired_at IS NOT NULL").Update("retired_at", time.Now().Add(-3*time.Hour))
+	if _, err = RotateJwtKeys(); err != nil {
+		t.Fatalf("RotateJwtKeys: %v", err)
+	}
+	jwtKeys.invalidate()
+	if _, err = j.ParseToken(oldToken); err == nil {
+		t.Error("token signed by pruned key should be rejected")
+	}
+	if set, _ := JwtKeySet(); len(set.Keys) != 1 {
+		t.Errorf("JWKS keys = %d, want 1", len(set.Keys))
+	}
+}
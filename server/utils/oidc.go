@@ -0,0 +1,352 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+	systemRes "github.com/flipped-aurora/gin-vue-admin/server/model/system/response"
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	oidcMetadataTTL     = time.Hour        // 身份提供方元数据缓存时长
+	oidcKeysTTL         = time.Hour        // 身份提供方公钥缓存时长
+	oidcKeysMissRefresh = 10 * time.Second // 遇到未知kid时重新获取公钥的最小间隔
+	oidcLeeway          = time.Minute      // 校验ID令牌时间时允许的时钟误差
+)
+
+// oidcSigningMethods ID令牌允许的签名算法 不接受对称算法和none
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// oidcMetadata 身份提供方元数据 见 OpenID Connect Discovery 1.0
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// OidcToken 授权码换取的令牌
+type OidcToken struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// OidcProvider OIDC依赖方客户端 实现授权码模式及PKCE
+type OidcProvider struct {
+	cfg    config.OIDC
+	client *http.Client
+
+	mu         sync.Mutex
+	metadata   *oidcMetadata
+	metadataAt time.Time
+	keys       map[string]crypto.PublicKey
+	keysAt     time.Time
+}
+
+// NewOidcProvider 按配置创建OIDC客户端 元数据和公钥在首次使用时获取
+func NewOidcProvider(cfg config.OIDC) *OidcProvider {
+	return &OidcProvider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// OidcRandom 生成n字节的随机串 base64url编码 用于state、nonce和PKCE校验码
+func OidcRandom(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PkceChallenge 按S256方式计算PKCE挑战码
+func PkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+//@function: AuthCodeURL
+//@description: 生成跳转到身份提供方的授权地址
+//@param: state string, nonce string, verifier string
+//@return: string, error
+
+func (p *OidcProvider) AuthCodeURL(state, nonce, verifier string) (string, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.cfg.ClientID)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("scope", strings.Join(scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", PkceChallenge(verifier))
+	v.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return metadata.AuthorizationEndpoint + sep + v.Encode(), nil
+}
+
+//@function: Exchange
+//@description: 使用授权码和PKCE校验码换取令牌
+//@param: code string, verifier string
+//@return: token OidcToken, err error
+
+func (p *OidcProvider) Exchange(code, verifier string) (token OidcToken, err error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return
+	}
+	v := url.Values{}
+	v.Set("grant_type", "authorization_code")
+	v.Set("code", code)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("client_id", p.cfg.ClientID)
+	v.Set("code_verifier", verifier)
+	req, err := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(v.Encode()))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		// client_secret_basic 见 RFC 6749 2.3.1
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(body, &token); err != nil {
+		return token, fmt.Errorf("解析令牌响应失败: %w", err)
+	}
+	if token.Error != "" {
+		return token, fmt.Errorf("换取令牌失败: %s %s", token.Error, token.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return token, fmt.Errorf("换取令牌失败: %s", resp.Status)
+	}
+	if token.IDToken == "" {
+		return token, errors.New("身份提供方未返回id_token")
+	}
+	return token, nil
+}
+
+//@function: VerifyIDToken
+//@description: 校验ID令牌的签名、签发者、受众、有效期和nonce 返回其中的claims
+//@param: raw string, nonce string
+//@return: claims jwt.MapClaims, err error
+
+func (p *OidcProvider) VerifyIDToken(raw, nonce string) (claims jwt.MapClaims, err error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return
+	}
+	claims = jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(kid)
+	},
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(oidcLeeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("id_token校验失败: %w", err)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("id_token校验失败: nonce不匹配")
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, errors.New("id_token校验失败: 缺少sub")
+	}
+	return claims, nil
+}
+
+//@function: UserInfo
+//@description: 获取用户信息 身份提供方未提供userinfo端点时返回空
+//@param: accessToken string
+//@return: claims map[string]interface{}, err error
+
+func (p *OidcProvider) UserInfo(accessToken string) (claims map[string]interface{}, err error) {
+	metadata, err := p.discover()
+	if err != nil || metadata.UserinfoEndpoint == "" || accessToken == "" {
+		return
+	}
+	req, err := http.NewRequest(http.MethodGet, metadata.UserinfoEndpoint, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	err = p.getJSON(req, &claims)
+	return
+}
+
+// discover 获取并缓存身份提供方元数据
+func (p *OidcProvider) discover() (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil && time.Since(p.metadataAt) < oidcMetadataTTL {
+		return p.metadata, nil
+	}
+	issuer := strings.TrimSuffix(p.cfg.Issuer, "/")
+	if issuer == "" {
+		return nil, errors.New("未配置OIDC身份提供方地址")
+	}
+	req, err := http.NewRequest(http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var metadata oidcMetadata
+	if err = p.getJSON(req, &metadata); err != nil {
+		return nil, fmt.Errorf("获取OIDC元数据失败: %w", err)
+	}
+	// 签发者必须与配置一致 防止元数据被替换
+	if strings.TrimSuffix(metadata.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("OIDC元数据签发者 %s 与配置不一致", metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JwksURI == "" {
+		return nil, errors.New("OIDC元数据缺少必要的端点")
+	}
+	p.metadata, p.metadataAt = &metadata, time.Now()
+	return p.metadata, nil
+}
+
+// publicKey 按kid获取身份提供方的验签公钥 未命中时重新获取公钥集合
+func (p *OidcProvider) publicKey(kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.lookupKey(kid); ok && time.Since(p.keysAt) < oidcKeysTTL {
+		return key, nil
+	}
+	if time.Since(p.keysAt) < oidcKeysMissRefresh {
+		return nil, fmt.Errorf("未找到kid为 %s 的公钥", kid)
+	}
+	req, err := http.NewRequest(http.MethodGet, p.metadata.JwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set systemRes.JSONWebKeySet
+	if err = p.getJSON(req, &set); err != nil {
+		return nil, fmt.Errorf("获取OIDC公钥失败: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := parseJSONWebKey(jwk); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys, p.keysAt = keys, time.Now()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未找到kid为 %s 的公钥", kid)
+}
+
+// lookupKey 令牌未携带kid且只有一个公钥时使用该公钥
+func (p *OidcProvider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if key, ok := p.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+func (p *OidcProvider) getJSON(req *http.Request, v interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s", req.URL.Path, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// parseJSONWebKey 将JWK转换为公钥 支持RSA、EC和Ed25519
+func parseJSONWebKey(jwk systemRes.JSONWebKey) (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的曲线: %s", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := decode(jwk.X)
+		if err != nil || jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("不支持的OKP公钥: %s", jwk.Crv)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("不支持的密钥类型: %s", jwk.Kty)
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+)
+
+// PasswordPolicyError 密码不符合密码策略 错误信息可直接提示用户
+type PasswordPolicyError string
+
+func (e PasswordPolicyError) Error() string {
+	return string(e)
+}
+
+// ValidatePassword 按密码策略校验密码 不符合时返回 PasswordPolicyError
+func ValidatePassword(policy config.PasswordPolicy, username, password string) error {
+	if n := utf8.RuneCountInString(password); n < policy.MinLength {
+		return PasswordPolicyError(fmt.Sprintf("密码长度不能少于%d位", policy.MinLength))
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	switch {
+	case policy.RequireUpper && !upper:
+		return PasswordPolicyError("密码必须包含大写字母")
+	case policy.RequireLower && !lower:
+		return PasswordPolicyError("密码必须包含小写字母")
+	case policy.RequireDigit && !digit:
+		return PasswordPolicyError("密码必须包含数字")
+	case policy.RequireSymbol && !symbol:
+		return PasswordPolicyError("密码必须包含特殊字符")
+	}
+	lowerPassword := strings.ToLower(password)
+	for _, word := range policy.BannedWords {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" && strings.Contains(lowerPassword, word) {
+			return PasswordPolicyError("密码不能包含常见或禁用的词语")
+		}
+	}
+	if username = strings.ToLower(strings.TrimSpace(username)); len(username) >= 3 && strings.Contains(lowerPassword, username) {
+		return PasswordPolicyError("密码不能包含用户名")
+	}
+	return nil
+}
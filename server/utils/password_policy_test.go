@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+)
+
+func TestValidatePassword(t *testing.T) {
+	policy := config.PasswordPolicy{
+		MinLength:     8,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		BannedWords:   []string{"Company"},
+	}
+	tests := []struct {
+		password string
+		wantErr  string
+	}{
+		{"Ab1!", "密码长度不能少于8位"},
+		{"abcdefg1!", "密码必须包含大写字母"},
+		{"ABCDEFG1!", "密码必须包含小写字母"},
+		{"Abcdefgh!", "密码必须包含数字"},
+		{"Abcdefgh1", "密码必须包含特殊字符"},
+		{"myCOMPANY1!", "密码不能包含常见或禁用的词语"},
+		{"xAdmin-2024", "密码不能包含用户名"},
+		{"Str0ng!Pass", ""},
+	}
+	for _, tt := range tests {
+		err := ValidatePassword(policy, "admin", tt.password)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.password, err)
+			}
+			continue
+		}
+		var policyErr PasswordPolicyError
+		if !errors.As(err, &policyErr) || policyErr.Error() != tt.wantErr {
+			t.Errorf("%s: err = %v, want %s", tt.password, err, tt.wantErr)
+		}
+	}
+}
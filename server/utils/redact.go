@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+)
+
+// DefaultRedactFields 始终脱敏的字段名 字段名以这些词结尾时同样脱敏 如 oldPassword refresh_token
+var DefaultRedactFields = []string{"password", "newPassword", "token", "secret", "captcha", "recoveryCode", "recoveryCodes"}
+
+// DefaultRedactRules 始终生效的路径规则 两步验证的 otpauth 链接中包含密钥
+var DefaultRedactRules = []config.RedactRule{
+	{Path: "/user/setupTotp", Resp: []string{"data.uri"}},
+}
+
+const defaultRedactMask = "******"
+
+// Redactor 操作记录脱敏器 按字段名和JSON路径替换请求参数与响应中的敏感值
+type Redactor struct {
+	mask   string
+	fields []string
+	rules  []config.RedactRule
+}
+
+// NewRedactor 按配置创建脱敏器 配置中的字段和规则追加在默认字段和规则之后
+func NewRedactor(cfg config.Redact) *Redactor {
+	r := &Redactor{mask: cfg.Mask, rules: append(DefaultRedactRules[:len(DefaultRedactRules):len(DefaultRedactRules)], cfg.Rules...)}
+	if r.mask == "" {
+		r.mask = defaultRedactMask
+	}
+	r.fields = appendRedactFields(nil, DefaultRedactFields)
+	r.fields = appendRedactFields(r.fields, cfg.Fields)
+	return r
+}
+
+//@function: RedactBody
+//@description: 脱敏请求参数 支持JSON和表单格式 其他内容原样返回
+//@param: reqPath string, body string
+//@return: string
+
+func (r *Redactor) RedactBody(reqPath string, body string) string {
+	fields, paths := r.match(reqPath, func(rule config.RedactRule) []string { return rule.Body })
+	if out, ok := r.redactJSON(body, fields, paths); ok {
+		return out
+	}
+	return r.redactForm(body, fields, paths)
+}
+
+//@function: RedactResp
+//@description: 脱敏响应内容 仅处理JSON 其他内容原样返回
+//@param: reqPath string, resp string
+//@return: string
+
+func (r *Redactor) RedactResp(reqPath string, resp string) string {
+	fields, paths := r.match(reqPath, func(rule config.RedactRule) []string { return rule.Resp })
+	out, _ := r.redactJSON(resp, fields, paths)
+	return out
+}
+
+// match 汇总命中请求路径的规则 返回生效的字段名和JSON路径
+func (r *Redactor) match(reqPath string, pathsOf func(config.RedactRule) []string) ([]string, [][]string) {
+	// 限制容量 追加规则字段时不改写共享的默认字段
+	fields := r.fields[:len(r.fields):len(r.fields)]
+	var paths [][]string
+	for _, rule := range r.rules {
+		if !matchRedactPath(rule.Path, reqPath) {
+			continue
+		}
+		fields = appendRedactFields(fields, rule.Fields)
+		for _, p := range pathsOf(rule) {
+			if p = strings.TrimSpace(p); p != "" {
+				paths = append(paths, strings.Split(p, "."))
+			}
+		}
+	}
+	return fields, paths
+}
+
+// redactJSON 解析JSON并脱敏 不是JSON对象或数组时返回 false
+func (r *Redactor) redactJSON(data string, fields []string, paths [][]string) (string, bool) {
+	trimmed := strings.TrimSpace(data)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return data, false
+	}
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return data, false
+	}
+	value = r.maskFields(value, fields)
+	for _, segments := range paths {
+		value = r.maskPath(value, segments)
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return data, false
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), true
+}
+
+// redactForm 脱敏表单格式的参数 JSON路径只取单层字段名
+func (r *Redactor) redactForm(data string, fields []string, paths [][]string) string {
+	if !strings.Contains(data, "=") {
+		return data
+	}
+	values, err := url.ParseQuery(data)
+	if err != nil {
+		return data
+	}
+	changed := false
+	for key, vs := range values {
+		if !matchRedactField(key, fields) && !matchRedactKey(key, paths) {
+			continue
+		}
+		for i := range vs {
+			vs[i] = r.mask
+		}
+		changed = true
+	}
+	if !changed {
+		return data
+	}
+	return values.Encode()
+}
+
+// maskFields 递归替换字段名命中的值 null 不替换
+func (r *Redactor) maskFields(value interface{}, fields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if child != nil && matchRedactField(key, fields) {
+				v[key] = r.mask
+				continue
+			}
+			v[key] = r.maskFields(child, fields)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = r.maskFields(child, fields)
+		}
+	}
+	return value
+}
+
+// maskPath 替换JSON路径命中的值 * 匹配任意字段或数组元素 数组会被自动展开
+func (r *Redactor) maskPath(value interface{}, segments []string) interface{} {
+	if len(segments) == 0 {
+		if value == nil {
+			return nil
+		}
+		return r.mask
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if segments[0] == "*" || segments[0] == key {
+				v[key] = r.maskPath(child, segments[1:])
+			}
+		}
+	case []interface{}:
+		rest := segments
+		if segments[0] == "*" {
+			rest = segments[1:]
+		}
+		for i, child := range v {
+			v[i] = r.maskPath(child, rest)
+		}
+	}
+	return value
+}
+
+// matchRedactPath 判断规则路径是否命中请求路径 以 /** 结尾时按前缀匹配
+func matchRedactPath(pattern, reqPath string) bool {
+	if pattern == "" {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return reqPath == prefix || strings.HasPrefix(reqPath, prefix+"/")
+	}
+	matched, err := path.Match(pattern, reqPath)
+	return err == nil && matched
+}
+
+// matchRedactField 字段名与脱敏字段相同(忽略大小写) 或以脱敏字段结尾且在驼峰、下划线处分隔时命中
+func matchRedactField(key string, fields []string) bool {
+	lower := strings.ToLower(key)
+	for _, field := range fields {
+		if lower == field {
+			return true
+		}
+		if len(lower) != len(key) || !strings.HasSuffix(lower, field) {
+			continue
+		}
+		i := len(key) - len(field)
+		if unicode.IsUpper(rune(key[i])) || key[i-1] == '_' || key[i-1] == '-' {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRedactKey 判断表单字段是否命中单层JSON路径
+func matchRedactKey(key string, paths [][]string) bool {
+	for _, segments := range paths {
+		if len(segments) == 1 && segments[0] == key {
+			return true
+		}
+	}
+	return false
+}
+
+func appendRedactFields(fields []string, extra []string) []string {
+	for _, field := range extra {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/config"
+)
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor(config.Redact{
+		Fields: []string{"idCard"},
+		Rules: []config.RedactRule{
+			{Path: "/user/**", Body: []string{"items.*.cardNo", "phone"}, Resp: []string{"data.user.phone"}},
+			{Path: "/order/*", Fields: []string{"address"}},
+		},
+	})
+
+	tests := []struct {
+		name string
+		path string
+		resp bool
+		in   string
+		want string
+	}{
+		{"默认字段", "/base/login", false, `{"username":"admin","passWord":"123456","captcha":"1234","captchaId":"x"}`, `{"captcha":"******","captchaId":"x","passWord":"******","username":"admin"}`},
+		{"字段后缀", "/user/changePassword", false, `{"oldPassword":"a","refresh_token":"b","tokenizer":"c"}`, `{"oldPassword":"******","refresh_token":"******","tokenizer":"c"}`},
+		{"追加字段与null", "/a", false, `{"idCard":"1","secret":null}`, `{"idCard":"******","secret":null}`},
+		{"JSON路径", "/user/setUserInfo", false, `{"items":[{"cardNo":"1","n":1},{"cardNo":"2"}],"phone":"13800000000"}`, `{"items":[{"cardNo":"******","n":1},{"cardNo":"******"}],"phone":"******"}`},
+		{"规则路径未命中", "/other", false, `{"phone":"13800000000"}`, `{"phone":"13800000000"}`},
+		{"规则字段", "/order/create", false, `{"address":"x","a":"<b>"}`, `{"a":"<b>","address":"******"}`},
+		{"表单", "/user/login", false, `password=1&phone=2&name=3`, `name=3&password=%2A%2A%2A%2A%2A%2A&phone=%2A%2A%2A%2A%2A%2A`},
+		{"非结构化内容", "/user/login", false, `[文件]`, `[文件]`},
+		{"两步验证密钥", "/user/setupTotp", true, `{"code":0,"data":{"secret":"JBSWY3DP","uri":"otpauth://totp/gva:admin?secret=JBSWY3DP"}}`, `{"code":0,"data":{"secret":"******","uri":"******"}}`},
+		{"恢复码", "/user/regenerateRecoveryCodes", true, `{"code":0,"data":{"recoveryCodes":["a1b2","c3d4"]}}`, `{"code":0,"data":{"recoveryCodes":"******"}}`},
+		{"恢复码剩余数量", "/user/getTotpStatus", true, `{"data":{"recoveryCodesLeft":8}}`, `{"data":{"recoveryCodesLeft":8}}`},
+		{"响应", "/user/getUserInfo", true, `{"code":0,"data":{"token":"t","user":{"phone":"1","id":12345678901234567890}}}`, `{"code":0,"data":{"token":"******","user":{"id":12345678901234567890,"phone":"******"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if tt.resp {
+				got = r.RedactResp(tt.path, tt.in)
+			} else {
+				got = r.RedactBody(tt.path, tt.in)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
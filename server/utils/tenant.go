@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TenantColumn 按租户隔离的表中记录所属租户的字段
+const TenantColumn = "tenant_id"
+
+type tenantKey struct{}
+
+// tenantTable 嵌入global.GVA_TENANT的模型自动按租户隔离
+type tenantTable interface {
+	TenantTable()
+}
+
+var (
+	tenantTables     sync.Map // 表名 -> struct{}
+	tenantRegisterMu sync.Mutex
+	tenantRegistered sync.Map // *gorm.Config -> struct{}
+)
+
+// WithTenant 将请求所属的租户写入context 平台用户(租户ID为0)不做租户过滤
+func WithTenant(ctx context.Context, tenantID uint) context.Context {
+	if tenantID == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext 从context读取请求所属的租户
+func TenantFromContext(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	tenantID, ok := ctx.Value(tenantKey{}).(uint)
+	return tenantID, ok && tenantID != 0
+}
+
+// TenantDomain 租户对应的casbin域 平台用户使用默认域
+func TenantDomain(tenantID uint) string {
+	if tenantID == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(tenantID))
+}
+
+// RegisterTenantTables 登记按租户隔离的表 用于未嵌入global.GVA_TENANT或通过Table()访问的表
+func RegisterTenantTables(tables ...string) {
+	for _, table := range tables {
+		tenantTables.Store(table, struct{}{})
+	}
+}
+
+// RegisterTenantCallbacks 为数据库实例注册租户回调 同一实例只注册一次
+// 回调从 Statement.Context 中读取请求所属的租户 未携带租户的语句(平台用户、启动任务、定时任务等)不做过滤
+func RegisterTenantCallbacks(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	if _, ok := tenantRegistered.Load(db.Config); ok {
+		return nil
+	}
+	tenantRegisterMu.Lock()
+	defer tenantRegisterMu.Unlock()
+	if _, ok := tenantRegistered.Load(db.Config); ok {
+		return nil
+	}
+	if db.Callback().Query().Get("tenant:before_query") == nil {
+		if err := db.Callback().Query().Before("gorm:query").Register("tenant:before_query", tenantWhere); err != nil {
+			return err
+		}
+		if err := db.Callback().Row().Before("gorm:row").Register("tenant:before_row", tenantWhere); err != nil {
+			return err
+		}
+		if err := db.Callback().Update().Before("gorm:update").Register("tenant:before_update", tenantBeforeUpdate); err != nil {
+			return err
+		}
+		if err := db.Callback().Delete().Before("gorm:delete").Register("tenant:before_delete", tenantWhere); err != nil {
+			return err
+		}
+		if err := db.Callback().Create().Before("gorm:create").Register("tenant:before_create", tenantBeforeCreate); err != nil {
+			return err
+		}
+	}
+	tenantRegistered.Store(db.Config, struct{}{})
+	return nil
+}
+
+// SkipTenant 跳过租户过滤 供需要跨租户访问的平台逻辑使用
+func SkipTenant(db *gorm.DB) *gorm.DB {
+	return db.Set("skip_tenant", true)
+}
+
+// tenantScope 获取语句所属的租户 语句未携带租户、显式跳过或表未按租户隔离时返回false
+func tenantScope(db *gorm.DB) (uint, bool) {
+	if db.Statement == nil || db.Statement.Context == nil {
+		return 0, false
+	}
+	if skip, ok := db.Get("skip_tenant"); ok {
+		if b, ok := skip.(bool); ok && b {
+			return 0, false
+		}
+	}
+	tenantID, ok := TenantFromContext(db.Statement.Context)
+	if !ok || !isTenantTable(db.Statement) {
+		return 0, false
+	}
+	return tenantID, true
+}
+
+// isTenantTable 判断语句访问的表是否按租户隔离
+func isTenantTable(stmt *gorm.Statement) bool {
+	if stmt.Table != "" {
+		if _, ok := tenantTables.Load(stmt.Table); ok {
+			return true
+		}
+	}
+	if stmt.Schema == nil || stmt.Schema.LookUpField(TenantColumn) == nil {
+		return false
+	}
+	_, ok := reflect.New(stmt.Schema.ModelType).Interface().(tenantTable)
+	return ok
+}
+
+// tenantWhere 查询、删除时只能访问所属租户的数据
+func tenantWhere(db *gorm.DB) {
+	tenantID, ok := tenantScope(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: TenantColumn}, Value: tenantID},
+	}})
+}
+
+// tenantBeforeUpdate 更新时只能修改所属租户的数据 且不能修改数据所属的租户
+func tenantBeforeUpdate(db *gorm.DB) {
+	if _, ok := tenantScope(db); !ok {
+		return
+	}
+	tenantWhere(db)
+	db.Statement.Omits = append(db.Statement.Omits, TenantColumn)
+}
+
+// tenantBeforeCreate 新建的数据归属于请求所属的租户
+func tenantBeforeCreate(db *gorm.DB) {
+	tenantID, ok := tenantScope(db)
+	if !ok {
+		return
+	}
+	if values, ok := db.Statement.Dest.(map[string]interface{}); ok {
+		values[TenantColumn] = tenantID
+		return
+	}
+	if db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(TenantColumn)
+	if field == nil {
+		return
+	}
+	setTenant := func(rv reflect.Value) {
+		if err := field.Set(db.Statement.Context, rv, tenantID); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			setTenant(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		setTenant(rv)
+	}
+}
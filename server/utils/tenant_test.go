@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/flipped-aurora/gin-vue-admin/server/global"
+)
+
+type tenantOrder struct {
+	global.GVA_MODEL
+	global.GVA_TENANT
+	Name string
+}
+
+type tenantLog struct {
+	ID       uint
+	TenantID uint
+	Name     string
+}
+
+func TestTenantCallbacks(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&tenantOrder{}, &tenantLog{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err = RegisterTenantCallbacks(db); err != nil {
+		t.Fatalf("RegisterTenantCallbacks: %v", err)
+	}
+	if err = RegisterTenantCallbacks(db); err != nil {
+		t.Fatalf("register twice: %v", err)
+	}
+	tenantA := db.WithContext(WithTenant(context.Background(), 1))
+	tenantB := db.WithContext(WithTenant(context.Background(), 2))
+
+	// 新建的数据归属于请求所属的租户 忽略请求中携带的租户
+	orders := []tenantOrder{{Name: "a1", GVA_TENANT: global.GVA_TENANT{TenantID: 2}}, {Name: "a2"}}
+	tenantA.Create(&orders)
+	tenantB.Create(&tenantOrder{Name: "b1"})
+	for _, order := range orders {
+		if order.TenantID != 1 {
+			t.Errorf("created order %s tenant = %d, want 1", order.Name, order.TenantID)
+		}
+	}
+
+	var count int64
+	tenantA.Model(&tenantOrder{}).Count(&count)
+	if count != 2 {
+		t.Errorf("tenant 1 sees %d orders, want 2", count)
+	}
+	var order tenantOrder
+	if err = tenantB.First(&order, orders[0].ID).Error; err == nil {
+		t.Error("tenant 2 should not read tenant 1's order")
+	}
+	// 平台用户及后台任务不做过滤
+	db.Model(&tenantOrder{}).Count(&count)
+	if count != 3 {
+		t.Errorf("platform sees %d orders, want 3", count)
+	}
+
+	// 只能修改、删除所属租户的数据 且不能修改数据所属的租户
+	if rows := tenantB.Model(&tenantOrder{}).Where("id = ?", orders[0].ID).Update("name", "x").RowsAffected; rows != 0 {
+		t.Errorf("tenant 2 updated %d rows of tenant 1", rows)
+	}
+	tenantA.Model(&orders[1]).Updates(map[string]interface{}{"name": "a2x", "tenant_id": 2})
+	db.First(&order, orders[1].ID)
+	if order.Name != "a2x" || order.TenantID != 1 {
+		t.Errorf("unexpected update result %+v", order)
+	}
+	if rows := tenantB.Delete(&tenantOrder{}, orders[0].ID).RowsAffected; rows != 0 {
+		t.Errorf("tenant 2 deleted %d rows of tenant 1", rows)
+	}
+	SkipTenant(tenantB).Model(&tenantOrder{}).Count(&count)
+	if count != 3 {
+		t.Errorf("skip tenant sees %d orders, want 3", count)
+	}
+
+	// 未嵌入租户字段的表登记后同样按租户过滤
+	db.Create(&[]tenantLog{{TenantID: 1, Name: "l1"}, {TenantID: 2, Name: "l2"}})
+	tenantA.Table("tenant_logs").Count(&count)
+	if count != 2 {
+		t.Errorf("unregistered table sees %d rows, want 2", count)
+	}
+	RegisterTenantTables("tenant_logs")
+	tenantA.Table("tenant_logs").Count(&count)
+	if count != 1 {
+		t.Errorf("registered table sees %d rows, want 1", count)
+	}
+}
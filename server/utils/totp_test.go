@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录B SHA1 测试向量 取后6位
+func TestTotpCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "59", unix: 59, want: "287082"},
+		{name: "1111111109", unix: 1111111109, want: "081804"},
+		{name: "1111111111", unix: 1111111111, want: "050471"},
+		{name: "1234567890", unix: 1234567890, want: "005924"},
+		{name: "2000000000", unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TotpCode(secret, uint64(tt.unix/totpPeriod))
+			if err != nil {
+				t.Fatalf("TotpCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TotpCode() = %v, want %v", got, tt.want)
+			}
+			if _, ok := ValidateTotp(secret, tt.want, time.Unix(tt.unix+totpPeriod, 0)); !ok {
+				t.Errorf("ValidateTotp() should accept previous step")
+			}
+			if _, ok := ValidateTotp(secret, tt.want, time.Unix(tt.unix+3*totpPeriod, 0)); ok {
+				t.Errorf("ValidateTotp() should reject stale code")
+			}
+		})
+	}
+}